@@ -6,6 +6,13 @@ go test ./...
 echo "Building ..."
 [ ! -d bin ] && mkdir bin
 
+VERSION=$(git describe --tags --always 2>/dev/null || echo dev)
+LDFLAGS="-X github.com/crabtree/defeway-toolbox/internal/cli.Version=$VERSION"
+
+CGO_ENABLED=0 GOOS=linux GOARCH=amd64 go build -ldflags "$LDFLAGS" -o bin/defeway ./cmd/defeway
+CGO_ENABLED=0 GOOS=windows GOARCH=amd64 go build -ldflags "$LDFLAGS" -o bin/defeway-amd64.exe ./cmd/defeway
+CGO_ENABLED=0 GOOS=windows GOARCH=386 go build -ldflags "$LDFLAGS" -o bin/defeway-x86.exe ./cmd/defeway
+
 CGO_ENABLED=0 GOOS=linux GOARCH=amd64 go build -o bin/defewaydownload ./cmd/download
 CGO_ENABLED=0 GOOS=windows GOARCH=amd64 go build -o bin/defewaydownload-amd64.exe ./cmd/download
 CGO_ENABLED=0 GOOS=windows GOARCH=386 go build -o bin/defewaydownload-x86.exe ./cmd/download
@@ -14,4 +21,36 @@ CGO_ENABLED=0 GOOS=linux GOARCH=amd64 go build -o bin/defewayscan ./cmd/scan
 CGO_ENABLED=0 GOOS=windows GOARCH=amd64 go build -o bin/defewayscan-amd64.exe ./cmd/scan
 CGO_ENABLED=0 GOOS=windows GOARCH=386 go build -o bin/defewayscan-x86.exe ./cmd/scan
 
+CGO_ENABLED=0 GOOS=linux GOARCH=amd64 go build -o bin/defewaydiff ./cmd/diff
+CGO_ENABLED=0 GOOS=windows GOARCH=amd64 go build -o bin/defewaydiff-amd64.exe ./cmd/diff
+CGO_ENABLED=0 GOOS=windows GOARCH=386 go build -o bin/defewaydiff-x86.exe ./cmd/diff
+
+CGO_ENABLED=0 GOOS=linux GOARCH=amd64 go build -o bin/defewayhealth ./cmd/health
+CGO_ENABLED=0 GOOS=windows GOARCH=amd64 go build -o bin/defewayhealth-amd64.exe ./cmd/health
+CGO_ENABLED=0 GOOS=windows GOARCH=386 go build -o bin/defewayhealth-x86.exe ./cmd/health
+
+CGO_ENABLED=0 GOOS=linux GOARCH=amd64 go build -o bin/defewayexporter ./cmd/exporter
+CGO_ENABLED=0 GOOS=windows GOARCH=amd64 go build -o bin/defewayexporter-amd64.exe ./cmd/exporter
+CGO_ENABLED=0 GOOS=windows GOARCH=386 go build -o bin/defewayexporter-x86.exe ./cmd/exporter
+
+CGO_ENABLED=0 GOOS=linux GOARCH=amd64 go build -o bin/defewayaudit ./cmd/audit
+CGO_ENABLED=0 GOOS=windows GOARCH=amd64 go build -o bin/defewayaudit-amd64.exe ./cmd/audit
+CGO_ENABLED=0 GOOS=windows GOARCH=386 go build -o bin/defewayaudit-x86.exe ./cmd/audit
+
+CGO_ENABLED=0 GOOS=linux GOARCH=amd64 go build -o bin/defewaytamper ./cmd/tamper
+CGO_ENABLED=0 GOOS=windows GOARCH=amd64 go build -o bin/defewaytamper-amd64.exe ./cmd/tamper
+CGO_ENABLED=0 GOOS=windows GOARCH=386 go build -o bin/defewaytamper-x86.exe ./cmd/tamper
+
+CGO_ENABLED=0 GOOS=linux GOARCH=amd64 go build -o bin/defewaytimelapse ./cmd/timelapse
+CGO_ENABLED=0 GOOS=windows GOARCH=amd64 go build -o bin/defewaytimelapse-amd64.exe ./cmd/timelapse
+CGO_ENABLED=0 GOOS=windows GOARCH=386 go build -o bin/defewaytimelapse-x86.exe ./cmd/timelapse
+
+CGO_ENABLED=0 GOOS=linux GOARCH=amd64 go build -o bin/defewaymosaic ./cmd/mosaic
+CGO_ENABLED=0 GOOS=windows GOARCH=amd64 go build -o bin/defewaymosaic-amd64.exe ./cmd/mosaic
+CGO_ENABLED=0 GOOS=windows GOARCH=386 go build -o bin/defewaymosaic-x86.exe ./cmd/mosaic
+
+CGO_ENABLED=0 GOOS=linux GOARCH=amd64 go build -o bin/defewaywatch ./cmd/watch
+CGO_ENABLED=0 GOOS=windows GOARCH=amd64 go build -o bin/defewaywatch-amd64.exe ./cmd/watch
+CGO_ENABLED=0 GOOS=windows GOARCH=386 go build -o bin/defewaywatch-x86.exe ./cmd/watch
+
 echo "... DONE!"
\ No newline at end of file
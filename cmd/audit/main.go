@@ -0,0 +1,12 @@
+package main
+
+import (
+	"os"
+
+	"github.com/crabtree/defeway-toolbox/internal/cli"
+)
+
+// defewayaudit is the alias of the "defeway audit" command.
+func main() {
+	os.Exit(cli.RunCommand("audit", os.Args[1:]))
+}
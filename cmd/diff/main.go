@@ -0,0 +1,12 @@
+package main
+
+import (
+	"os"
+
+	"github.com/crabtree/defeway-toolbox/internal/cli"
+)
+
+// defewaydiff is the alias of the "defeway diff" command.
+func main() {
+	os.Exit(cli.RunCommand("diff", os.Args[1:]))
+}
@@ -0,0 +1,12 @@
+package main
+
+import (
+	"os"
+
+	"github.com/crabtree/defeway-toolbox/internal/cli"
+)
+
+// defewayexporter is the alias of the "defeway exporter" command.
+func main() {
+	os.Exit(cli.RunCommand("exporter", os.Args[1:]))
+}
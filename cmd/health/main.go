@@ -0,0 +1,12 @@
+package main
+
+import (
+	"os"
+
+	"github.com/crabtree/defeway-toolbox/internal/cli"
+)
+
+// defewayhealth is the alias of the "defeway health" command.
+func main() {
+	os.Exit(cli.RunCommand("health", os.Args[1:]))
+}
@@ -0,0 +1,12 @@
+package main
+
+import (
+	"os"
+
+	"github.com/crabtree/defeway-toolbox/internal/cli"
+)
+
+// defewaymosaic is the alias of the "defeway mosaic" command.
+func main() {
+	os.Exit(cli.RunCommand("mosaic", os.Args[1:]))
+}
@@ -1,36 +1,12 @@
 package main
 
 import (
-	"log"
+	"os"
 
-	"github.com/crabtree/defeway-toolbox/internal/scanner"
-	"github.com/crabtree/defeway-toolbox/pkg/cmdtoolbox"
+	"github.com/crabtree/defeway-toolbox/internal/cli"
 )
 
+// defewayscan is the alias of the "defeway scan" command.
 func main() {
-	params, err := NewParams()
-	cmdtoolbox.DieOnError(err)
-
-	log.Println(params.Dump())
-
-	command := scanner.NewCommand(
-		paramsToCommandParams(params))
-
-	err = command.Run()
-	cmdtoolbox.DieOnError(err)
-}
-
-func paramsToCommandParams(params *params) scanner.ScannerParams {
-	return scanner.ScannerParams{
-		Concurrent:    params.Concurrent,
-		LogDir:        params.LogDir,
-		NetAddr:       params.NetAddr,
-		NetMask:       params.NetMask,
-		Password:      params.Password,
-		Ports:         params.Ports,
-		TLSSkipVerify: params.TLSSkipVerify,
-		Timeout:       params.Timeout,
-		Username:      params.Username,
-		WithSnapshots: params.WithSnapshots,
-	}
+	os.Exit(cli.RunCommand("scan", os.Args[1:]))
 }
@@ -0,0 +1,12 @@
+package main
+
+import (
+	"os"
+
+	"github.com/crabtree/defeway-toolbox/internal/cli"
+)
+
+// defewaytamper is the alias of the "defeway tamper" command.
+func main() {
+	os.Exit(cli.RunCommand("tamper", os.Args[1:]))
+}
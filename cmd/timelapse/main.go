@@ -0,0 +1,12 @@
+package main
+
+import (
+	"os"
+
+	"github.com/crabtree/defeway-toolbox/internal/cli"
+)
+
+// defewaytimelapse is the alias of the "defeway timelapse" command.
+func main() {
+	os.Exit(cli.RunCommand("timelapse", os.Args[1:]))
+}
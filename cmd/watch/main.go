@@ -0,0 +1,12 @@
+package main
+
+import (
+	"os"
+
+	"github.com/crabtree/defeway-toolbox/internal/cli"
+)
+
+// defewaywatch is the alias of the "defeway watch" command.
+func main() {
+	os.Exit(cli.RunCommand("watch", os.Args[1:]))
+}
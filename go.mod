@@ -2,4 +2,8 @@ module github.com/crabtree/defeway-toolbox
 
 go 1.13
 
-require github.com/stretchr/testify v1.7.0
+require (
+	github.com/stretchr/testify v1.7.0
+	golang.org/x/crypto v0.0.0-20211117183948-ae814b36b871
+	gopkg.in/yaml.v3 v3.0.1
+)
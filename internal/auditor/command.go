@@ -0,0 +1,76 @@
+package auditor
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/crabtree/defeway-toolbox/pkg/audit"
+	"github.com/crabtree/defeway-toolbox/pkg/health"
+	"github.com/crabtree/defeway-toolbox/pkg/inventory"
+)
+
+type command struct {
+	output  io.Writer
+	params  AuditorParams
+	results []health.Result
+}
+
+func NewCommand(params AuditorParams) *command {
+	return &command{
+		output: os.Stdout,
+		params: params,
+	}
+}
+
+func (c *command) Run() error {
+	inv, err := inventory.Load(c.params.Inventory)
+	if err != nil {
+		return err
+	}
+
+	policy, err := audit.LoadPolicy(c.params.Policy)
+	if err != nil {
+		return err
+	}
+
+	inv.Sort()
+	for _, device := range inv.Devices {
+		c.results = append(c.results, audit.Audit(device, policy))
+	}
+
+	if c.params.Format == FormatJSON {
+		return c.writeJSON()
+	}
+
+	return health.WriteReport(c.output, "AUDIT", c.results)
+}
+
+// Status returns the worst status of all audited devices.
+func (c *command) Status() health.Status {
+	return health.Worst(c.results)
+}
+
+type deviceReport struct {
+	Key      string
+	Address  string
+	Status   health.Status
+	Findings []health.Finding
+}
+
+func (c *command) writeJSON() error {
+	reports := make([]deviceReport, 0, len(c.results))
+	for _, result := range c.results {
+		reports = append(reports, deviceReport{
+			Key:      result.Key,
+			Address:  result.Address,
+			Status:   result.Status(),
+			Findings: result.Findings,
+		})
+	}
+
+	enc := json.NewEncoder(c.output)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(reports)
+}
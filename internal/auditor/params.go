@@ -0,0 +1,12 @@
+package auditor
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+type AuditorParams struct {
+	Format    string
+	Inventory string
+	Policy    string
+}
@@ -0,0 +1,100 @@
+package checker
+
+import (
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/crabtree/defeway-toolbox/pkg/health"
+	"github.com/crabtree/defeway-toolbox/pkg/inventory"
+	"github.com/crabtree/defeway-toolbox/pkg/snapshot"
+)
+
+type command struct {
+	output  io.Writer
+	params  CheckerParams
+	results []health.Result
+	mu      sync.Mutex
+}
+
+func NewCommand(params CheckerParams) *command {
+	return &command{
+		output: os.Stdout,
+		params: params,
+	}
+}
+
+func (c *command) Run() error {
+	var wg sync.WaitGroup
+
+	inv, err := inventory.Load(c.params.Inventory)
+	if err != nil {
+		return err
+	}
+
+	devChan := make(chan inventory.Device, len(inv.Devices))
+	for _, device := range inv.Devices {
+		devChan <- device
+	}
+	close(devChan)
+
+	for i := 0; i < c.params.Concurrent; i++ {
+		wg.Add(1)
+		go func(devChan <-chan inventory.Device) {
+			defer wg.Done()
+			c.check(devChan)
+		}(devChan)
+	}
+
+	wg.Wait()
+
+	sort.Slice(c.results, func(i, j int) bool {
+		return c.results[i].Address < c.results[j].Address
+	})
+
+	return health.WriteReport(c.output, "HEALTH", c.results)
+}
+
+// Status returns the worst status of all checked devices.
+func (c *command) Status() health.Status {
+	return health.Worst(c.results)
+}
+
+func (c *command) check(devChan <-chan inventory.Device) {
+	thresholds := health.Thresholds{
+		DiskUsageFail:   c.params.DiskUsageFail,
+		DiskUsageWarn:   c.params.DiskUsageWarn,
+		ExpectedCameras: c.params.ExpectedCameras,
+	}
+
+	for device := range devChan {
+		client := defewayclient.NewDeviceInfoClient(
+			c.params.ClientConfig(device))
+
+		info, err := client.Fetch()
+		result := health.Check(device, info, err, thresholds)
+
+		if err == nil && c.params.WithSnapshots && info.DeviceInfo != nil {
+			health.CheckSnapshots(&result, c.checkSnapshots(device, info.DeviceInfo.CamCount))
+		}
+
+		c.mu.Lock()
+		c.results = append(c.results, result)
+		c.mu.Unlock()
+	}
+}
+
+func (c *command) checkSnapshots(device inventory.Device, camCount uint8) []snapshot.ChannelResult {
+	client := defewayclient.NewSnapshotClient(c.params.ClientConfig(device))
+
+	channels := make([]int, camCount)
+	for ch := range channels {
+		channels[ch] = ch
+	}
+
+	results, _ := snapshot.CheckChannels(client, channels, c.params.FrozenInterval)
+
+	return results
+}
@@ -0,0 +1,19 @@
+package checker
+
+import (
+	"time"
+
+	"github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/crabtree/defeway-toolbox/pkg/inventory"
+)
+
+type CheckerParams struct {
+	ClientConfig    func(device inventory.Device) defewayclient.DefewayClientConfig
+	Concurrent      int
+	DiskUsageFail   float64
+	DiskUsageWarn   float64
+	ExpectedCameras int
+	FrozenInterval  time.Duration
+	Inventory       string
+	WithSnapshots   bool
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/crabtree/defeway-toolbox/internal/auditor"
+	"github.com/crabtree/defeway-toolbox/pkg/health"
+)
+
+var auditCommand = &command{
+	Name:          "audit",
+	Summary:       "audit the firmware and configuration of the inventory devices",
+	ErrorExitCode: health.ExitUnknown,
+	Run:           runAudit,
+}
+
+type auditParams struct {
+	Format    string
+	Inventory string
+	Policy    string
+}
+
+func (p *auditParams) Dump() string {
+	return fmt.Sprintf("Format=%s Inventory=%s Policy=%s",
+		p.Format, p.Inventory, p.Policy)
+}
+
+func newAuditParams(fs *flag.FlagSet, args []string) (*auditParams, error) {
+	format := fs.String("format", auditor.FormatText, "output format, text or json")
+	inventoryFile := fs.String("inventory", "", "path to the inventory file")
+	policyFile := fs.String("policy", "", "path to the policy file, JSON or YAML with the .yaml or .yml extension")
+
+	if err := parseFlags(fs, args); err != nil {
+		return nil, err
+	}
+
+	if *inventoryFile == "" {
+		return nil, fmt.Errorf("specify inventory file")
+	}
+
+	if *policyFile == "" {
+		return nil, fmt.Errorf("specify policy file")
+	}
+
+	if *format != auditor.FormatText && *format != auditor.FormatJSON {
+		return nil, fmt.Errorf("the format %s is not supported", *format)
+	}
+
+	return &auditParams{
+		Format:    *format,
+		Inventory: *inventoryFile,
+		Policy:    *policyFile,
+	}, nil
+}
+
+func runAudit(fs *flag.FlagSet, args []string) error {
+	params, err := newAuditParams(fs, args)
+	if err != nil {
+		return err
+	}
+
+	log.Println(params.Dump())
+
+	command := auditor.NewCommand(auditor.AuditorParams{
+		Format:    params.Format,
+		Inventory: params.Inventory,
+		Policy:    params.Policy,
+	})
+
+	if err := command.Run(); err != nil {
+		return err
+	}
+
+	return exitStatus(command.Status().ExitCode())
+}
@@ -0,0 +1,223 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/crabtree/defeway-toolbox/pkg/secret"
+)
+
+const programName = "defeway"
+
+// Version is set at build time with
+// -ldflags "-X github.com/crabtree/defeway-toolbox/internal/cli.Version=v1.0.0".
+var Version = "dev"
+
+type command struct {
+	Name    string
+	Summary string
+	// ErrorExitCode is used when the command fails, the monitoring plugin
+	// style commands exit with the UNKNOWN status.
+	ErrorExitCode int
+	Run           func(fs *flag.FlagSet, args []string) error
+}
+
+// exitStatus is returned by the commands which completed, but exit with the
+// non zero code, like the health check reporting the critical status.
+type exitStatus int
+
+func (s exitStatus) Error() string {
+	return fmt.Sprintf("exit status %d", int(s))
+}
+
+// flagError is the error of parsing the flags, the flag set has already
+// printed it together with the usage.
+type flagError struct {
+	err error
+}
+
+func (e flagError) Error() string {
+	return e.err.Error()
+}
+
+func commands() []*command {
+	return []*command{
+		scanCommand,
+		infoCommand,
+		snapshotCommand,
+		searchCommand,
+		downloadCommand,
+		diffCommand,
+		healthCommand,
+		exporterCommand,
+		auditCommand,
+		tamperCommand,
+		timelapseCommand,
+		mosaicCommand,
+		watchCommand,
+		configCommand,
+		credsCommand,
+		{
+			Name:    "version",
+			Summary: "print the version",
+			Run:     runVersion,
+		},
+	}
+}
+
+func findCommand(name string) *command {
+	for _, cmd := range commands() {
+		if cmd.Name == name {
+			return cmd
+		}
+	}
+
+	return nil
+}
+
+// Main runs the subcommand given as the first argument and returns the exit
+// code of the program.
+func Main(args []string) int {
+	if len(args) == 0 {
+		printUsage(os.Stderr)
+		return 2
+	}
+
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		if len(args) > 1 {
+			if cmd := findCommand(args[1]); cmd != nil {
+				return runCommand(cmd, programName+" "+cmd.Name, []string{"-h"})
+			}
+		}
+
+		printUsage(os.Stdout)
+		return 0
+	}
+
+	cmd := findCommand(args[0])
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+		printUsage(os.Stderr)
+		return 2
+	}
+
+	return runCommand(cmd, programName+" "+cmd.Name, args[1:])
+}
+
+// RunCommand runs the subcommand on behalf of the standalone binary, like
+// defewayscan, which is the alias of the "defeway scan" command.
+func RunCommand(name string, args []string) int {
+	cmd := findCommand(name)
+	if cmd == nil {
+		log.Printf("unknown command %q\n", name)
+		return 2
+	}
+
+	return runCommand(cmd, filepath.Base(os.Args[0]), args)
+}
+
+func runCommand(cmd *command, prog string, args []string) int {
+	// the errors of the HTTP clients contain the request URLs with the
+	// credentials
+	log.SetOutput(secret.NewWriter(os.Stderr))
+
+	fs := flag.NewFlagSet(prog, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s [flags]\n\n%s\n\nFlags:\n", prog, capitalize(cmd.Summary))
+		fs.PrintDefaults()
+	}
+
+	err := cmd.Run(fs, args)
+	if err == nil {
+		return 0
+	}
+
+	var status exitStatus
+	var flagErr flagError
+	switch {
+	case errors.As(err, &status):
+		return int(status)
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.As(err, &flagErr) && cmd.ErrorExitCode == 0:
+		return 2
+	case errors.As(err, &flagErr):
+		return cmd.ErrorExitCode
+	}
+
+	log.Println(err)
+	if cmd.ErrorExitCode != 0 {
+		return cmd.ErrorExitCode
+	}
+
+	return 1
+}
+
+// parseFlags parses the flags of the command and sets the flags not given on
+// the command line from the environment and the configuration file.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	cfg := &configParams{}
+	cfg.register(fs)
+
+	if printing[fs] {
+		recordValues(fs)
+	}
+
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	if err := cfg.apply(fs); err != nil {
+		return err
+	}
+
+	if printing[fs] {
+		printFlags(fs)
+		return errPrinted
+	}
+
+	return nil
+}
+
+func parseArgs(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return flagError{err: err}
+	}
+
+	return err
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags]\n\nCommands:\n", programName)
+	for _, cmd := range commands() {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.Name, cmd.Summary)
+	}
+	fmt.Fprintf(w, "\nRun \"%s help <command>\" for the flags of the command.\n", programName)
+}
+
+func runVersion(fs *flag.FlagSet, args []string) error {
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s %s (%s %s/%s)\n", programName, Version, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+
+	return nil
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+
+	return strings.ToUpper(s[:1]) + s[1:] + "."
+}
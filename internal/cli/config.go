@@ -0,0 +1,224 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/crabtree/defeway-toolbox/pkg/config"
+)
+
+var configCommand = &command{
+	Name:    "config",
+	Summary: "print the effective configuration of the profile or the flags of the command with the secrets redacted",
+}
+
+// the config command runs the other commands, its Run is set here to break
+// the initialization cycle
+func init() {
+	configCommand.Run = runConfig
+}
+
+// printing holds the flag sets of the commands run by the config command,
+// their parse prints the effective values of the flags instead of running
+// the command.
+var printing = make(map[*flag.FlagSet]bool)
+
+// errPrinted stops the command run by the config command once its flags are
+// printed.
+var errPrinted = errors.New("configuration printed")
+
+// configParams select the configuration file and the device profile, which
+// provide the values of the flags not given on the command line.
+type configParams struct {
+	Path    string
+	Profile string
+}
+
+func (p *configParams) register(fs *flag.FlagSet) {
+	fs.StringVar(&p.Path, "config", "", "path to the configuration file, defaults to $DEFEWAY_CONFIG or defeway/config.yaml in the user configuration directory")
+	fs.StringVar(&p.Profile, "profile", "", "name of the device profile from the configuration file, defaults to $DEFEWAY_PROFILE")
+}
+
+// values returns the flag values of the profile overridden by the DEFEWAY_*
+// environment variables.
+func (p *configParams) values() (map[string]string, error) {
+	path := p.Path
+	if path == "" {
+		path = os.Getenv(config.EnvName("config"))
+	}
+	if path == "" {
+		path = config.DefaultPath()
+	}
+
+	profile := p.Profile
+	if profile == "" {
+		profile = os.Getenv(config.EnvName("profile"))
+	}
+
+	values := make(map[string]string)
+	if path != "" {
+		cfg, err := config.Load(path)
+		if err != nil {
+			return nil, err
+		}
+
+		prof, err := cfg.Profile(profile)
+		if err != nil {
+			return nil, err
+		}
+
+		values = prof.FlagValues()
+	} else if profile != "" {
+		return nil, fmt.Errorf("specify configuration file of the profile %s", profile)
+	}
+
+	for _, env := range os.Environ() {
+		kv := strings.SplitN(env, "=", 2)
+		if len(kv) != 2 || !strings.HasPrefix(kv[0], config.EnvPrefix) {
+			continue
+		}
+
+		name := strings.ToLower(strings.Replace(strings.TrimPrefix(kv[0], config.EnvPrefix), "_", "-", -1))
+		if name == "config" || name == "profile" {
+			continue
+		}
+
+		values[name] = kv[1]
+	}
+
+	return values, nil
+}
+
+// apply sets the flags of the command which were not given on the command
+// line, the command line takes precedence over the environment and the
+// configuration file.
+func (p *configParams) apply(fs *flag.FlagSet) error {
+	values, err := p.values()
+	if err != nil {
+		return err
+	}
+
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	for _, name := range config.SortedNames(values) {
+		if set[name] || fs.Lookup(name) == nil {
+			continue
+		}
+
+		if err := fs.Set(name, values[name]); err != nil {
+			return fmt.Errorf("invalid value %q of the configured flag -%s: %s", config.Redact(name, values[name]), name, err)
+		}
+	}
+
+	return nil
+}
+
+// recordedValue keeps the values given to the custom flag, which does not
+// print its value, of the command run by the config command.
+type recordedValue struct {
+	flag.Value
+	values []string
+}
+
+func (v *recordedValue) Set(value string) error {
+	if err := v.Value.Set(value); err != nil {
+		return err
+	}
+
+	v.values = append(v.values, value)
+
+	return nil
+}
+
+// recordValues makes the custom flags of the command keep their values, it
+// is called before the flags are parsed.
+func recordValues(fs *flag.FlagSet) {
+	fs.VisitAll(func(f *flag.Flag) {
+		if _, ok := f.Value.(flag.Getter); !ok {
+			f.Value = &recordedValue{Value: f.Value}
+		}
+	})
+}
+
+// printFlags prints the values of all flags of the command, sorted by the
+// name, with the secrets redacted. The flag given multiple times is printed
+// once per value.
+func printFlags(fs *flag.FlagSet) {
+	fs.VisitAll(func(f *flag.Flag) {
+		values := []string{f.Value.String()}
+		if v, ok := f.Value.(*recordedValue); ok {
+			values = append(v.values[:0:0], v.values...)
+			if len(values) == 0 {
+				values = []string{""}
+			}
+		}
+
+		for _, value := range values {
+			if b, ok := f.Value.(interface{ IsBoolFlag() bool }); !ok || !b.IsBoolFlag() {
+				value = config.Redact(f.Name, value)
+			}
+			fmt.Printf("%s=%s\n", f.Name, value)
+		}
+	})
+}
+
+func runConfig(fs *flag.FlagSet, args []string) error {
+	p := &configParams{}
+	p.register(fs)
+
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	if fs.NArg() > 0 {
+		return runConfigOf(p, fs.Arg(0), fs.Args()[1:])
+	}
+
+	values, err := p.values()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range config.SortedNames(values) {
+		fmt.Printf("%s=%s\n", name, config.Redact(name, values[name]))
+	}
+
+	return nil
+}
+
+// runConfigOf parses the flags of the command like the command does and
+// prints their effective values, the configuration selected before the
+// command name applies unless the flags of the command select another.
+func runConfigOf(p *configParams, name string, args []string) error {
+	cmd := findCommand(name)
+	if cmd == nil || cmd == configCommand {
+		return fmt.Errorf("unknown command %q", name)
+	}
+
+	if p.Profile != "" {
+		args = append([]string{"-profile", p.Profile}, args...)
+	}
+	if p.Path != "" {
+		args = append([]string{"-config", p.Path}, args...)
+	}
+
+	fs := flag.NewFlagSet(programName+" "+cmd.Name, flag.ContinueOnError)
+	printing[fs] = true
+	defer delete(printing, fs)
+
+	err := cmd.Run(fs, args)
+	if errors.Is(err, errPrinted) {
+		return nil
+	}
+	if err == nil {
+		return fmt.Errorf("the command %s has no flags", cmd.Name)
+	}
+
+	return err
+}
@@ -0,0 +1,214 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/crabtree/defeway-toolbox/pkg/config"
+	"github.com/crabtree/defeway-toolbox/pkg/credstore"
+	"github.com/crabtree/defeway-toolbox/pkg/secret"
+)
+
+const (
+	credsFileName = "credentials.json"
+	credsSummary  = "manage the encrypted store of the DVR credentials keyed by the serial number or address"
+)
+
+var credsCommand = &command{
+	Name:    "creds",
+	Summary: credsSummary,
+	Run:     runCreds,
+}
+
+type credsParams struct {
+	Action         string
+	Creds          string
+	Key            string
+	Password       string
+	PasswordFile   string
+	PasswordPrompt bool
+	Show           bool
+	Username       string
+}
+
+func newCredsParams(fs *flag.FlagSet, args []string) (*credsParams, error) {
+	p := &credsParams{}
+
+	fs.StringVar(&p.Creds, "creds", defaultCredsPath(), "path to the encrypted credentials store")
+	fs.StringVar(&p.Password, "password", "", "password of the DVR to set, prefer -password-file or -password-prompt")
+	fs.StringVar(&p.PasswordFile, "password-file", "", "path to the file with the password of the DVR to set")
+	fs.BoolVar(&p.PasswordPrompt, "password-prompt", false, "asks for the password of the DVR to set on the terminal")
+	fs.BoolVar(&p.Show, "show", false, "prints the password with the get action")
+	fs.StringVar(&p.Username, "username", "admin", "username of the DVR to set")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s [flags] <set|get|delete|list|passwd> [key]\n\n%s\n\n", fs.Name(), capitalize(credsSummary))
+		fmt.Fprintf(fs.Output(), "The key is the serial number, the MAC address or the IP address of the DVR. The passphrase\n")
+		fmt.Fprintf(fs.Output(), "of the store is read from $%s or asked for on the terminal.\n\nFlags:\n", config.EnvName("creds-passphrase"))
+		fs.PrintDefaults()
+	}
+
+	if err := parseFlags(fs, args); err != nil {
+		return nil, err
+	}
+
+	if p.Creds == "" {
+		return nil, fmt.Errorf("specify credentials store path")
+	}
+
+	if fs.NArg() == 0 {
+		return nil, fmt.Errorf("specify action: set, get, delete, list or passwd")
+	}
+
+	p.Action = fs.Arg(0)
+	switch p.Action {
+	case "set", "get", "delete":
+		if fs.NArg() != 2 {
+			return nil, fmt.Errorf("specify the key of the %s action", p.Action)
+		}
+		p.Key = fs.Arg(1)
+	case "list", "passwd":
+		if fs.NArg() != 1 {
+			return nil, fmt.Errorf("unexpected arguments of the %s action", p.Action)
+		}
+	default:
+		return nil, fmt.Errorf("unknown action %q", p.Action)
+	}
+
+	return p, nil
+}
+
+func runCreds(fs *flag.FlagSet, args []string) error {
+	params, err := newCredsParams(fs, args)
+	if err != nil {
+		return err
+	}
+
+	passphrase, err := readPassphrase("Passphrase: ")
+	if err != nil {
+		return err
+	}
+
+	store, err := credstore.Open(params.Creds, passphrase)
+	if err != nil {
+		return err
+	}
+
+	switch params.Action {
+	case "get":
+		cred, ok := store.Get(params.Key)
+		if !ok {
+			return fmt.Errorf("no credentials of %s", params.Key)
+		}
+
+		password := config.Redact("password", cred.Password)
+		if params.Show {
+			password = cred.Password
+		}
+		fmt.Printf("username=%s\npassword=%s\n", cred.Username, password)
+
+		return nil
+	case "list":
+		for _, key := range store.Keys() {
+			cred, _ := store.Get(key)
+			fmt.Printf("%s\t%s\n", key, cred.Username)
+		}
+
+		return nil
+	case "delete":
+		if !store.Delete(params.Key) {
+			return fmt.Errorf("no credentials of %s", params.Key)
+		}
+	case "set":
+		password, err := params.readPassword()
+		if err != nil {
+			return err
+		}
+
+		store.Set(params.Key, credstore.Credential{Username: params.Username, Password: password})
+	case "passwd":
+		if passphrase, err = readPassphrase("New passphrase: "); err != nil {
+			return err
+		}
+
+		confirmation, err := secret.PromptPassword("Repeat new passphrase: ")
+		if err != nil {
+			return err
+		}
+
+		if confirmation != passphrase {
+			return fmt.Errorf("passphrases do not match")
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(params.Creds), 0700); err != nil {
+		return err
+	}
+
+	return store.Save(params.Creds, passphrase)
+}
+
+func (p *credsParams) readPassword() (string, error) {
+	switch {
+	case p.PasswordFile != "":
+		return secret.ReadPasswordFile(p.PasswordFile)
+	case p.PasswordPrompt:
+		return secret.PromptPassword("Password: ")
+	case p.Password != "":
+		return p.Password, nil
+	}
+
+	return "", fmt.Errorf("specify password with -password-file, -password-prompt or -password")
+}
+
+// openCredentials opens the credentials store used by the commands connecting
+// to the DVRs.
+func openCredentials(path string) (*credstore.Store, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("cannot open credentials store: %s", err)
+	}
+
+	passphrase, err := readPassphrase("Credentials store passphrase: ")
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := credstore.Open(path, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range store.Keys() {
+		cred, _ := store.Get(key)
+		secret.Register(cred.Password)
+	}
+
+	return store, nil
+}
+
+// readPassphrase reads the passphrase of the credentials store from the
+// environment, or asks for it on the terminal.
+func readPassphrase(prompt string) (string, error) {
+	if passphrase := os.Getenv(config.EnvName("creds-passphrase")); passphrase != "" {
+		return passphrase, nil
+	}
+
+	passphrase, err := secret.PromptPassword(prompt)
+	if err != nil {
+		return "", fmt.Errorf("cannot read passphrase: %s", err)
+	}
+
+	return passphrase, nil
+}
+
+// defaultCredsPath returns the path of the credentials store in the user
+// configuration directory.
+func defaultCredsPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "defeway", credsFileName)
+}
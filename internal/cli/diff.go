@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/crabtree/defeway-toolbox/internal/differ"
+)
+
+var diffCommand = &command{
+	Name:    "diff",
+	Summary: "compare two inventories",
+	Run:     runDiff,
+}
+
+type diffParams struct {
+	Format       string
+	NewInventory string
+	OldInventory string
+}
+
+func (p *diffParams) Dump() string {
+	return fmt.Sprintf("Format=%s NewInventory=%s OldInventory=%s",
+		p.Format, p.NewInventory, p.OldInventory)
+}
+
+func newDiffParams(fs *flag.FlagSet, args []string) (*diffParams, error) {
+	format := fs.String("format", differ.FormatText, "output format, text or json")
+	newInventory := fs.String("new", "", "path to the newer inventory file")
+	oldInventory := fs.String("old", "", "path to the older inventory file")
+
+	if err := parseFlags(fs, args); err != nil {
+		return nil, err
+	}
+
+	if *oldInventory == "" {
+		return nil, fmt.Errorf("specify path to the older inventory file")
+	}
+
+	if *newInventory == "" {
+		return nil, fmt.Errorf("specify path to the newer inventory file")
+	}
+
+	if *format != differ.FormatText && *format != differ.FormatJSON {
+		return nil, fmt.Errorf("the format %s is not supported", *format)
+	}
+
+	return &diffParams{
+		Format:       *format,
+		NewInventory: *newInventory,
+		OldInventory: *oldInventory,
+	}, nil
+}
+
+func runDiff(fs *flag.FlagSet, args []string) error {
+	params, err := newDiffParams(fs, args)
+	if err != nil {
+		return err
+	}
+
+	log.Println(params.Dump())
+
+	command := differ.NewCommand(differ.DifferParams{
+		Format:       params.Format,
+		NewInventory: params.NewInventory,
+		OldInventory: params.OldInventory,
+	})
+
+	return command.Run()
+}
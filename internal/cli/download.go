@@ -0,0 +1,430 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/crabtree/defeway-toolbox/internal/downloader"
+	"github.com/crabtree/defeway-toolbox/pkg/aimd"
+	"github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/crabtree/defeway-toolbox/pkg/inventory"
+	"github.com/crabtree/defeway-toolbox/pkg/layout"
+	"github.com/crabtree/defeway-toolbox/pkg/pipeline"
+	"github.com/crabtree/defeway-toolbox/pkg/progress"
+	"github.com/crabtree/defeway-toolbox/pkg/schedule"
+	"github.com/crabtree/defeway-toolbox/pkg/secret"
+	"github.com/crabtree/defeway-toolbox/pkg/sink"
+)
+
+// the handling of the downloads in flight at the end of the window
+const (
+	windowEndFinish = "finish"
+	windowEndPause  = "pause"
+)
+
+var downloadCommand = &command{
+	Name:    "download",
+	Summary: "download the recordings of the DVR",
+	Run:     runDownload,
+}
+
+type downloadParams struct {
+	Connection        connectionParams
+	Hooks             hooksParams
+	Limiter           limiterParams
+	Progress          progressParams
+	Recordings        recordingsParams
+	Target            targetParams
+	ChannelNames      map[int]string
+	Concurrent        int
+	ControlAddr       string
+	DeadlineRatio     float64
+	DisableKeepAlives bool
+	FailedFile        string
+	FFmpeg            string
+	InputFile         string
+	Layout            *layout.Template
+	MaxConcurrent     int
+	MigrateFrom       []*layout.Template
+	MinConcurrent     int
+	MoveTo            string
+	Output            string
+	Overwrite         bool
+	Preview           bool
+	Process           []string
+	RetryBackoff      time.Duration
+	Retries           int
+	S3                sink.S3Config
+	Site              string
+	StallTimeout      time.Duration
+	WindowEnd         string
+	Windows           schedule.Windows
+}
+
+func (p *downloadParams) Dump() string {
+	return fmt.Sprintf("%s %s %s %s %s %s ChannelNames=%v Concurrent=%d ControlAddr=%s DeadlineRatio=%g DisableKeepAlives=%t FailedFile=%s FFmpeg=%s InputFile=%s Layout=%s MaxConcurrent=%d MigrateFrom=%v MinConcurrent=%d MoveTo=%s Output=%s Overwrite=%t Preview=%t Process=%v RetryBackoff=%d Retries=%d S3Endpoint=%s S3Region=%s Site=%s StallTimeout=%d WindowEnd=%s Windows=%s",
+		p.Target.Dump(), p.Connection.Dump(), p.Hooks.Dump(), p.Limiter.Dump(), p.Progress.Dump(), p.Recordings.Dump(), p.ChannelNames, p.Concurrent, p.ControlAddr, p.DeadlineRatio, p.DisableKeepAlives, p.FailedFile, p.FFmpeg, p.InputFile, p.Layout, p.MaxConcurrent, p.MigrateFrom, p.MinConcurrent, p.MoveTo, p.Output, p.Overwrite, p.Preview, p.Process, p.RetryBackoff, p.Retries, p.S3.Endpoint, p.S3.Region, p.Site, p.StallTimeout, p.WindowEnd, p.Windows)
+}
+
+func newDownloadParams(fs *flag.FlagSet, args []string) (*downloadParams, error) {
+	p := &downloadParams{ChannelNames: make(map[int]string)}
+	var migrateFrom templatesParam
+	var process stepsParam
+	var windows windowsParam
+
+	p.Connection.register(fs)
+	p.Hooks.register(fs)
+	p.Limiter.register(fs)
+	p.Progress.register(fs)
+	p.Recordings.register(fs)
+	p.Target.register(fs)
+	fs.Var((*channelNamesParam)(&p.ChannelNames), "channel-name", "name of the channel in format <channel id>=<name> used by the {channel-name} placeholder, you can specify multiple names")
+	concurrent := fs.Int("concurrent", 1, "sets the number of concurrent workers, the initial number of the concurrent downloads with -max-concurrent")
+	controlAddr := fs.String("control-addr", "", "address of the control endpoint changing the download rates while running, like 127.0.0.1:9190")
+	deadlineRatio := fs.Float64("deadline-ratio", 4, "aborts the download taking longer than the multiple of the duration of the recording, plus a minute, 0 does not limit")
+	disableKeepAlives := fs.Bool("no-keep-alives", false, "disables the keep alives connections")
+	failedFile := fs.String("failed", "", "path to the XML file with the recordings which failed all retries, download them again with -file")
+	ffmpeg := fs.String("ffmpeg", pipeline.DefaultFFmpeg, "path to the ffmpeg binary used by the mp4 step")
+	inputFile := fs.String("file", "", "path to the input file with recordings to download")
+	layoutTemplate := fs.String("layout", layout.Legacy, "template of the recording path relative to the downloads directory")
+	maxConcurrent := fs.Int("max-concurrent", 0, "adapts the number of the concurrent downloads to the device, up to the maximum, 0 keeps the fixed -concurrent")
+	minConcurrent := fs.Int("min-concurrent", 1, "minimum number of the concurrent downloads with -max-concurrent")
+	moveTo := fs.String("move-to", "", "destination of the move step, the directory, tar:<path>, tar:- or s3://<bucket>/<prefix>")
+	fs.Var(&migrateFrom, "migrate-from", "template of the previous layout, the recordings found there are moved to the current layout, you can specify multiple templates")
+	output := fs.String("output", "", "path to the downloads directory, tar:<path> of the tar archive, tar:- to stream the tar archive to the standard output, or s3://<bucket>/<prefix> of the S3 bucket")
+	overwrite := fs.Bool("overwrite", false, "overwrite existing files")
+	preview := fs.Bool("preview", false, "download only preview")
+	fs.Var(&process, "process", fmt.Sprintf("comma separated steps run on each downloaded recording, in order, of %s", strings.Join(pipeline.Steps, ", ")))
+	retries := fs.Int("retries", 2, "the number of retries of the failed or stalled download")
+	retryBackoff := fs.Duration("retry-backoff", 10*time.Second, "the delay before the first retry, doubled with every retry")
+	s3Endpoint := fs.String("s3-endpoint", "", "URL of the S3 compatible object storage, like http://localhost:9000")
+	s3Region := fs.String("s3-region", sink.DefaultS3Region, "region of the S3 bucket")
+	site := fs.String("site", "", "name of the site used by the {site} placeholder, defaults to the profile name")
+	stallTimeout := fs.Duration("stall-timeout", time.Minute, "aborts the download which receives no data for the duration, 0 does not limit")
+	fs.Var(&windows, "window", "download only within the window in format [<days> ]HH:MM-HH:MM in the time zone of the DVR, like mon-fri 01:00-05:30, you can specify multiple windows")
+	windowEnd := fs.String("window-end", windowEndFinish, fmt.Sprintf("what happens to the downloads in flight at the end of the window, %s lets them complete, %s aborts them and starts again in the next window", windowEndFinish, windowEndPause))
+
+	if err := parseFlags(fs, args); err != nil {
+		return nil, err
+	}
+
+	if err := p.Connection.setCredentials(); err != nil {
+		return nil, err
+	}
+
+	if err := p.Target.validate(); err != nil {
+		return nil, err
+	}
+
+	p.Recordings.setDefaults()
+	if *inputFile == "" {
+		if err := p.Recordings.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if *output == "" {
+		return nil, fmt.Errorf("specify downloads directory")
+	}
+
+	if len(process) > 0 && (strings.HasPrefix(*output, "tar:") || strings.HasPrefix(*output, "s3://")) {
+		return nil, fmt.Errorf("the processing steps need the local downloads directory, use -move-to to store the processed recordings in %s", *output)
+	}
+
+	if *moveTo != "" && !process.contains("move") {
+		return nil, fmt.Errorf("add the move step to use %s", *moveTo)
+	}
+
+	if strings.HasPrefix(*output, "s3://") || strings.HasPrefix(*moveTo, "s3://") {
+		if *s3Endpoint == "" {
+			return nil, fmt.Errorf("specify S3 endpoint")
+		}
+
+		// the keys are read from the environment, like by the AWS tools
+		p.S3.AccessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+		p.S3.SecretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		if p.S3.AccessKey == "" || p.S3.SecretKey == "" {
+			return nil, fmt.Errorf("set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables")
+		}
+		secret.Register(p.S3.SecretKey)
+	}
+
+	if err := p.Limiter.validate(); err != nil {
+		return nil, err
+	}
+
+	if err := p.Progress.validate(); err != nil {
+		return nil, err
+	}
+
+	if *concurrent < 1 {
+		return nil, fmt.Errorf("specify at least one worker")
+	}
+
+	if *maxConcurrent > 0 && (*minConcurrent < 1 || *minConcurrent > *maxConcurrent) {
+		return nil, fmt.Errorf("specify minimum concurrent downloads of at least 1 and not greater than maximum")
+	}
+
+	if *retries < 0 || *retryBackoff < 0 || *stallTimeout < 0 || *deadlineRatio < 0 {
+		return nil, fmt.Errorf("specify non-negative retries and timeouts")
+	}
+
+	if *windowEnd != windowEndFinish && *windowEnd != windowEndPause {
+		return nil, fmt.Errorf("specify %s or %s at the end of the window", windowEndFinish, windowEndPause)
+	}
+
+	tmpl, err := layout.Parse(*layoutTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	// the archives downloaded with the legacy layouts are always migrated
+	for _, s := range layout.Legacies {
+		legacy, _ := layout.Parse(s)
+		migrateFrom = append(migrateFrom, legacy)
+	}
+
+	p.Concurrent = *concurrent
+	p.ControlAddr = *controlAddr
+	p.DeadlineRatio = *deadlineRatio
+	p.DisableKeepAlives = *disableKeepAlives
+	p.FailedFile = *failedFile
+	p.FFmpeg = *ffmpeg
+	p.InputFile = *inputFile
+	p.Layout = tmpl
+	p.MaxConcurrent = *maxConcurrent
+	p.MigrateFrom = migrateFrom
+	p.MinConcurrent = *minConcurrent
+	p.MoveTo = *moveTo
+	p.Output = *output
+	p.Overwrite = *overwrite
+	p.Preview = *preview
+	p.Process = process
+	p.RetryBackoff = *retryBackoff
+	p.Retries = *retries
+	p.S3.Endpoint = *s3Endpoint
+	p.S3.Region = *s3Region
+	p.Site = *site
+	p.StallTimeout = *stallTimeout
+	p.WindowEnd = *windowEnd
+	p.Windows = schedule.Windows(windows)
+
+	return p, nil
+}
+
+func runDownload(fs *flag.FlagSet, args []string) error {
+	params, err := newDownloadParams(fs, args)
+	if err != nil {
+		return err
+	}
+
+	log.Println(params.Dump())
+
+	if err := params.Target.resolve(&params.Connection); err != nil {
+		return err
+	}
+
+	limiter := params.Limiter.limiter()
+	bandwidth := params.Limiter.bandwidth(params.ControlAddr != "")
+	clientConfig := params.Connection.clientConfig(params.Target.addr(), limiter, params.Target.Device)
+	clientConfig.DisableKeepAlives = params.DisableKeepAlives
+
+	// the download of the recording takes longer than any timeout, it is
+	// limited by the stall timeout and the deadline instead
+	downloadClientConfig := clientConfig
+	downloadClientConfig.Timeout = 0
+	downloadClientConfig.Bandwidth = bandwidth
+	downloadClientConfig.StallTimeout = params.StallTimeout
+	downloadClientConfig.DeadlineRatio = params.DeadlineRatio
+
+	client := defewayclient.NewRecordingsClient(clientConfig, downloadClientConfig)
+
+	concurrency, concurrent, err := params.concurrency()
+	if err != nil {
+		return err
+	}
+
+	s, err := sink.Open(params.Output, params.S3)
+	if err != nil {
+		return err
+	}
+
+	pipe, moveTo, err := newPipeline(params)
+	if err != nil {
+		s.Close()
+		return err
+	}
+
+	h, err := params.Hooks.hooks("download")
+	if err != nil {
+		s.Close()
+		if moveTo != nil {
+			moveTo.Close()
+		}
+		return err
+	}
+
+	tracker := progress.NewTracker("recordings")
+
+	command := downloader.NewCommand(client, downloader.DownloaderParams{
+		Bandwidth:        bandwidth,
+		Channels:         params.Recordings.Channels,
+		Concurrency:      concurrency,
+		Concurrent:       concurrent,
+		ControlAddr:      params.ControlAddr,
+		Date:             params.Recordings.Date,
+		Device:           layoutDevice(params, clientConfig),
+		EndTime:          params.Recordings.EndTime,
+		FailedFile:       params.FailedFile,
+		Hooks:            h,
+		InputFile:        params.InputFile,
+		Layout:           params.Layout,
+		Location:         params.Recordings.Timezone,
+		MigrateFrom:      params.MigrateFrom,
+		Overwrite:        params.Overwrite,
+		PauseAtWindowEnd: params.WindowEnd == windowEndPause,
+		Pipeline:         pipe,
+		Preview:          params.Preview,
+		Progress:         tracker,
+		RecordingTypes:   params.Recordings.RecordingTypes,
+		RetryBackoff:     params.RetryBackoff,
+		Retries:          params.Retries,
+		Sink:             s,
+		StartTime:        params.Recordings.StartTime,
+		Windows:          params.Windows,
+	})
+
+	stopProgress := params.Progress.start(tracker)
+	err = command.Run()
+	stopProgress()
+
+	for _, sk := range []sink.Sink{s, moveTo} {
+		if sk == nil {
+			continue
+		}
+		if closeErr := sk.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	return err
+}
+
+// concurrency returns the controller of the adaptive concurrency and the
+// number of the workers, which is the maximum concurrency when it adapts.
+func (p *downloadParams) concurrency() (*aimd.Controller, int, error) {
+	if p.MaxConcurrent == 0 {
+		return nil, p.Concurrent, nil
+	}
+
+	controller, err := aimd.NewController(aimd.Config{
+		Min:     p.MinConcurrent,
+		Max:     p.MaxConcurrent,
+		Initial: p.Concurrent,
+	}, func(change aimd.Change) {
+		log.Printf("Device %s: %s\n", p.Target.addr(), change)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return controller, p.MaxConcurrent, nil
+}
+
+// newPipeline returns the pipeline of the -process steps with its state kept
+// in the downloads directory, and the sink of the move step.
+func newPipeline(params *downloadParams) (*pipeline.Pipeline, sink.Sink, error) {
+	if len(params.Process) == 0 {
+		return nil, nil, nil
+	}
+
+	var moveTo sink.Sink
+	if params.MoveTo != "" {
+		s, err := sink.Open(params.MoveTo, params.S3)
+		if err != nil {
+			return nil, nil, err
+		}
+		moveTo = s
+	}
+
+	pipe, err := openPipeline(params, moveTo)
+	if err != nil {
+		if moveTo != nil {
+			moveTo.Close()
+		}
+		return nil, nil, err
+	}
+
+	return pipe, moveTo, nil
+}
+
+func openPipeline(params *downloadParams, moveTo sink.Sink) (*pipeline.Pipeline, error) {
+	processors, err := pipeline.NewProcessors(params.Process, pipeline.Options{
+		FFmpeg: params.FFmpeg,
+		Sink:   moveTo,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := pipeline.OpenState(filepath.Join(params.Output, pipeline.StateFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	return pipeline.New(processors, state), nil
+}
+
+// layoutDevice returns the values of the layout placeholders describing the
+// device. The {device} placeholder is based on the device identity, so the
+// archive does not split when the device changes its IP address.
+func layoutDevice(params *downloadParams, clientConfig defewayclient.DefewayClientConfig) layout.Device {
+	address := fmt.Sprintf("%s-%d", params.Target.Address.String(), params.Target.Port)
+	device := layout.Device{
+		Address:      address,
+		ChannelNames: params.ChannelNames,
+		Date:         params.Recordings.Date,
+		Device:       address,
+		Site:         params.Site,
+	}
+
+	if device.Site == "" {
+		device.Site = address
+	}
+
+	client := defewayclient.NewDeviceInfoClient(clientConfig)
+
+	info, err := client.Fetch()
+	if err != nil {
+		log.Printf("Cannot fetch device identity, using %s: %s\n", address, err)
+		return device
+	}
+
+	var network *defewayclient.DefewayNetwork
+	if info.EnvLoad != nil {
+		network = info.EnvLoad.Network
+	}
+
+	if info.DeviceInfo != nil {
+		device.Name = info.DeviceInfo.Name
+		device.Serial = info.DeviceInfo.SerialNumber
+	}
+
+	id := inventory.Identity(info.DeviceInfo, network)
+	if id == "" {
+		log.Printf("Device has no serial number nor MAC address, using %s\n", address)
+		return device
+	}
+	device.Device = layout.Sanitize(id)
+
+	if params.Site == "" {
+		device.Site = device.Device
+	}
+
+	return device
+}
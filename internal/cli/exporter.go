@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/crabtree/defeway-toolbox/internal/exporter"
+)
+
+var exporterCommand = &command{
+	Name:    "exporter",
+	Summary: "serve the Prometheus metrics of the inventory devices",
+	Run:     runExporter,
+}
+
+type exporterParams struct {
+	Connection     connectionParams
+	Limiter        limiterParams
+	Concurrent     int
+	Interval       time.Duration
+	Inventory      string
+	ListenAddr     string
+	Lookback       time.Duration
+	RecordingTypes uint16
+	WithRecordings bool
+}
+
+func (p *exporterParams) Dump() string {
+	return fmt.Sprintf("%s %s Concurrent=%d Interval=%s Inventory=%s ListenAddr=%s Lookback=%s RecordingTypes=%d WithRecordings=%t",
+		p.Connection.Dump(), p.Limiter.Dump(), p.Concurrent, p.Interval, p.Inventory, p.ListenAddr, p.Lookback, p.RecordingTypes, p.WithRecordings)
+}
+
+func newExporterParams(fs *flag.FlagSet, args []string) (*exporterParams, error) {
+	var types recordingTypesParam
+	p := &exporterParams{}
+
+	p.Connection.register(fs)
+	p.Limiter.register(fs)
+	concurrent := fs.Int("concurrent", 1, "sets the number of concurrent workers")
+	interval := fs.Duration("interval", time.Minute, "sets the interval between polls of the DVRs")
+	inventoryFile := fs.String("inventory", "", "path to the inventory file")
+	listenAddr := fs.String("listen", ":9700", "address on which the metrics are served")
+	lookback := fs.Duration("lookback", 7*24*time.Hour, "sets how many days back the latest recording of the channel without the recordings of the day is searched on the first poll of the DVR")
+	fs.Var(&types, "type", "recording type used to find the latest recording")
+	withRecordings := fs.Bool("with-recordings", true, "export the timestamp of the latest recording per channel")
+
+	if err := parseFlags(fs, args); err != nil {
+		return nil, err
+	}
+
+	if err := p.Connection.setCredentials(); err != nil {
+		return nil, err
+	}
+
+	if err := p.Limiter.validate(); err != nil {
+		return nil, err
+	}
+
+	if *inventoryFile == "" {
+		return nil, fmt.Errorf("specify inventory file")
+	}
+
+	if *concurrent < 1 {
+		return nil, fmt.Errorf("specify at least one worker")
+	}
+
+	if *interval <= 0 {
+		return nil, fmt.Errorf("specify positive poll interval")
+	}
+
+	if *lookback < 0 {
+		return nil, fmt.Errorf("specify non-negative lookback")
+	}
+
+	if types == 0 {
+		types = recordingTypesParam(0xf)
+	}
+
+	p.Concurrent = *concurrent
+	p.Interval = *interval
+	p.Inventory = *inventoryFile
+	p.ListenAddr = *listenAddr
+	p.Lookback = *lookback
+	p.RecordingTypes = uint16(types)
+	p.WithRecordings = *withRecordings
+
+	return p, nil
+}
+
+func runExporter(fs *flag.FlagSet, args []string) error {
+	params, err := newExporterParams(fs, args)
+	if err != nil {
+		return err
+	}
+
+	log.Println(params.Dump())
+
+	command := exporter.NewCommand(exporter.ExporterParams{
+		ClientConfig:   params.Connection.deviceConfig(params.Limiter.limiter()),
+		Concurrent:     params.Concurrent,
+		Interval:       params.Interval,
+		Inventory:      params.Inventory,
+		ListenAddr:     params.ListenAddr,
+		Lookback:       params.Lookback,
+		RecordingTypes: params.RecordingTypes,
+		WithRecordings: params.WithRecordings,
+	})
+
+	return command.Run()
+}
@@ -0,0 +1,704 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/crabtree/defeway-toolbox/pkg/cmdtoolbox"
+	"github.com/crabtree/defeway-toolbox/pkg/config"
+	"github.com/crabtree/defeway-toolbox/pkg/credstore"
+	"github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/crabtree/defeway-toolbox/pkg/hooks"
+	"github.com/crabtree/defeway-toolbox/pkg/inventory"
+	"github.com/crabtree/defeway-toolbox/pkg/layout"
+	"github.com/crabtree/defeway-toolbox/pkg/progress"
+	"github.com/crabtree/defeway-toolbox/pkg/schedule"
+	"github.com/crabtree/defeway-toolbox/pkg/secret"
+)
+
+// connectionParams are the flags shared by all commands which connect to the
+// DVRs.
+type connectionParams struct {
+	Creds          string
+	Password       string
+	PasswordFile   string
+	PasswordPrompt bool
+	Timeout        time.Duration
+	TLSSkipVerify  bool
+	Username       string
+
+	store *credstore.Store
+}
+
+func (p *connectionParams) Dump() string {
+	return fmt.Sprintf("Creds=%s Password=%s PasswordFile=%s PasswordPrompt=%t Timeout=%d TLSSkipVerify=%t Username=%s",
+		p.Creds, config.Redact("password", p.Password), p.PasswordFile, p.PasswordPrompt, p.Timeout, p.TLSSkipVerify, p.Username)
+}
+
+func (p *connectionParams) register(fs *flag.FlagSet) {
+	fs.StringVar(&p.Creds, "creds", "", "path to the encrypted credentials store with the credentials of the DVRs, which take precedence over -username and -password")
+	fs.StringVar(&p.Password, "password", "", "password for the DVR, prefer $DEFEWAY_PASSWORD, -password-file or -password-prompt as the command line is visible to other users")
+	fs.StringVar(&p.PasswordFile, "password-file", "", "path to the file with the password for the DVR")
+	fs.BoolVar(&p.PasswordPrompt, "password-prompt", false, "asks for the password for the DVR on the terminal")
+	fs.BoolVar(&p.TLSSkipVerify, "tls-skip-verify", false, "disables the TLS certificate verification")
+	fs.DurationVar(&p.Timeout, "timeout", 5*time.Second, "sets the client timeout")
+	fs.StringVar(&p.Username, "username", "admin", "username for the DVR")
+}
+
+// setCredentials reads the password from the file or the terminal, registers
+// it to be masked in the logs and opens the credentials store.
+func (p *connectionParams) setCredentials() error {
+	if p.PasswordFile != "" && p.PasswordPrompt {
+		return fmt.Errorf("specify either password file or password prompt")
+	}
+
+	var err error
+	switch {
+	case p.PasswordFile != "":
+		p.Password, err = secret.ReadPasswordFile(p.PasswordFile)
+	case p.PasswordPrompt:
+		p.Password, err = secret.PromptPassword("Password: ")
+	}
+	if err != nil {
+		return fmt.Errorf("cannot read password: %s", err)
+	}
+
+	secret.Register(p.Password)
+
+	if p.Creds == "" {
+		return nil
+	}
+
+	p.store, err = openCredentials(p.Creds)
+
+	return err
+}
+
+// credentials returns the credentials store, or nil when it is not used.
+func (p *connectionParams) credentials() defewayclient.Credentials {
+	if p.store == nil {
+		return nil
+	}
+
+	return p.store
+}
+
+// clientConfig returns the config of the client of the DVR at the address.
+// The keys, like the serial number of the DVR, select its credentials in the
+// credentials store.
+func (p *connectionParams) clientConfig(addr string, limiter *defewayclient.Limiter, keys ...string) defewayclient.DefewayClientConfig {
+	config := defewayclient.DefewayClientConfig{
+		Address:  addr,
+		Username: p.Username,
+		Password: p.Password,
+		HTTPClientConfig: defewayclient.HTTPClientConfig{
+			Limiter:       limiter,
+			TLSSkipVerify: p.TLSSkipVerify,
+			Timeout:       p.Timeout,
+		},
+	}
+
+	return config.WithCredentials(p.credentials(), keys...)
+}
+
+// deviceConfig returns the config of the client of the DVR of the
+// inventory. The DVRs are polled rarely, so the connections are not kept
+// alive.
+func (p *connectionParams) deviceConfig(limiter *defewayclient.Limiter) func(device inventory.Device) defewayclient.DefewayClientConfig {
+	return func(device inventory.Device) defewayclient.DefewayClientConfig {
+		config := p.clientConfig(device.Address, limiter, device.Identities()...)
+		config.DisableKeepAlives = true
+
+		return config
+	}
+}
+
+// targetParams select the single DVR, either by its IP address or by its
+// serial number or MAC address resolved with the inventory.
+type targetParams struct {
+	Address   net.IP
+	Device    string
+	Inventory string
+	Port      uint
+}
+
+func (p *targetParams) Dump() string {
+	return fmt.Sprintf("Address=%s Device=%s Inventory=%s Port=%d",
+		p.Address, p.Device, p.Inventory, p.Port)
+}
+
+func (p *targetParams) register(fs *flag.FlagSet) {
+	fs.Var((*cmdtoolbox.IPParam)(&p.Address), "addr", "IP address of the DVR")
+	fs.StringVar(&p.Device, "device", "", "serial number or MAC address of the DVR, used in place of the IP address")
+	fs.StringVar(&p.Inventory, "inventory", "", "path to the inventory file used to resolve the device address")
+	fs.UintVar(&p.Port, "port", 60001, "sets the port to the DVR")
+}
+
+func (p *targetParams) validate() error {
+	if p.Address == nil && p.Device == "" {
+		return fmt.Errorf("specify IP address or device serial number")
+	}
+
+	if p.Device != "" && p.Address == nil && p.Inventory == "" {
+		return fmt.Errorf("specify inventory file or IP address to resolve the device")
+	}
+
+	return nil
+}
+
+func (p *targetParams) addr() string {
+	return net.JoinHostPort(p.Address.String(), strconv.Itoa(int(p.Port)))
+}
+
+// resolve finds the current address of the device given by its serial number
+// or MAC address.
+func (p *targetParams) resolve(conn *connectionParams) error {
+	if p.Device == "" {
+		return nil
+	}
+
+	var inv *inventory.Inventory
+	if p.Inventory != "" {
+		var err error
+		if inv, err = inventory.Load(p.Inventory); err != nil {
+			return err
+		}
+	}
+
+	var lastAddr string
+	if p.Address != nil {
+		lastAddr = p.addr()
+	}
+
+	resolver := inventory.NewResolver(inv, inventory.ResolverConfig{
+		Client: conn.clientConfig(lastAddr, nil, p.Device),
+	})
+
+	addr, err := resolver.Resolve(p.Device, lastAddr)
+	if err != nil {
+		return err
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+
+	portNo, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Device %s resolved to %s\n", p.Device, addr)
+
+	p.Address = net.ParseIP(host)
+	p.Port = uint(portNo)
+
+	return nil
+}
+
+// limiterParams limit the load of the DVRs, which often have slow CPUs and
+// fall over under the parallel requests.
+type limiterParams struct {
+	Jitter             time.Duration
+	MaxRate            int64
+	MaxRatePerDevice   int64
+	PerHostConnections int
+	RequestsPerSecond  float64
+}
+
+func (p *limiterParams) Dump() string {
+	return fmt.Sprintf("Jitter=%d MaxRate=%s MaxRatePerDevice=%s PerHostConnections=%d RequestsPerSecond=%g",
+		p.Jitter, defewayclient.FormatRate(p.MaxRate), defewayclient.FormatRate(p.MaxRatePerDevice), p.PerHostConnections, p.RequestsPerSecond)
+}
+
+func (p *limiterParams) register(fs *flag.FlagSet) {
+	fs.DurationVar(&p.Jitter, "jitter", 0, "sets the maximum random delay added before each request")
+	fs.Var((*rateParam)(&p.MaxRate), "max-rate", "sets the maximum download rate shared by all workers, like 2MiB/s or 8Mbit/s, 0 means unlimited")
+	fs.Var((*rateParam)(&p.MaxRatePerDevice), "max-rate-per-device", "sets the maximum download rate from one DVR, like 512KiB/s, 0 means unlimited")
+	fs.IntVar(&p.PerHostConnections, "per-host", 0, "sets the maximum number of concurrent connections to one DVR, 0 means unlimited")
+	fs.Float64Var(&p.RequestsPerSecond, "rate", 0, "sets the maximum number of requests per second, 0 means unlimited")
+}
+
+func (p *limiterParams) validate() error {
+	if p.RequestsPerSecond < 0 {
+		return fmt.Errorf("specify non-negative requests rate")
+	}
+
+	return nil
+}
+
+func (p *limiterParams) limiter() *defewayclient.Limiter {
+	return defewayclient.NewLimiter(defewayclient.LimiterConfig{
+		Jitter:             p.Jitter,
+		PerHostConnections: p.PerHostConnections,
+		RequestsPerSecond:  p.RequestsPerSecond,
+	})
+}
+
+// bandwidth returns the bandwidth of the downloads, or nil when it is not
+// limited and cannot be changed with the control endpoint.
+func (p *limiterParams) bandwidth(adjustable bool) *defewayclient.Bandwidth {
+	if p.MaxRate == 0 && p.MaxRatePerDevice == 0 && !adjustable {
+		return nil
+	}
+
+	return defewayclient.NewBandwidth(defewayclient.BandwidthConfig{
+		Rate:        p.MaxRate,
+		PerHostRate: p.MaxRatePerDevice,
+	})
+}
+
+// hooksParams configure the hooks notified about the events of the command.
+type hooksParams struct {
+	Commands      specsParam
+	Retries       int
+	Timeout       time.Duration
+	Webhooks      specsParam
+	WebhookSecret string
+}
+
+func (p *hooksParams) Dump() string {
+	return fmt.Sprintf("Hooks=%q HookRetries=%d HookTimeout=%d Webhooks=%q WebhookSecret=%s",
+		p.Commands, p.Retries, p.Timeout, p.Webhooks, config.Redact("webhook-secret", p.WebhookSecret))
+}
+
+func (p *hooksParams) register(fs *flag.FlagSet) {
+	fs.Var(&p.Commands, "hook", fmt.Sprintf("shell command run with the JSON event on the standard input, in format <events>=<command>, where the events are the comma separated %s or *, you can specify multiple hooks", strings.Join(hooks.Events, ", ")))
+	fs.IntVar(&p.Retries, "hook-retries", hooks.DefaultRetries, "sets the number of retries of the failed webhook delivery")
+	fs.DurationVar(&p.Timeout, "hook-timeout", hooks.DefaultTimeout, "sets the timeout of the hook command and of the webhook request")
+	fs.Var(&p.Webhooks, "webhook", "URL receiving the JSON event with the POST request, in format <events>=<url>, you can specify multiple webhooks")
+	fs.StringVar(&p.WebhookSecret, "webhook-secret", "", "key of the HMAC-SHA256 signature of the webhook requests sent in the X-Defeway-Signature header")
+}
+
+// hooks returns the hooks of the command, or nil when none is configured.
+func (p *hooksParams) hooks(command string) (*hooks.Hooks, error) {
+	if len(p.Commands) == 0 && len(p.Webhooks) == 0 {
+		return nil, nil
+	}
+
+	if p.Retries < 0 {
+		return nil, fmt.Errorf("specify non-negative number of hook retries")
+	}
+
+	secret.Register(p.WebhookSecret)
+
+	h := hooks.New(command)
+	for _, spec := range p.Commands {
+		events, target, err := hooks.ParseSpec(spec)
+		if err == nil {
+			err = h.Add(events, hooks.NewCommand(target, p.Timeout))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid hook %q: %s", spec, err)
+		}
+	}
+
+	for _, spec := range p.Webhooks {
+		events, target, err := hooks.ParseSpec(spec)
+		if err == nil {
+			err = h.Add(events, hooks.NewWebhook(target, p.WebhookSecret, p.Timeout, p.Retries))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid webhook %q: %s", spec, err)
+		}
+	}
+
+	return h, nil
+}
+
+// progress modes of the long running commands
+const (
+	progressAuto   = "auto"
+	progressBars   = "bars"
+	progressNDJSON = "ndjson"
+	progressOff    = "off"
+)
+
+// barsInterval is the interval between the redraws of the progress bars.
+const barsInterval = 250 * time.Millisecond
+
+// progressParams are the flags of the progress of the long running commands.
+type progressParams struct {
+	Mode     string
+	Interval time.Duration
+}
+
+func (p *progressParams) Dump() string {
+	return fmt.Sprintf("Progress=%s ProgressInterval=%d", p.Mode, p.Interval)
+}
+
+func (p *progressParams) register(fs *flag.FlagSet) {
+	fs.StringVar(&p.Mode, "progress", progressAuto, fmt.Sprintf("progress reported on the standard error, %s draws the bars, %s writes the JSON lines, %s disables it, %s draws the bars on the terminal and writes the JSON lines otherwise", progressBars, progressNDJSON, progressOff, progressAuto))
+	fs.DurationVar(&p.Interval, "progress-interval", 10*time.Second, "sets the interval between the JSON lines of the progress")
+}
+
+func (p *progressParams) validate() error {
+	switch p.Mode {
+	case progressAuto, progressBars, progressNDJSON, progressOff:
+	default:
+		return fmt.Errorf("specify %s, %s, %s or %s progress", progressAuto, progressBars, progressNDJSON, progressOff)
+	}
+
+	if p.Interval <= 0 {
+		return fmt.Errorf("specify positive progress interval")
+	}
+
+	return nil
+}
+
+// start reports the progress of the tracker on the standard error, it
+// returns the function stopping the reports. The log is printed above the
+// bars while they are drawn.
+func (p *progressParams) start(tracker *progress.Tracker) func() {
+	mode := p.Mode
+	if mode == progressAuto {
+		mode = progressNDJSON
+		if progress.IsTerminal(os.Stderr) {
+			mode = progressBars
+		}
+	}
+
+	switch mode {
+	case progressBars:
+		logWriter := log.Writer()
+		bars := progress.NewBars(tracker, os.Stderr, barsInterval)
+		log.SetOutput(secret.NewWriter(bars))
+		return func() {
+			bars.Stop()
+			log.SetOutput(logWriter)
+		}
+	case progressNDJSON:
+		ndjson := progress.NewNDJSON(tracker, log.Writer(), p.Interval)
+		return ndjson.Stop
+	default:
+		return func() {}
+	}
+}
+
+// recordingsParams select the recordings searched on the DVR.
+type recordingsParams struct {
+	Channels       uint16
+	Date           time.Time
+	EndTime        time.Time
+	RecordingTypes uint16
+	StartTime      time.Time
+	Timezone       *time.Location
+}
+
+func (p *recordingsParams) Dump() string {
+	return fmt.Sprintf("Channels=%d Date=%s EndTime=%s RecordingTypes=%d StartTime=%s Timezone=%s",
+		p.Channels, p.Date.Format("2006-01-02"), p.EndTime.Format("15:04:05"), p.RecordingTypes, p.StartTime.Format("15:04:05"), p.Timezone)
+}
+
+func (p *recordingsParams) register(fs *flag.FlagSet) {
+	fs.Var((*channelsParam)(&p.Channels), "chan", "channel id")
+	fs.Var((*dateParam)(&p.Date), "date", "specify date in format YYYY-MM-DD (eg. 2019-01-01)")
+	fs.Var((*timeParam)(&p.EndTime), "end", "recording end time")
+	fs.Var((*timeParam)(&p.StartTime), "start", "recording start time")
+	fs.Var(timezoneParam{loc: &p.Timezone}, "timezone", "time zone of the DVR, like Europe/Warsaw, used to compute the default date")
+	fs.Var((*recordingTypesParam)(&p.RecordingTypes), "type", "recording type")
+}
+
+// setDefaults sets the end of the day as the end time and the yesterday in
+// the time zone of the DVR as the date, when they are not specified.
+func (p *recordingsParams) setDefaults() {
+	if p.EndTime.IsZero() {
+		p.EndTime = time.Date(0, 0, 0, 23, 59, 59, 999999999, time.UTC)
+	}
+
+	if p.Timezone == nil {
+		p.Timezone = time.Local
+	}
+
+	if p.Date.IsZero() {
+		p.Date = time.Now().In(p.Timezone).Add(-24 * time.Hour)
+	}
+}
+
+func (p *recordingsParams) validate() error {
+	if p.Channels == 0 {
+		return fmt.Errorf("specify at least one channel id")
+	}
+
+	if p.RecordingTypes == 0 {
+		return fmt.Errorf("specify at least one recording type")
+	}
+
+	return nil
+}
+
+func (p *recordingsParams) fetchParams() defewayclient.RecordingsFetchParams {
+	return defewayclient.RecordingsFetchParams{
+		Channels:       p.Channels,
+		Date:           p.Date,
+		EndTime:        p.EndTime,
+		RecordingTypes: p.RecordingTypes,
+		StartTime:      p.StartTime,
+	}
+}
+
+// channels returns the channel ids, counted from 1, selected in the mask.
+func channels(mask uint16) []int {
+	var ids []int
+	for ch := 1; ch <= 16; ch++ {
+		if mask&(1<<uint(ch-1)) != 0 {
+			ids = append(ids, ch)
+		}
+	}
+
+	return ids
+}
+
+type channelsParam uint16
+
+func (c *channelsParam) String() string {
+	return "cameras parameters"
+}
+
+// Set accepts the single channel id or the comma separated list of ids, like
+// the channels of the device profile.
+func (c *channelsParam) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		v, err := strconv.ParseInt(strings.TrimSpace(item), 10, 16)
+		if err != nil {
+			return err
+		}
+
+		if v < 1 || v > 16 {
+			return fmt.Errorf("the channel id %d is out of range 1-16", v)
+		}
+
+		*c = *c | channelsParam(math.Pow(float64(2), float64(v-1)))
+	}
+
+	return nil
+}
+
+type rateParam int64
+
+func (rp *rateParam) String() string {
+	return "rate parameter"
+}
+
+func (rp *rateParam) Set(value string) error {
+	rate, err := defewayclient.ParseRate(value)
+	if err != nil {
+		return err
+	}
+
+	*rp = rateParam(rate)
+
+	return nil
+}
+
+type recordingTypesParam uint16
+
+func (rt *recordingTypesParam) String() string {
+	return "recording types"
+}
+
+func (rt *recordingTypesParam) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		v, err := strconv.ParseInt(strings.TrimSpace(item), 10, 16)
+		if err != nil {
+			return err
+		}
+
+		*rt = *rt | recordingTypesParam(math.Pow(float64(2), float64(v-1)))
+	}
+
+	return nil
+}
+
+type dateParam time.Time
+
+func (dp *dateParam) String() string {
+	return "date parameter"
+}
+
+func (dp *dateParam) Set(value string) error {
+	v, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return err
+	}
+
+	*dp = dateParam(v)
+
+	return nil
+}
+
+type timeParam time.Time
+
+func (tp *timeParam) String() string {
+	return "time parameter"
+}
+
+func (tp *timeParam) Set(value string) error {
+	v, err := time.Parse("15:04:05", value)
+	if err != nil {
+		return err
+	}
+
+	*tp = timeParam(v)
+
+	return nil
+}
+
+type portsParam []uint
+
+func (param *portsParam) String() string {
+	return "port parameters"
+}
+
+func (param *portsParam) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		v, err := strconv.ParseInt(strings.TrimSpace(item), 10, 32)
+		if err != nil {
+			return err
+		}
+
+		*param = append(*param, uint(v))
+	}
+
+	return nil
+}
+
+// timezoneParam sets the location, the pointer type cannot have its own
+// methods.
+type timezoneParam struct {
+	loc **time.Location
+}
+
+func (tz timezoneParam) String() string {
+	return "time zone parameter"
+}
+
+func (tz timezoneParam) Set(value string) error {
+	loc, err := time.LoadLocation(value)
+	if err != nil {
+		return err
+	}
+
+	*tz.loc = loc
+
+	return nil
+}
+
+type windowsParam schedule.Windows
+
+func (wp *windowsParam) String() string {
+	return "time windows parameter"
+}
+
+func (wp *windowsParam) Set(value string) error {
+	windows, err := schedule.ParseWindows(value)
+	if err != nil {
+		return err
+	}
+
+	*wp = append(*wp, windows...)
+
+	return nil
+}
+
+type templatesParam []*layout.Template
+
+func (tp *templatesParam) String() string {
+	return "layout templates parameter"
+}
+
+func (tp *templatesParam) Set(value string) error {
+	tmpl, err := layout.Parse(value)
+	if err != nil {
+		return err
+	}
+
+	*tp = append(*tp, tmpl)
+
+	return nil
+}
+
+type channelNamesParam map[int]string
+
+func (cn *channelNamesParam) String() string {
+	return "channel names parameter"
+}
+
+// Set accepts the channel name in format <channel id>=<name>, or the comma
+// separated list of them, like the channel names of the device profile.
+func (cn *channelNamesParam) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[1]) == "" {
+			return fmt.Errorf("specify channel name in format <channel id>=<name>")
+		}
+
+		ch, err := strconv.Atoi(strings.TrimSpace(kv[0]))
+		if err != nil {
+			return err
+		}
+
+		if ch < 1 || ch > 16 {
+			return fmt.Errorf("the channel id %d is out of range 1-16", ch)
+		}
+
+		(*cn)[ch] = strings.TrimSpace(kv[1])
+	}
+
+	return nil
+}
+
+type stepsParam []string
+
+func (sp *stepsParam) String() string {
+	return "processing steps parameter"
+}
+
+// Set accepts the name of the step, or the comma separated list of them,
+// like the steps of the device profile.
+func (sp *stepsParam) Set(value string) error {
+	for _, step := range strings.Split(value, ",") {
+		if step = strings.TrimSpace(step); step != "" {
+			*sp = append(*sp, step)
+		}
+	}
+
+	return nil
+}
+
+func (sp stepsParam) contains(step string) bool {
+	for _, s := range sp {
+		if s == step {
+			return true
+		}
+	}
+
+	return false
+}
+
+type specsParam []string
+
+func (sp *specsParam) String() string {
+	return "specifications parameter"
+}
+
+// Set accepts the specification, or the newline separated list of them, like
+// the hooks of the device profile. The commas separate the parts of the
+// specification.
+func (sp *specsParam) Set(value string) error {
+	for _, spec := range strings.Split(value, "\n") {
+		if spec = strings.TrimSpace(spec); spec != "" {
+			*sp = append(*sp, spec)
+		}
+	}
+
+	return nil
+}
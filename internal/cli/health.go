@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/crabtree/defeway-toolbox/internal/checker"
+	"github.com/crabtree/defeway-toolbox/pkg/health"
+)
+
+var healthCommand = &command{
+	Name:          "health",
+	Summary:       "check the disks, reachability and cameras of the inventory devices",
+	ErrorExitCode: health.ExitUnknown,
+	Run:           runHealth,
+}
+
+type healthParams struct {
+	Connection      connectionParams
+	Limiter         limiterParams
+	Concurrent      int
+	DiskUsageFail   float64
+	DiskUsageWarn   float64
+	ExpectedCameras int
+	FrozenInterval  time.Duration
+	Inventory       string
+	WithSnapshots   bool
+}
+
+func (p *healthParams) Dump() string {
+	return fmt.Sprintf("%s %s Concurrent=%d DiskUsageFail=%g DiskUsageWarn=%g ExpectedCameras=%d FrozenInterval=%d Inventory=%s WithSnapshots=%t",
+		p.Connection.Dump(), p.Limiter.Dump(), p.Concurrent, p.DiskUsageFail, p.DiskUsageWarn, p.ExpectedCameras, p.FrozenInterval, p.Inventory, p.WithSnapshots)
+}
+
+func newHealthParams(fs *flag.FlagSet, args []string) (*healthParams, error) {
+	p := &healthParams{}
+
+	p.Connection.register(fs)
+	p.Limiter.register(fs)
+	concurrent := fs.Int("concurrent", 1, "sets the number of concurrent workers")
+	diskUsageFail := fs.Float64("disk-fail", 98, "disk usage in percent above which the check fails, 0 disables the check")
+	diskUsageWarn := fs.Float64("disk-warn", 90, "disk usage in percent above which the check warns, 0 disables the check")
+	expectedCameras := fs.Int("cameras", 0, "expected number of cameras, 0 means the number from the inventory")
+	frozenInterval := fs.Duration("frozen-interval", 0, "sets the interval between two snapshots compared to detect frozen image, 0 disables the check")
+	inventoryFile := fs.String("inventory", "", "path to the inventory file")
+	withSnapshots := fs.Bool("with-snapshots", false, "analyse channels snapshots for black, blue, overexposed and frozen images")
+
+	if err := parseFlags(fs, args); err != nil {
+		return nil, err
+	}
+
+	if err := p.Connection.setCredentials(); err != nil {
+		return nil, err
+	}
+
+	if err := p.Limiter.validate(); err != nil {
+		return nil, err
+	}
+
+	if *inventoryFile == "" {
+		return nil, fmt.Errorf("specify inventory file")
+	}
+
+	if *concurrent < 1 {
+		return nil, fmt.Errorf("specify at least one worker")
+	}
+
+	p.Concurrent = *concurrent
+	p.DiskUsageFail = *diskUsageFail
+	p.DiskUsageWarn = *diskUsageWarn
+	p.ExpectedCameras = *expectedCameras
+	p.FrozenInterval = *frozenInterval
+	p.Inventory = *inventoryFile
+	p.WithSnapshots = *withSnapshots
+
+	return p, nil
+}
+
+func runHealth(fs *flag.FlagSet, args []string) error {
+	params, err := newHealthParams(fs, args)
+	if err != nil {
+		return err
+	}
+
+	log.Println(params.Dump())
+
+	command := checker.NewCommand(checker.CheckerParams{
+		ClientConfig:    params.Connection.deviceConfig(params.Limiter.limiter()),
+		Concurrent:      params.Concurrent,
+		DiskUsageFail:   params.DiskUsageFail,
+		DiskUsageWarn:   params.DiskUsageWarn,
+		ExpectedCameras: params.ExpectedCameras,
+		FrozenInterval:  params.FrozenInterval,
+		Inventory:       params.Inventory,
+		WithSnapshots:   params.WithSnapshots,
+	})
+
+	if err := command.Run(); err != nil {
+		return err
+	}
+
+	return exitStatus(command.Status().ExitCode())
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/crabtree/defeway-toolbox/internal/inspector"
+	"github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+)
+
+var infoCommand = &command{
+	Name:    "info",
+	Summary: "print the device info, network configuration and disks of the DVR",
+	Run:     runInfo,
+}
+
+type infoParams struct {
+	Connection connectionParams
+	Target     targetParams
+	Format     string
+}
+
+func (p *infoParams) Dump() string {
+	return fmt.Sprintf("%s %s Format=%s",
+		p.Target.Dump(), p.Connection.Dump(), p.Format)
+}
+
+func newInfoParams(fs *flag.FlagSet, args []string) (*infoParams, error) {
+	p := &infoParams{}
+
+	p.Connection.register(fs)
+	p.Target.register(fs)
+	format := fs.String("format", inspector.FormatText, "output format, text or json")
+
+	if err := parseFlags(fs, args); err != nil {
+		return nil, err
+	}
+
+	if err := p.Connection.setCredentials(); err != nil {
+		return nil, err
+	}
+
+	if err := p.Target.validate(); err != nil {
+		return nil, err
+	}
+
+	if *format != inspector.FormatText && *format != inspector.FormatJSON {
+		return nil, fmt.Errorf("the format %s is not supported", *format)
+	}
+
+	p.Format = *format
+
+	return p, nil
+}
+
+func runInfo(fs *flag.FlagSet, args []string) error {
+	params, err := newInfoParams(fs, args)
+	if err != nil {
+		return err
+	}
+
+	log.Println(params.Dump())
+
+	if err := params.Target.resolve(&params.Connection); err != nil {
+		return err
+	}
+
+	command := inspector.NewCommand(
+		defewayclient.NewDeviceInfoClient(
+			params.Connection.clientConfig(params.Target.addr(), nil, params.Target.Device)),
+		inspector.InspectorParams{
+			Address: params.Target.addr(),
+			Format:  params.Format,
+		})
+
+	return command.Run()
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/crabtree/defeway-toolbox/internal/mosaic"
+	"github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+)
+
+var mosaicCommand = &command{
+	Name:    "mosaic",
+	Summary: "compose the snapshots of all channels into one image",
+	Run:     runMosaic,
+}
+
+type mosaicParams struct {
+	Connection connectionParams
+	Target     targetParams
+	Columns    int
+	Concurrent int
+	Output     string
+	Quality    int
+	TileWidth  int
+}
+
+func (p *mosaicParams) Dump() string {
+	return fmt.Sprintf("%s %s Columns=%d Concurrent=%d Output=%s Quality=%d TileWidth=%d",
+		p.Target.Dump(), p.Connection.Dump(), p.Columns, p.Concurrent, p.Output, p.Quality, p.TileWidth)
+}
+
+func newMosaicParams(fs *flag.FlagSet, args []string) (*mosaicParams, error) {
+	p := &mosaicParams{}
+
+	p.Connection.register(fs)
+	p.Target.register(fs)
+	columns := fs.Int("columns", 0, "sets the number of columns of the grid, 0 means the smallest square grid")
+	concurrent := fs.Int("concurrent", 4, "sets the number of concurrent workers")
+	output := fs.String("output", "", "path to the mosaic image, .jpg or .png")
+	quality := fs.Int("quality", 90, "sets the JPEG quality, from 1 to 100")
+	tileWidth := fs.Int("tile-width", 352, "sets the width of the channel tile in pixels")
+
+	if err := parseFlags(fs, args); err != nil {
+		return nil, err
+	}
+
+	if err := p.Connection.setCredentials(); err != nil {
+		return nil, err
+	}
+
+	if err := p.Target.validate(); err != nil {
+		return nil, err
+	}
+
+	if *output == "" {
+		return nil, fmt.Errorf("specify mosaic image path")
+	}
+
+	switch strings.ToLower(filepath.Ext(*output)) {
+	case ".jpg", ".jpeg", ".png":
+	default:
+		return nil, fmt.Errorf("the mosaic image must be .jpg or .png file")
+	}
+
+	if *concurrent < 1 {
+		return nil, fmt.Errorf("specify at least one worker")
+	}
+
+	if *quality < 1 || *quality > 100 {
+		return nil, fmt.Errorf("the JPEG quality must be between 1 and 100")
+	}
+
+	if *tileWidth < 16 {
+		return nil, fmt.Errorf("the tile width must be at least 16 pixels")
+	}
+
+	p.Columns = *columns
+	p.Concurrent = *concurrent
+	p.Output = *output
+	p.Quality = *quality
+	p.TileWidth = *tileWidth
+
+	return p, nil
+}
+
+func runMosaic(fs *flag.FlagSet, args []string) error {
+	params, err := newMosaicParams(fs, args)
+	if err != nil {
+		return err
+	}
+
+	log.Println(params.Dump())
+
+	if err := params.Target.resolve(&params.Connection); err != nil {
+		return err
+	}
+
+	clientConfig := params.Connection.clientConfig(params.Target.addr(), nil, params.Target.Device)
+
+	command := mosaic.NewCommand(
+		defewayclient.NewDeviceInfoClient(clientConfig),
+		defewayclient.NewSnapshotClient(clientConfig),
+		mosaic.MosaicParams{
+			Columns:    params.Columns,
+			Concurrent: params.Concurrent,
+			Output:     params.Output,
+			Quality:    params.Quality,
+			TileWidth:  params.TileWidth,
+		})
+
+	return command.Run()
+}
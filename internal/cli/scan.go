@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"github.com/crabtree/defeway-toolbox/internal/scanner"
+	"github.com/crabtree/defeway-toolbox/pkg/cmdtoolbox"
+	"github.com/crabtree/defeway-toolbox/pkg/progress"
+)
+
+var scanCommand = &command{
+	Name:    "scan",
+	Summary: "scan the network for DVRs and write the inventory",
+	Run:     runScan,
+}
+
+type scanParams struct {
+	Connection      connectionParams
+	Hooks           hooksParams
+	Limiter         limiterParams
+	Progress        progressParams
+	Concurrent      int
+	FrozenInterval  time.Duration
+	LogDir          string
+	NetAddr         net.IP
+	NetMask         net.IPMask
+	Ports           []uint
+	ProbeConcurrent int
+	ProbeTimeout    time.Duration
+	Shuffle         bool
+	WithSnapshots   bool
+}
+
+func (p *scanParams) Dump() string {
+	return fmt.Sprintf("%s %s %s %s Concurrent=%d FrozenInterval=%d LogDir=%s NetAddr=%s NetMask=%s Ports=%d ProbeConcurrent=%d ProbeTimeout=%d Shuffle=%t WithSnapshots=%t",
+		p.Connection.Dump(), p.Hooks.Dump(), p.Limiter.Dump(), p.Progress.Dump(), p.Concurrent, p.FrozenInterval, p.LogDir, p.NetAddr, p.NetMask, p.Ports, p.ProbeConcurrent, p.ProbeTimeout, p.Shuffle, p.WithSnapshots)
+}
+
+func newScanParams(fs *flag.FlagSet, args []string) (*scanParams, error) {
+	var netAddr cmdtoolbox.IPParam
+	var netMask cmdtoolbox.IPMaskParam
+	var ports portsParam
+	p := &scanParams{}
+
+	p.Connection.register(fs)
+	p.Hooks.register(fs)
+	p.Limiter.register(fs)
+	p.Progress.register(fs)
+	fs.Var(&netAddr, "addr", "IP address of the network")
+	concurrent := fs.Int("concurrent", 1, "sets the number of concurrent workers")
+	frozenInterval := fs.Duration("frozen-interval", 0, "sets the interval between two snapshots compared to detect frozen image, 0 disables the check")
+	logDir := fs.String("logdir", "", "path to the logs directory")
+	fs.Var(&netMask, "mask", "IP address of the network mask")
+	fs.Var(&ports, "port", "port number")
+	probeConcurrent := fs.Int("probe-concurrent", 16, "sets the number of concurrent TCP probe workers")
+	probeTimeout := fs.Duration("probe-timeout", 500*time.Millisecond, "sets the TCP probe connect timeout")
+	shuffle := fs.Bool("shuffle", false, "scan the addresses in random order")
+	withSnapshots := fs.Bool("with-snapshots", false, "fetch channels snapshots of discovered device")
+
+	if err := parseFlags(fs, args); err != nil {
+		return nil, err
+	}
+
+	if err := p.Connection.setCredentials(); err != nil {
+		return nil, err
+	}
+
+	if *logDir == "" {
+		return nil, fmt.Errorf("specify logs directory")
+	}
+
+	if netAddr == nil {
+		return nil, fmt.Errorf("specify IP address of the network")
+	}
+
+	if netMask == nil {
+		return nil, fmt.Errorf("specify IP address of the network mask")
+	}
+
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("specify ports to scan")
+	}
+
+	if err := p.Limiter.validate(); err != nil {
+		return nil, err
+	}
+
+	if err := p.Progress.validate(); err != nil {
+		return nil, err
+	}
+
+	if *probeConcurrent < 1 {
+		return nil, fmt.Errorf("specify at least one TCP probe worker")
+	}
+
+	p.Concurrent = *concurrent
+	p.FrozenInterval = *frozenInterval
+	p.LogDir = *logDir
+	p.NetAddr = net.IP(netAddr)
+	p.NetMask = net.IPMask(netMask)
+	p.Ports = ports
+	p.ProbeConcurrent = *probeConcurrent
+	p.ProbeTimeout = *probeTimeout
+	p.Shuffle = *shuffle
+	p.WithSnapshots = *withSnapshots
+
+	return p, nil
+}
+
+func runScan(fs *flag.FlagSet, args []string) error {
+	params, err := newScanParams(fs, args)
+	if err != nil {
+		return err
+	}
+
+	log.Println(params.Dump())
+
+	h, err := params.Hooks.hooks("scan")
+	if err != nil {
+		return err
+	}
+
+	tracker := progress.NewTracker("addresses")
+
+	command := scanner.NewCommand(scanner.ScannerParams{
+		Concurrent:         params.Concurrent,
+		Credentials:        params.Connection.credentials(),
+		FrozenInterval:     params.FrozenInterval,
+		Hooks:              h,
+		Jitter:             params.Limiter.Jitter,
+		LogDir:             params.LogDir,
+		NetAddr:            params.NetAddr,
+		NetMask:            params.NetMask,
+		Password:           params.Connection.Password,
+		PerHostConnections: params.Limiter.PerHostConnections,
+		Ports:              params.Ports,
+		ProbeConcurrent:    params.ProbeConcurrent,
+		ProbeTimeout:       params.ProbeTimeout,
+		Progress:           tracker,
+		RequestsPerSecond:  params.Limiter.RequestsPerSecond,
+		Shuffle:            params.Shuffle,
+		TLSSkipVerify:      params.Connection.TLSSkipVerify,
+		Timeout:            params.Connection.Timeout,
+		Username:           params.Connection.Username,
+		WithSnapshots:      params.WithSnapshots,
+	})
+
+	stopProgress := params.Progress.start(tracker)
+	defer stopProgress()
+
+	return command.Run()
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/crabtree/defeway-toolbox/internal/searcher"
+	"github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+)
+
+var searchCommand = &command{
+	Name:    "search",
+	Summary: "list the recordings of the DVR",
+	Run:     runSearch,
+}
+
+type searchParams struct {
+	Connection connectionParams
+	Recordings recordingsParams
+	Target     targetParams
+	Format     string
+}
+
+func (p *searchParams) Dump() string {
+	return fmt.Sprintf("%s %s %s Format=%s",
+		p.Target.Dump(), p.Connection.Dump(), p.Recordings.Dump(), p.Format)
+}
+
+func newSearchParams(fs *flag.FlagSet, args []string) (*searchParams, error) {
+	p := &searchParams{}
+
+	p.Connection.register(fs)
+	p.Recordings.register(fs)
+	p.Target.register(fs)
+	format := fs.String("format", searcher.FormatText, "output format, text or json")
+
+	if err := parseFlags(fs, args); err != nil {
+		return nil, err
+	}
+
+	if err := p.Connection.setCredentials(); err != nil {
+		return nil, err
+	}
+
+	if err := p.Target.validate(); err != nil {
+		return nil, err
+	}
+
+	p.Recordings.setDefaults()
+	if err := p.Recordings.validate(); err != nil {
+		return nil, err
+	}
+
+	if *format != searcher.FormatText && *format != searcher.FormatJSON {
+		return nil, fmt.Errorf("the format %s is not supported", *format)
+	}
+
+	p.Format = *format
+
+	return p, nil
+}
+
+func runSearch(fs *flag.FlagSet, args []string) error {
+	params, err := newSearchParams(fs, args)
+	if err != nil {
+		return err
+	}
+
+	log.Println(params.Dump())
+
+	if err := params.Target.resolve(&params.Connection); err != nil {
+		return err
+	}
+
+	clientConfig := params.Connection.clientConfig(params.Target.addr(), nil, params.Target.Device)
+
+	command := searcher.NewCommand(
+		defewayclient.NewRecordingsClient(clientConfig, clientConfig),
+		searcher.SearcherParams{
+			FetchParams: params.Recordings.fetchParams(),
+			Format:      params.Format,
+		})
+
+	return command.Run()
+}
@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/crabtree/defeway-toolbox/internal/snapshotter"
+	"github.com/crabtree/defeway-toolbox/pkg/cmdtoolbox"
+	"github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+)
+
+var snapshotCommand = &command{
+	Name:    "snapshot",
+	Summary: "save the current snapshots of the DVR channels",
+	Run:     runSnapshot,
+}
+
+type snapshotParams struct {
+	Connection connectionParams
+	Target     targetParams
+	Channels   uint16
+	OutputDir  string
+}
+
+func (p *snapshotParams) Dump() string {
+	return fmt.Sprintf("%s %s Channels=%d OutputDir=%s",
+		p.Target.Dump(), p.Connection.Dump(), p.Channels, p.OutputDir)
+}
+
+func newSnapshotParams(fs *flag.FlagSet, args []string) (*snapshotParams, error) {
+	var channels channelsParam
+	p := &snapshotParams{}
+
+	p.Connection.register(fs)
+	p.Target.register(fs)
+	fs.Var(&channels, "chan", "channel id, all channels when not specified")
+	outputDir := fs.String("output", ".", "path to the snapshots directory")
+
+	if err := parseFlags(fs, args); err != nil {
+		return nil, err
+	}
+
+	if err := p.Connection.setCredentials(); err != nil {
+		return nil, err
+	}
+
+	if err := p.Target.validate(); err != nil {
+		return nil, err
+	}
+
+	if *outputDir == "" {
+		return nil, fmt.Errorf("specify snapshots directory")
+	}
+
+	p.Channels = uint16(channels)
+	p.OutputDir = *outputDir
+
+	return p, nil
+}
+
+func runSnapshot(fs *flag.FlagSet, args []string) error {
+	params, err := newSnapshotParams(fs, args)
+	if err != nil {
+		return err
+	}
+
+	log.Println(params.Dump())
+
+	if err := cmdtoolbox.EnsureDir(params.OutputDir); err != nil {
+		return err
+	}
+
+	if err := params.Target.resolve(&params.Connection); err != nil {
+		return err
+	}
+
+	clientConfig := params.Connection.clientConfig(params.Target.addr(), nil, params.Target.Device)
+
+	command := snapshotter.NewCommand(
+		defewayclient.NewDeviceInfoClient(clientConfig),
+		defewayclient.NewSnapshotClient(clientConfig),
+		snapshotter.SnapshotterParams{
+			Channels:  channels(params.Channels),
+			OutputDir: params.OutputDir,
+		})
+
+	return command.Run()
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/crabtree/defeway-toolbox/internal/tamper"
+	"github.com/crabtree/defeway-toolbox/pkg/health"
+	"github.com/crabtree/defeway-toolbox/pkg/snapshot"
+)
+
+var tamperCommand = &command{
+	Name:          "tamper",
+	Summary:       "compare the channel snapshots with the baselines to detect tampering, once per run like the monitoring plugin",
+	ErrorExitCode: health.ExitUnknown,
+	Run:           runTamper,
+}
+
+type tamperParams struct {
+	Connection    connectionParams
+	Limiter       limiterParams
+	BaselineDir   string
+	Capture       bool
+	Concurrent    int
+	Device        string
+	Format        string
+	Inventory     string
+	MinSimilarity float64
+}
+
+func (p *tamperParams) Dump() string {
+	return fmt.Sprintf("%s %s BaselineDir=%s Capture=%t Concurrent=%d Device=%s Format=%s Inventory=%s MinSimilarity=%g",
+		p.Connection.Dump(), p.Limiter.Dump(), p.BaselineDir, p.Capture, p.Concurrent, p.Device, p.Format, p.Inventory, p.MinSimilarity)
+}
+
+func newTamperParams(fs *flag.FlagSet, args []string) (*tamperParams, error) {
+	p := &tamperParams{}
+
+	p.Connection.register(fs)
+	p.Limiter.register(fs)
+	baselineDir := fs.String("baseline-dir", "baselines", "path to the directory with the baseline snapshots")
+	capture := fs.Bool("capture", false, "capture new baseline snapshots instead of comparing with them")
+	concurrent := fs.Int("concurrent", 1, "sets the number of concurrent workers")
+	device := fs.String("device", "", "serial number or MAC address of the only device to check")
+	format := fs.String("format", tamper.FormatText, "output format, text or json")
+	inventoryFile := fs.String("inventory", "", "path to the inventory file")
+	minSimilarity := fs.Float64("min-similarity", snapshot.DefaultMinSimilarity, "similarity to the baseline below which the view is considered changed, from 0 to 1")
+
+	if err := parseFlags(fs, args); err != nil {
+		return nil, err
+	}
+
+	if err := p.Connection.setCredentials(); err != nil {
+		return nil, err
+	}
+
+	if err := p.Limiter.validate(); err != nil {
+		return nil, err
+	}
+
+	if *inventoryFile == "" {
+		return nil, fmt.Errorf("specify inventory file")
+	}
+
+	if *baselineDir == "" {
+		return nil, fmt.Errorf("specify baseline directory")
+	}
+
+	if *concurrent < 1 {
+		return nil, fmt.Errorf("specify at least one worker")
+	}
+
+	if *minSimilarity < 0 || *minSimilarity > 1 {
+		return nil, fmt.Errorf("the minimum similarity must be between 0 and 1")
+	}
+
+	if *format != tamper.FormatText && *format != tamper.FormatJSON {
+		return nil, fmt.Errorf("the format %s is not supported", *format)
+	}
+
+	p.BaselineDir = *baselineDir
+	p.Capture = *capture
+	p.Concurrent = *concurrent
+	p.Device = *device
+	p.Format = *format
+	p.Inventory = *inventoryFile
+	p.MinSimilarity = *minSimilarity
+
+	return p, nil
+}
+
+func runTamper(fs *flag.FlagSet, args []string) error {
+	params, err := newTamperParams(fs, args)
+	if err != nil {
+		return err
+	}
+
+	log.Println(params.Dump())
+
+	command := tamper.NewCommand(tamper.TamperParams{
+		BaselineDir:   params.BaselineDir,
+		Capture:       params.Capture,
+		ClientConfig:  params.Connection.deviceConfig(params.Limiter.limiter()),
+		Concurrent:    params.Concurrent,
+		Device:        params.Device,
+		Format:        params.Format,
+		Inventory:     params.Inventory,
+		MinSimilarity: params.MinSimilarity,
+	})
+
+	if err := command.Run(); err != nil {
+		return err
+	}
+
+	return exitStatus(command.Status().ExitCode())
+}
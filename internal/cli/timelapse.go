@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/crabtree/defeway-toolbox/internal/timelapser"
+	"github.com/crabtree/defeway-toolbox/pkg/cmdtoolbox"
+	"github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/crabtree/defeway-toolbox/pkg/schedule"
+)
+
+var timelapseCommand = &command{
+	Name:    "timelapse",
+	Summary: "capture the channel snapshots periodically and assemble them into time-lapse videos",
+	Run:     runTimelapse,
+}
+
+type timelapseParams struct {
+	Connection connectionParams
+	Target     targetParams
+	Assemble   bool
+	Channels   uint16
+	FPS        int
+	From       time.Time
+	Interval   time.Duration
+	Location   *time.Location
+	OutputDir  string
+	Retention  time.Duration
+	To         time.Time
+	Windows    schedule.Windows
+}
+
+func (p *timelapseParams) Dump() string {
+	return fmt.Sprintf("%s %s Assemble=%t Channels=%d FPS=%d From=%s Interval=%d Location=%s OutputDir=%s Retention=%d To=%s Windows=%s",
+		p.Target.Dump(), p.Connection.Dump(), p.Assemble, p.Channels, p.FPS, p.From.Format("2006-01-02"), p.Interval, p.Location, p.OutputDir, p.Retention, p.To.Format("2006-01-02"), p.Windows)
+}
+
+func newTimelapseParams(fs *flag.FlagSet, args []string) (*timelapseParams, error) {
+	var channels channelsParam
+	var from dateParam
+	var to dateParam
+	var windows windowsParam
+	p := &timelapseParams{Location: time.Local}
+
+	p.Connection.register(fs)
+	p.Target.register(fs)
+	assemble := fs.Bool("assemble", false, "assemble the captured snapshots into Motion-JPEG AVI files instead of capturing")
+	fs.Var(&channels, "chan", "channel id")
+	fps := fs.Int("fps", 25, "sets the frame rate of the assembled AVI files")
+	fs.Var(&from, "from", "assemble snapshots captured since the date in format YYYY-MM-DD")
+	interval := fs.Duration("interval", time.Minute, "sets the interval between the snapshots")
+	outputDir := fs.String("output", "", "path to the snapshots directory")
+	fs.Var(timezoneParam{loc: &p.Location}, "timezone", "time zone of the DVR, like Europe/Warsaw, used for the windows and the snapshot names")
+	retention := fs.Duration("retention", 0, "removes the snapshots older than the duration, 0 keeps all snapshots")
+	fs.Var(&to, "to", "assemble snapshots captured before the date in format YYYY-MM-DD")
+	fs.Var(&windows, "window", "capture only within the daily window in format HH:MM-HH:MM, you can specify multiple windows")
+
+	if err := parseFlags(fs, args); err != nil {
+		return nil, err
+	}
+
+	if err := p.Connection.setCredentials(); err != nil {
+		return nil, err
+	}
+
+	if !*assemble {
+		if err := p.Target.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if channels == 0 {
+		return nil, fmt.Errorf("specify at least one channel id")
+	}
+
+	if *outputDir == "" {
+		return nil, fmt.Errorf("specify snapshots directory")
+	}
+
+	if *interval <= 0 {
+		return nil, fmt.Errorf("specify positive interval")
+	}
+
+	if *fps < 1 {
+		return nil, fmt.Errorf("specify positive frame rate")
+	}
+
+	if *retention < 0 {
+		return nil, fmt.Errorf("specify non-negative retention")
+	}
+
+	p.Assemble = *assemble
+	p.Channels = uint16(channels)
+	p.FPS = *fps
+	p.From = localDate(time.Time(from), p.Location)
+	p.Interval = *interval
+	p.OutputDir = *outputDir
+	p.Retention = *retention
+	p.Windows = schedule.Windows(windows)
+
+	// the date is inclusive
+	if p.To = localDate(time.Time(to), p.Location); !p.To.IsZero() {
+		p.To = p.To.AddDate(0, 0, 1)
+	}
+
+	return p, nil
+}
+
+// localDate returns the midnight of the date in the time zone, the snapshots
+// are named with the time of the DVR.
+func localDate(date time.Time, loc *time.Location) time.Time {
+	if date.IsZero() {
+		return date
+	}
+
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+}
+
+func runTimelapse(fs *flag.FlagSet, args []string) error {
+	params, err := newTimelapseParams(fs, args)
+	if err != nil {
+		return err
+	}
+
+	log.Println(params.Dump())
+
+	if err := cmdtoolbox.EnsureDir(params.OutputDir); err != nil {
+		return err
+	}
+
+	var client *defewayclient.SnapshotClient
+	if !params.Assemble {
+		if err := params.Target.resolve(&params.Connection); err != nil {
+			return err
+		}
+
+		clientConfig := params.Connection.clientConfig(params.Target.addr(), nil, params.Target.Device)
+		clientConfig.DisableKeepAlives = true
+		client = defewayclient.NewSnapshotClient(clientConfig)
+	}
+
+	command := timelapser.NewCommand(client, timelapser.TimelapserParams{
+		Assemble:  params.Assemble,
+		Channels:  params.Channels,
+		FPS:       params.FPS,
+		From:      params.From,
+		Interval:  params.Interval,
+		Location:  params.Location,
+		OutputDir: params.OutputDir,
+		Retention: params.Retention,
+		To:        params.To,
+		Windows:   params.Windows,
+	})
+
+	return command.Run()
+}
@@ -0,0 +1,264 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/crabtree/defeway-toolbox/internal/watcher"
+	"github.com/crabtree/defeway-toolbox/pkg/config"
+	"github.com/crabtree/defeway-toolbox/pkg/hooks"
+	"github.com/crabtree/defeway-toolbox/pkg/inventory"
+	"github.com/crabtree/defeway-toolbox/pkg/notify"
+	"github.com/crabtree/defeway-toolbox/pkg/schedule"
+	"github.com/crabtree/defeway-toolbox/pkg/secret"
+)
+
+var watchCommand = &command{
+	Name:    "watch",
+	Summary: "watch the DVRs for the alarm and motion recordings and send notifications",
+	Run:     runWatch,
+}
+
+type watchParams struct {
+	Connection     connectionParams
+	Limiter        limiterParams
+	Target         targetParams
+	Channels       uint16
+	ChannelNames   map[int]string
+	Concurrent     int
+	Interval       time.Duration
+	Location       *time.Location
+	Lookback       time.Duration
+	MQTT           notify.MQTT
+	NDJSON         bool
+	QuietHours     schedule.Windows
+	RecordingTypes uint16
+	SMTP           notify.SMTP
+	Snapshot       bool
+	State          string
+	Webhook        string
+	WebhookSecret  string
+}
+
+func (p *watchParams) Dump() string {
+	return fmt.Sprintf("%s %s %s Channels=%d ChannelNames=%v Concurrent=%d Interval=%d Location=%s Lookback=%d MQTTBroker=%s MQTTPassword=%s MQTTTopic=%s MQTTUsername=%s NDJSON=%t QuietHours=%s RecordingTypes=%d SMTPAddr=%s SMTPFrom=%s SMTPPassword=%s SMTPTo=%v SMTPUsername=%s Snapshot=%t State=%s Webhook=%s WebhookSecret=%s",
+		p.Target.Dump(), p.Connection.Dump(), p.Limiter.Dump(), p.Channels, p.ChannelNames, p.Concurrent, p.Interval, p.Location, p.Lookback,
+		p.MQTT.Broker, config.Redact("mqtt-password", p.MQTT.Password), p.MQTT.Topic, p.MQTT.Username, p.NDJSON, p.QuietHours, p.RecordingTypes,
+		p.SMTP.Addr, p.SMTP.From, config.Redact("smtp-password", p.SMTP.Password), p.SMTP.To, p.SMTP.Username, p.Snapshot, p.State, p.Webhook, config.Redact("webhook-secret", p.WebhookSecret))
+}
+
+func newWatchParams(fs *flag.FlagSet, args []string) (*watchParams, error) {
+	var channels channelsParam
+	var quietHours windowsParam
+	var recordingTypes recordingTypesParam
+	p := &watchParams{ChannelNames: make(map[int]string), Location: time.Local}
+
+	p.Connection.register(fs)
+	p.Limiter.register(fs)
+	p.Target.register(fs)
+	fs.Var(&channels, "chan", "channel id, defaults to all channels")
+	fs.Var((*channelNamesParam)(&p.ChannelNames), "channel-name", "name of the channel in format <channel id>=<name> used in the notifications, you can specify multiple names")
+	concurrent := fs.Int("concurrent", 1, "sets the number of concurrent workers")
+	interval := fs.Duration("interval", time.Minute, "sets the interval between the searches of the recordings")
+	lookback := fs.Duration("lookback", 10*time.Minute, "sets how far back the recordings are searched, it covers the recordings reported by the DVR with the delay")
+	mqttBroker := fs.String("mqtt-broker", "", "address of the MQTT broker receiving the notifications, host:port, tcp://host:port or tls://host:port")
+	mqttPassword := fs.String("mqtt-password", "", "password for the MQTT broker")
+	mqttTopic := fs.String("mqtt-topic", notify.DefaultMQTTTopic, "topic of the MQTT notifications, with the {device}, {channel} and {type} placeholders")
+	mqttUsername := fs.String("mqtt-username", "", "username for the MQTT broker")
+	ndjson := fs.Bool("ndjson", false, "writes the notifications as JSON lines to the standard output, the default when no other notifier is configured")
+	fs.Var(&quietHours, "quiet-hours", "suppress the notifications within the daily window in format HH:MM-HH:MM, you can specify multiple windows")
+	smtpAddr := fs.String("smtp-addr", "", "address of the SMTP server sending the notifications by email, in format host:port")
+	smtpFrom := fs.String("smtp-from", "", "sender address of the notification emails")
+	smtpPassword := fs.String("smtp-password", "", "password for the SMTP server")
+	smtpTo := fs.String("smtp-to", "", "comma separated recipient addresses of the notification emails")
+	smtpUsername := fs.String("smtp-username", "", "username for the SMTP server, the authentication is disabled when empty")
+	snapshot := fs.Bool("snapshot", false, "attach the snapshot of the channel to the notifications")
+	state := fs.String("state", "", "path to the file with the notified recordings, which are not notified again after the restart")
+	fs.Var(timezoneParam{loc: &p.Location}, "timezone", "time zone of the DVR, like Europe/Warsaw, used for the searches and the quiet hours")
+	fs.Var(&recordingTypes, "type", "recording type, defaults to the motion and alarm recordings")
+	webhook := fs.String("notify-webhook", "", "URL receiving the JSON notifications with the POST request")
+	webhookSecret := fs.String("webhook-secret", "", "key of the HMAC-SHA256 signature of the webhook requests sent in the X-Defeway-Signature header")
+
+	if err := parseFlags(fs, args); err != nil {
+		return nil, err
+	}
+
+	if err := p.Connection.setCredentials(); err != nil {
+		return nil, err
+	}
+
+	if err := p.Limiter.validate(); err != nil {
+		return nil, err
+	}
+
+	if p.Target.Address != nil || p.Target.Device != "" {
+		if err := p.Target.validate(); err != nil {
+			return nil, err
+		}
+	} else if p.Target.Inventory == "" {
+		return nil, fmt.Errorf("specify IP address, device serial number or inventory file")
+	}
+
+	if *concurrent < 1 {
+		return nil, fmt.Errorf("specify at least one worker")
+	}
+
+	if *interval <= 0 {
+		return nil, fmt.Errorf("specify positive interval")
+	}
+
+	if *lookback < *interval {
+		return nil, fmt.Errorf("specify lookback not shorter than the interval")
+	}
+
+	if *smtpAddr != "" && (*smtpFrom == "" || *smtpTo == "") {
+		return nil, fmt.Errorf("specify sender and recipients of the notification emails")
+	}
+
+	if channels == 0 {
+		channels = 0xffff
+	}
+
+	if recordingTypes == 0 {
+		// the bits of the motion and alarm recordings
+		recordingTypes = 2 | 4
+	}
+
+	secret.Register(*mqttPassword)
+	secret.Register(*smtpPassword)
+	secret.Register(*webhookSecret)
+
+	p.Channels = uint16(channels)
+	p.Concurrent = *concurrent
+	p.Interval = *interval
+	p.Lookback = *lookback
+	p.MQTT = notify.MQTT{
+		Broker:   *mqttBroker,
+		ClientID: fmt.Sprintf("%s-%d", programName, os.Getpid()),
+		Password: *mqttPassword,
+		Topic:    *mqttTopic,
+		Username: *mqttUsername,
+	}
+	p.NDJSON = *ndjson
+	p.QuietHours = schedule.Windows(quietHours)
+	p.RecordingTypes = uint16(recordingTypes)
+	p.SMTP = notify.SMTP{
+		Addr:     *smtpAddr,
+		From:     *smtpFrom,
+		Password: *smtpPassword,
+		Username: *smtpUsername,
+	}
+	for _, to := range strings.Split(*smtpTo, ",") {
+		if to = strings.TrimSpace(to); to != "" {
+			p.SMTP.To = append(p.SMTP.To, to)
+		}
+	}
+	p.Snapshot = *snapshot
+	p.State = *state
+	p.Webhook = *webhook
+	p.WebhookSecret = *webhookSecret
+
+	return p, nil
+}
+
+// notifiers returns the configured notifiers, the notifications are written
+// to the standard output when none is configured.
+func (p *watchParams) notifiers() []notify.Notifier {
+	var notifiers []notify.Notifier
+	if p.SMTP.Addr != "" {
+		smtp := p.SMTP
+		notifiers = append(notifiers, &smtp)
+	}
+
+	if p.Webhook != "" {
+		notifiers = append(notifiers, notify.NewWebhook(
+			hooks.NewWebhook(p.Webhook, p.WebhookSecret, hooks.DefaultTimeout, hooks.DefaultRetries)))
+	}
+
+	if p.MQTT.Broker != "" {
+		mqtt := p.MQTT
+		notifiers = append(notifiers, &mqtt)
+	}
+
+	if p.NDJSON || len(notifiers) == 0 {
+		notifiers = append(notifiers, notify.NewNDJSON(os.Stdout))
+	}
+
+	return notifiers
+}
+
+// devices returns the watched device, or all devices of the inventory when
+// the device is not given.
+func (p *watchParams) devices() ([]inventory.Device, error) {
+	var inv *inventory.Inventory
+	if p.Target.Inventory != "" {
+		var err error
+		if inv, err = inventory.Load(p.Target.Inventory); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.Target.Address == nil && p.Target.Device == "" {
+		inv.Sort()
+		return inv.Devices, nil
+	}
+
+	if err := p.Target.resolve(&p.Connection); err != nil {
+		return nil, err
+	}
+
+	device := inventory.Device{Address: p.Target.addr()}
+	if inv != nil && p.Target.Device != "" {
+		if found, ok := inv.Find(p.Target.Device); ok {
+			device = *found
+			device.Address = p.Target.addr()
+		}
+	}
+
+	return []inventory.Device{device}, nil
+}
+
+func runWatch(fs *flag.FlagSet, args []string) error {
+	params, err := newWatchParams(fs, args)
+	if err != nil {
+		return err
+	}
+
+	log.Println(params.Dump())
+
+	devices, err := params.devices()
+	if err != nil {
+		return err
+	}
+
+	if len(devices) == 0 {
+		return fmt.Errorf("no devices to watch")
+	}
+
+	seen, err := notify.OpenSeen(params.State)
+	if err != nil {
+		return err
+	}
+
+	command := watcher.NewCommand(watcher.WatcherParams{
+		Channels:       params.Channels,
+		ChannelNames:   params.ChannelNames,
+		ClientConfig:   params.Connection.deviceConfig(params.Limiter.limiter()),
+		Concurrent:     params.Concurrent,
+		Devices:        devices,
+		Interval:       params.Interval,
+		Location:       params.Location,
+		Lookback:       params.Lookback,
+		Notifiers:      params.notifiers(),
+		QuietHours:     params.QuietHours,
+		RecordingTypes: params.RecordingTypes,
+		Seen:           seen,
+		Snapshot:       params.Snapshot,
+	})
+
+	return command.Run()
+}
@@ -0,0 +1,106 @@
+package differ
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/crabtree/defeway-toolbox/pkg/inventory"
+)
+
+type command struct {
+	output io.Writer
+	params DifferParams
+}
+
+func NewCommand(params DifferParams) *command {
+	return &command{
+		output: os.Stdout,
+		params: params,
+	}
+}
+
+func (c *command) Run() error {
+	oldInv, err := inventory.Load(c.params.OldInventory)
+	if err != nil {
+		return err
+	}
+
+	newInv, err := inventory.Load(c.params.NewInventory)
+	if err != nil {
+		return err
+	}
+
+	diff := inventory.Compare(oldInv, newInv)
+
+	if c.params.Format == FormatJSON {
+		return c.writeJSON(diff)
+	}
+
+	return c.writeText(diff)
+}
+
+func (c *command) writeJSON(diff *inventory.Diff) error {
+	enc := json.NewEncoder(c.output)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(diff)
+}
+
+func (c *command) writeText(diff *inventory.Diff) error {
+	if diff.IsEmpty() {
+		_, err := fmt.Fprintln(c.output, "No changes")
+		return err
+	}
+
+	w := &errWriter{w: c.output}
+
+	if len(diff.Added) > 0 {
+		w.printf("Added devices:\n")
+		for _, device := range diff.Added {
+			w.printf("  + %s %s%s\n", device.Key(), device.Address, describe(device))
+		}
+	}
+
+	if len(diff.Removed) > 0 {
+		w.printf("Removed devices:\n")
+		for _, device := range diff.Removed {
+			w.printf("  - %s %s%s\n", device.Key(), device.Address, describe(device))
+		}
+	}
+
+	if len(diff.Changed) > 0 {
+		w.printf("Changed devices:\n")
+		for _, changed := range diff.Changed {
+			w.printf("  ~ %s %s\n", changed.Key, changed.Address)
+			for _, change := range changed.Changes {
+				w.printf("      %s: %s -> %s\n", change.Field, change.Old, change.New)
+			}
+		}
+	}
+
+	return w.err
+}
+
+func describe(device inventory.Device) string {
+	if device.DeviceInfo == nil {
+		return ""
+	}
+
+	return fmt.Sprintf(" (%s %s, %s)",
+		device.DeviceInfo.Name, device.DeviceInfo.Model, device.DeviceInfo.SWVer)
+}
+
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, args ...interface{}) {
+	if ew.err != nil {
+		return
+	}
+
+	_, ew.err = fmt.Fprintf(ew.w, format, args...)
+}
@@ -0,0 +1,12 @@
+package differ
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+type DifferParams struct {
+	Format       string
+	NewInventory string
+	OldInventory string
+}
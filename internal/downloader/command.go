@@ -1,48 +1,135 @@
 package downloader
 
 import (
+	"fmt"
 	"io"
 	"log"
 	"sync"
+	"time"
 
 	dc "github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/crabtree/defeway-toolbox/pkg/hooks"
+	"github.com/crabtree/defeway-toolbox/pkg/sink"
 )
 
 type RecordingsClient interface {
 	Fetch(fetchParams dc.RecordingsFetchParams) ([]dc.RecordingMeta, error)
-	Download(recMeta dc.RecordingMeta, dst io.Writer, isPreview bool) error
+	DownloadWithProgress(recMeta dc.RecordingMeta, dst io.Writer, isPreview bool, progress dc.ProgressFunc) error
 }
 
 type command struct {
 	client RecordingsClient
 	params DownloaderParams
+	root   string
+
+	mu               sync.Mutex
+	summary          map[string]int
+	waitingFor       time.Time
+	failedRecordings []dc.RecordingMeta
 }
 
 func NewCommand(client RecordingsClient, params DownloaderParams) *command {
+	if params.Location == nil {
+		params.Location = time.Local
+	}
+
 	return &command{
-		client: client,
-		params: params,
+		client:  client,
+		params:  params,
+		summary: make(map[string]int),
 	}
 }
 
 func (c *command) Run() error {
 	var wg sync.WaitGroup
 
-	recsChan, err := c.fetch()
+	// the processors work on the local files
+	if c.params.Pipeline != nil {
+		local, ok := c.params.Sink.(*sink.Local)
+		if !ok {
+			return fmt.Errorf("the processing steps need the local downloads directory")
+		}
+		c.root = local.Dir
+	}
+
+	if c.params.ControlAddr != "" && c.params.Bandwidth != nil {
+		stop, err := c.serveControl()
+		if err != nil {
+			return err
+		}
+		defer stop()
+	}
+
+	// the DVR is not searched outside of the download windows either
+	c.waitWindow()
+
+	jobsChan, err := c.fetch()
 	if err != nil {
+		c.params.Hooks.Fire(hooks.Event{
+			Event:   hooks.RunFinished,
+			Address: c.params.Device.Address,
+			Device:  c.params.Device.Device,
+			Error:   err.Error(),
+		})
 		return err
 	}
+	c.params.Progress.SetTotal(len(jobsChan))
 
 	for i := 0; i < c.params.Concurrent; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			if err = c.process(recsChan); err != nil {
+			if err = c.process(jobsChan); err != nil {
 				log.Println(err)
 			}
 		}()
 	}
 
 	wg.Wait()
+	c.logSummary()
+
+	if c.params.FailedFile != "" {
+		if err := c.writeFailed(); err != nil {
+			log.Println(err)
+		}
+	}
+
+	c.params.Hooks.Fire(hooks.Event{
+		Event:   hooks.RunFinished,
+		Address: c.params.Device.Address,
+		Device:  c.params.Device.Device,
+		Summary: c.summary,
+	})
+
 	return nil
 }
+
+// count adds the recording to the summary of the run.
+func (c *command) count(outcome string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.summary[outcome]++
+	c.params.Progress.Done(outcome == failed)
+}
+
+func (c *command) logSummary() {
+	log.Printf("Summary: %d downloaded, %d existing, %d processed earlier, %d failed\n",
+		c.summary[downloaded], c.summary[existing], c.summary[processed], c.summary[failed])
+
+	if c.params.Concurrency != nil {
+		log.Printf("Concurrency: %s\n", c.params.Concurrency.Stats())
+	}
+
+	if c.params.Pipeline == nil {
+		return
+	}
+
+	for _, step := range c.params.Pipeline.Summary() {
+		log.Printf("Step %s: %d ok, %d failed, %d skipped\n", step.Step, step.OK, step.Failed, step.Skipped)
+	}
+
+	for _, f := range c.params.Pipeline.Failures() {
+		log.Printf("Step %s failed for %s: %s\n", f.Step, c.location(f.Recording), f.Err)
+	}
+}
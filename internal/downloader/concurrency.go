@@ -0,0 +1,48 @@
+package downloader
+
+import (
+	"io"
+	"time"
+
+	"github.com/crabtree/defeway-toolbox/pkg/aimd"
+)
+
+// transferWriter measures the download of the recording for the adaptive
+// concurrency.
+type transferWriter struct {
+	io.Writer
+	started  time.Time
+	latency  time.Duration
+	bytes    int64
+	writeErr error
+}
+
+func newTransferWriter(w io.Writer) *transferWriter {
+	return &transferWriter{Writer: w, started: time.Now()}
+}
+
+func (w *transferWriter) Write(p []byte) (int, error) {
+	if w.latency == 0 {
+		w.latency = time.Since(w.started)
+	}
+
+	n, err := w.Writer.Write(p)
+	w.bytes += int64(n)
+	if err != nil {
+		w.writeErr = err
+	}
+
+	return n, err
+}
+
+// result returns the result of the download. The failed writes, like to the
+// full disk or after the end of the window, are not the failures of the
+// device.
+func (w *transferWriter) result(err error) aimd.Result {
+	return aimd.Result{
+		Bytes:   w.bytes,
+		Latency: w.latency,
+		Err:     err,
+		Aborted: err != nil && err == w.writeErr,
+	}
+}
@@ -0,0 +1,87 @@
+package downloader
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+
+	dc "github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+)
+
+type bandwidthStatus struct {
+	MaxRate          string
+	MaxRatePerDevice string
+}
+
+// serveControl serves the control endpoint, which changes the bandwidth of
+// the running downloads. The returned function stops the server.
+func (c *command) serveControl() (func(), error) {
+	l, err := net.Listen("tcp", c.params.ControlAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/bandwidth", c.serveBandwidth)
+	server := &http.Server{Handler: mux}
+
+	log.Printf("Serving control endpoint on %s/bandwidth\n", l.Addr())
+	go func() {
+		if err := server.Serve(l); err != nil && err != http.ErrServerClosed {
+			log.Println(err)
+		}
+	}()
+
+	return func() { server.Close() }, nil
+}
+
+// serveBandwidth returns the current rates, the POST request sets the rates
+// given in the max-rate and max-rate-per-device form values, like 2MiB/s.
+func (c *command) serveBandwidth(rw http.ResponseWriter, req *http.Request) {
+	bandwidth := c.params.Bandwidth
+
+	switch req.Method {
+	case http.MethodGet:
+	case http.MethodPost, http.MethodPut:
+		config := bandwidth.Config()
+		if err := setRate(&config.Rate, req.FormValue("max-rate")); err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := setRate(&config.PerHostRate, req.FormValue("max-rate-per-device")); err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		bandwidth.SetConfig(config)
+		log.Printf("Bandwidth changed to %s, %s per device\n", dc.FormatRate(config.Rate), dc.FormatRate(config.PerHostRate))
+	default:
+		rw.Header().Set("Allow", "GET, POST, PUT")
+		http.Error(rw, fmt.Sprintf("method %s not allowed", req.Method), http.StatusMethodNotAllowed)
+		return
+	}
+
+	config := bandwidth.Config()
+	rw.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(rw).Encode(bandwidthStatus{
+		MaxRate:          dc.FormatRate(config.Rate),
+		MaxRatePerDevice: dc.FormatRate(config.PerHostRate),
+	})
+}
+
+// setRate parses the non empty value into the rate.
+func setRate(rate *int64, value string) error {
+	if value == "" {
+		return nil
+	}
+
+	v, err := dc.ParseRate(value)
+	if err != nil {
+		return err
+	}
+	*rate = v
+
+	return nil
+}
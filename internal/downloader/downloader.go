@@ -1,16 +1,36 @@
 package downloader
 
 import (
+	"io"
 	"log"
-	"os"
 	"path"
+	"time"
 
+	"github.com/crabtree/defeway-toolbox/pkg/aimd"
 	"github.com/crabtree/defeway-toolbox/pkg/cmdtoolbox"
 	"github.com/crabtree/defeway-toolbox/pkg/defewayclient"
 	dc "github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/crabtree/defeway-toolbox/pkg/hooks"
+	"github.com/crabtree/defeway-toolbox/pkg/pipeline"
+	"github.com/crabtree/defeway-toolbox/pkg/sink"
 )
 
-func (c *command) fetch() (<-chan dc.RecordingMeta, error) {
+// outcomes of the recordings counted in the summary of the run
+const (
+	downloaded = "downloaded"
+	existing   = "existing"
+	processed  = "processed"
+	failed     = "failed"
+)
+
+// job is the recording to download into the path relative to the root of
+// the sink.
+type job struct {
+	rec  dc.RecordingMeta
+	path string
+}
+
+func (c *command) fetch() (<-chan job, error) {
 	var recordings []defewayclient.RecordingMeta
 	var err error
 
@@ -24,14 +44,21 @@ func (c *command) fetch() (<-chan dc.RecordingMeta, error) {
 		return nil, err
 	}
 
-	recordingsChan := make(chan dc.RecordingMeta, len(recordings))
-	defer close(recordingsChan)
+	// all paths are rendered before the download starts, so the collisions
+	// are detected before any file is written
+	paths, err := c.params.Layout.RenderAll(c.params.Device, recordings)
+	if err != nil {
+		return nil, err
+	}
+
+	jobsChan := make(chan job, len(recordings))
+	defer close(jobsChan)
 
-	for _, rec := range recordings {
-		recordingsChan <- rec
+	for i, rec := range recordings {
+		jobsChan <- job{rec: rec, path: paths[i]}
 	}
 
-	return recordingsChan, nil
+	return jobsChan, nil
 }
 
 func (c *command) parseInputFile() ([]defewayclient.RecordingMeta, error) {
@@ -48,84 +75,186 @@ func (c *command) parseInputFile() ([]defewayclient.RecordingMeta, error) {
 	return parsed.RecSearch.SearchResults, nil
 }
 
-func (c *command) process(recsChan <-chan dc.RecordingMeta) error {
-	if err := cmdtoolbox.EnsureDir(c.params.OutputDir); err != nil {
-		return err
-	}
-
-	for recMeta := range recsChan {
-		shortNamePath := path.Join(c.params.OutputDir, recMeta.GetFileShortName())
-		dstPath := path.Join(c.params.OutputDir, recMeta.GetFileName())
+func (c *command) process(jobsChan <-chan job) error {
+	for j := range jobsChan {
+		recMeta := j.rec
+		location := c.location(j.path)
 
-		err := handleExistingWithShortName(shortNamePath, dstPath)
-		if err != nil {
+		if err := c.migrate(recMeta, j.path); err != nil {
 			log.Println(err)
+			c.count(failed)
 			continue
 		}
 
-		exists, err := fileExists(dstPath)
+		// the recording may be already moved out of the downloads
+		// directory by the processing steps
+		if c.params.Pipeline != nil && !c.params.Overwrite {
+			if c.params.Pipeline.Done(j.path) {
+				log.Printf("File %s already processed\n", location)
+				c.count(processed)
+				continue
+			}
+
+			if c.params.Pipeline.Pending(j.path) {
+				log.Printf("Retrying processing of %s\n", location)
+				c.count(existing)
+				c.runPipeline(j)
+				continue
+			}
+		}
+
+		exists, err := c.params.Sink.Exists(j.path)
 		if err != nil {
 			log.Println(err)
+			c.count(failed)
 			continue
 		}
 
 		if exists && !c.params.Overwrite {
-			log.Printf("File %s already exists\n", dstPath)
+			log.Printf("File %s already exists\n", location)
+			c.count(existing)
+			if c.params.Pipeline != nil {
+				c.runPipeline(j)
+			}
 			continue
 		}
 
-		log.Printf("Downloading %d into %s\n", recMeta.RecordingID, dstPath)
-
-		if err = c.download(dstPath, recMeta); err != nil {
+		if err = c.downloadWithRetry(j, location); err != nil {
 			log.Println(err)
+			c.count(failed)
+			c.addFailed(j.rec)
+			c.fire(hooks.DownloadFailed, j, err)
 			continue
 		}
+		c.count(downloaded)
+		c.fire(hooks.RecordingDownloaded, j, nil)
+
+		if c.params.Pipeline != nil {
+			if err := c.params.Pipeline.Reset(j.path); err != nil {
+				log.Println(err)
+				continue
+			}
+			c.runPipeline(j)
+		}
 	}
 
 	return nil
 }
 
-func (c *command) download(dstPath string, recMeta dc.RecordingMeta) error {
-	dst, err := os.Create(dstPath)
+// fire notifies the hooks about the event of the recording.
+func (c *command) fire(event string, j job, err error) {
+	rec := j.rec
+	e := hooks.Event{
+		Event:     event,
+		Address:   c.params.Device.Address,
+		Device:    c.params.Device.Device,
+		Path:      c.location(j.path),
+		Recording: &rec,
+	}
 	if err != nil {
-		return err
+		e.Error = err.Error()
 	}
-	defer func() {
-		err := dst.Close()
-		if err != nil {
-			log.Println(err)
-		}
-	}()
 
-	if err = c.client.Download(recMeta, dst, c.params.Preview); err != nil {
-		return err
+	c.params.Hooks.Fire(e)
+}
+
+// runPipeline runs the processing steps on the downloaded recording. The
+// failed steps are reported in the summary of the run.
+func (c *command) runPipeline(j job) {
+	c.params.Pipeline.Process(j.path, pipeline.Recording{
+		Root:   c.root,
+		Path:   j.path,
+		Meta:   j.rec,
+		Device: c.params.Device,
+	})
+}
+
+// location returns the path of the recording within the sink, used in the
+// log messages.
+func (c *command) location(name string) string {
+	return path.Join(c.params.Sink.String(), name)
+}
+
+// migrate moves the recording downloaded with one of the previous layouts to
+// the path of the current layout, when the sink supports it. The existing
+// recording of the current layout is never replaced.
+func (c *command) migrate(recMeta dc.RecordingMeta, dstPath string) error {
+	mover, ok := c.params.Sink.(sink.Mover)
+	if !ok {
+		return nil
 	}
 
-	return nil
+	moved, err := sink.Migrate(mover, c.params.MigrateFrom, c.params.Device, recMeta, dstPath)
+	for _, srcPath := range moved {
+		log.Printf("Moved %s to %s\n", c.location(srcPath), c.location(dstPath))
+	}
+
+	return err
+}
+
+// downloadInWindow downloads the recording within the download windows. The
+// download paused at the end of the window starts again from the beginning
+// when the next window opens.
+func (c *command) downloadInWindow(j job, location string) error {
+	for {
+		end := c.waitWindow()
+
+		if c.params.Concurrency != nil {
+			level := c.params.Concurrency.Stats().Level
+			c.params.Progress.SetConcurrency(level)
+			log.Printf("Downloading %d into %s (concurrency %d)\n", j.rec.RecordingID, location, level)
+		} else {
+			log.Printf("Downloading %d into %s\n", j.rec.RecordingID, location)
+		}
+
+		err := c.download(j.path, j.rec, end)
+		if err != errWindowClosed {
+			return err
+		}
+
+		log.Printf("Download window closed, paused download of %s\n", location)
+	}
 }
 
-func handleExistingWithShortName(shortNamePath, dstPath string) error {
-	exists, err := fileExists(shortNamePath)
+// download writes the recording through the sink until the end time, when
+// it is not zero. The partially downloaded recording is discarded, so it is
+// downloaded again on the next run.
+func (c *command) download(dstPath string, recMeta dc.RecordingMeta, end time.Time) error {
+	release := c.params.Concurrency.Acquire()
+
+	dst, err := c.params.Sink.Create(dstPath)
 	if err != nil {
+		release(aimd.Result{Aborted: true})
 		return err
 	}
 
-	if !exists {
-		return nil
+	var w io.Writer = dst
+	if !end.IsZero() {
+		w = &windowWriter{Writer: dst, end: end}
 	}
 
-	return os.Rename(shortNamePath, dstPath)
-}
+	// the duration estimates the size of the recording, the preview is cut
+	// to about a minute
+	duration := time.Duration(recMeta.EndTimestamp-recMeta.StartTimestamp) * time.Second
+	if c.params.Preview {
+		duration = 0
+	}
+	tracked := c.params.Progress.Start(c.location(dstPath), duration)
+
+	transfer := newTransferWriter(w)
+	err = c.client.DownloadWithProgress(recMeta, transfer, c.params.Preview, func(p dc.DownloadProgress) {
+		tracked.Update(p.Bytes, p.Total)
+	})
+	release(transfer.result(err))
+	tracked.Finish(err == nil)
 
-func fileExists(dstPath string) (bool, error) {
-	_, err := os.Stat(dstPath)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return false, nil
+		if abortErr := dst.Abort(); abortErr != nil {
+			log.Println(abortErr)
 		}
 
-		return false, err
+		return err
 	}
 
-	return true, nil
+	return dst.Close()
 }
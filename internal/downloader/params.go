@@ -3,20 +3,42 @@ package downloader
 import (
 	"time"
 
+	"github.com/crabtree/defeway-toolbox/pkg/aimd"
 	dc "github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/crabtree/defeway-toolbox/pkg/hooks"
+	"github.com/crabtree/defeway-toolbox/pkg/layout"
+	"github.com/crabtree/defeway-toolbox/pkg/pipeline"
+	"github.com/crabtree/defeway-toolbox/pkg/progress"
+	"github.com/crabtree/defeway-toolbox/pkg/schedule"
+	"github.com/crabtree/defeway-toolbox/pkg/sink"
 )
 
 type DownloaderParams struct {
-	Channels       uint16
-	Concurrent     int
-	Date           time.Time
-	EndTime        time.Time
-	InputFile      string
-	Overwrite      bool
-	OutputDir      string
-	RecordingTypes uint16
-	StartTime      time.Time
-	Preview        bool
+	Bandwidth        *dc.Bandwidth
+	Channels         uint16
+	Concurrency      *aimd.Controller
+	Concurrent       int
+	ControlAddr      string
+	Date             time.Time
+	Device           layout.Device
+	EndTime          time.Time
+	FailedFile       string
+	Hooks            *hooks.Hooks
+	InputFile        string
+	Layout           *layout.Template
+	Location         *time.Location
+	MigrateFrom      []*layout.Template
+	Overwrite        bool
+	PauseAtWindowEnd bool
+	Pipeline         *pipeline.Pipeline
+	RecordingTypes   uint16
+	RetryBackoff     time.Duration
+	Retries          int
+	Sink             sink.Sink
+	StartTime        time.Time
+	Preview          bool
+	Progress         *progress.Tracker
+	Windows          schedule.Windows
 }
 
 func (dp *DownloaderParams) ToRecordingsFetchParams() dc.RecordingsFetchParams {
@@ -0,0 +1,77 @@
+package downloader
+
+import (
+	"io/ioutil"
+	"log"
+	"sort"
+	"time"
+
+	dc "github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+)
+
+// maxRetryBackoff caps the doubled delays between the retries.
+const maxRetryBackoff = 10 * time.Minute
+
+// downloadWithRetry downloads the recording, retrying the failed and stalled
+// downloads with the exponential backoff.
+func (c *command) downloadWithRetry(j job, location string) error {
+	for attempt := 1; ; attempt++ {
+		err := c.downloadInWindow(j, location)
+		if err == nil || attempt > c.params.Retries {
+			return err
+		}
+
+		delay := retryDelay(c.params.RetryBackoff, attempt)
+		log.Printf("Download of %s failed: %s, retry %d of %d in %s\n", location, err, attempt, c.params.Retries, delay)
+		time.Sleep(delay)
+	}
+}
+
+// retryDelay returns the delay before the retry, which doubles with every
+// attempt.
+func retryDelay(backoff time.Duration, attempt int) time.Duration {
+	delay := backoff
+	for i := 1; i < attempt && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > maxRetryBackoff {
+		delay = maxRetryBackoff
+	}
+
+	return delay
+}
+
+// addFailed adds the recording, which failed all retries, to the failed
+// list.
+func (c *command) addFailed(rec dc.RecordingMeta) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.failedRecordings = append(c.failedRecordings, rec)
+}
+
+// writeFailed writes the failed list in the format of the input file, so the
+// failed recordings are downloaded again with -file. The list is rewritten
+// by every run, and it is empty when all recordings were downloaded.
+func (c *command) writeFailed() error {
+	recordings := c.failedRecordings
+	sort.Slice(recordings, func(i, j int) bool {
+		return recordings[i].RecordingID < recordings[j].RecordingID
+	})
+
+	data, err := dc.NewForRecSearch(dc.DefewayRecSearch{SearchResults: recordings}).Marshal()
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(c.params.FailedFile, []byte(data), 0644); err != nil {
+		return err
+	}
+
+	if len(recordings) > 0 {
+		log.Printf("Wrote %d failed recordings to %s\n", len(recordings), c.params.FailedFile)
+	}
+
+	return nil
+}
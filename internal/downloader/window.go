@@ -0,0 +1,65 @@
+package downloader
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/crabtree/defeway-toolbox/pkg/sink"
+)
+
+// errWindowClosed aborts the download paused at the end of the window.
+var errWindowClosed = errors.New("the download window closed")
+
+// waitWindow blocks until any of the download windows is open. It returns
+// the time when the window closes, or the zero time when the downloads are
+// not paused at the end of the window.
+func (c *command) waitWindow() time.Time {
+	if len(c.params.Windows) == 0 {
+		return time.Time{}
+	}
+
+	for {
+		// the windows are given in the time zone of the DVR
+		now := time.Now().In(c.params.Location)
+		next := c.params.Windows.Next(now)
+		if !next.After(now) {
+			break
+		}
+
+		c.logWaiting(next)
+		time.Sleep(next.Sub(now))
+	}
+
+	if !c.params.PauseAtWindowEnd {
+		return time.Time{}
+	}
+
+	return c.params.Windows.End(time.Now().In(c.params.Location))
+}
+
+// logWaiting logs the wait for the window once, not by every worker.
+func (c *command) logWaiting(next time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.waitingFor.Equal(next) {
+		log.Printf("Waiting for the download window opening at %s\n", next.Format(time.RFC3339))
+		c.waitingFor = next
+	}
+}
+
+// windowWriter fails the writes after the end of the window, which aborts
+// the download in flight.
+type windowWriter struct {
+	sink.Writer
+	end time.Time
+}
+
+func (w *windowWriter) Write(p []byte) (int, error) {
+	if !time.Now().Before(w.end) {
+		return 0, errWindowClosed
+	}
+
+	return w.Writer.Write(p)
+}
@@ -0,0 +1,166 @@
+package exporter
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/crabtree/defeway-toolbox/pkg/inventory"
+	"github.com/crabtree/defeway-toolbox/pkg/metrics"
+)
+
+type command struct {
+	params ExporterParams
+
+	mu       sync.RWMutex
+	metrics  []metrics.Device
+	polledAt time.Time
+
+	// latest keeps the end timestamps of the latest recordings per device
+	// key and channel, so the channels without the recordings of the day
+	// keep their series
+	latestMu sync.Mutex
+	latest   map[string]map[uint16]uint64
+}
+
+func NewCommand(params ExporterParams) *command {
+	return &command{
+		params: params,
+		latest: make(map[string]map[uint16]uint64),
+	}
+}
+
+func (c *command) Run() error {
+	inv, err := inventory.Load(c.params.Inventory)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for {
+			c.poll(inv.Devices)
+			time.Sleep(c.params.Interval)
+		}
+	}()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", c.serveMetrics)
+
+	log.Printf("Serving metrics on %s/metrics\n", c.params.ListenAddr)
+	return http.ListenAndServe(c.params.ListenAddr, mux)
+}
+
+func (c *command) serveMetrics(rw http.ResponseWriter, req *http.Request) {
+	c.mu.RLock()
+	devices, polledAt := c.metrics, c.polledAt
+	c.mu.RUnlock()
+
+	rw.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := metrics.Write(rw, devices, polledAt); err != nil {
+		log.Println(err)
+	}
+}
+
+func (c *command) poll(devices []inventory.Device) {
+	var wg sync.WaitGroup
+	results := make([]metrics.Device, len(devices))
+	idxChan := make(chan int, len(devices))
+
+	for i := range devices {
+		idxChan <- i
+	}
+	close(idxChan)
+
+	for i := 0; i < c.params.Concurrent; i++ {
+		wg.Add(1)
+		go func(idxChan <-chan int) {
+			defer wg.Done()
+			for idx := range idxChan {
+				results[idx] = c.collect(devices[idx])
+			}
+		}(idxChan)
+	}
+
+	wg.Wait()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.metrics = results
+	c.polledAt = time.Now()
+}
+
+func (c *command) collect(device inventory.Device) metrics.Device {
+	m := metrics.Device{Device: device}
+
+	client := defewayclient.NewDeviceInfoClient(
+		c.params.ClientConfig(device))
+
+	start := time.Now()
+	juan, err := client.Fetch()
+	if err != nil {
+		log.Printf("Device %s at %s is down: %s\n", device.Key(), device.Address, err)
+		return m
+	}
+
+	m.Up = true
+	m.Latency = time.Since(start)
+	m.Juan = juan
+
+	if c.params.WithRecordings && juan.DeviceInfo != nil {
+		m.Latest = c.fetchLatestRecordings(device, juan.DeviceInfo.CamCount)
+	}
+
+	return m
+}
+
+// fetchLatestRecordings returns the end timestamps of the latest recordings
+// per channel. On the first poll of the device the channels without the
+// recordings of the day are searched back for the days within the lookback,
+// later the channels keep their last known timestamps.
+func (c *command) fetchLatestRecordings(device inventory.Device, camCount uint8) map[uint16]uint64 {
+	cfg := c.params.ClientConfig(device)
+	client := defewayclient.NewRecordingsClient(cfg, cfg)
+
+	c.latestMu.Lock()
+	known, polled := c.latest[device.Key()]
+	latest := make(map[uint16]uint64, len(known))
+	for ch, ts := range known {
+		latest[ch] = ts
+	}
+	c.latestMu.Unlock()
+
+	var days int
+	if !polled {
+		days = int(c.params.Lookback / (24 * time.Hour))
+	}
+
+	found, err := client.FetchLatest(time.Now(), days, channelsMask(camCount), c.params.RecordingTypes)
+	if err != nil {
+		log.Printf("Cannot fetch recordings of %s at %s: %s\n", device.Key(), device.Address, err)
+	}
+
+	for ch, ts := range found {
+		if ts > latest[ch] {
+			latest[ch] = ts
+		}
+	}
+
+	c.latestMu.Lock()
+	c.latest[device.Key()] = latest
+	c.latestMu.Unlock()
+
+	return latest
+}
+
+// channelsMask returns the channels bit mask of the recordings search for all
+// cameras of the DVR. The mask covers up to 16 channels.
+func channelsMask(camCount uint8) uint16 {
+	if camCount >= 16 {
+		return 0xffff
+	}
+
+	return uint16(1)<<camCount - 1
+}
@@ -0,0 +1,19 @@
+package exporter
+
+import (
+	"time"
+
+	"github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/crabtree/defeway-toolbox/pkg/inventory"
+)
+
+type ExporterParams struct {
+	ClientConfig   func(device inventory.Device) defewayclient.DefewayClientConfig
+	Concurrent     int
+	Interval       time.Duration
+	Inventory      string
+	ListenAddr     string
+	Lookback       time.Duration
+	RecordingTypes uint16
+	WithRecordings bool
+}
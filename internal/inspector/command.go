@@ -0,0 +1,93 @@
+package inspector
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	dc "github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/crabtree/defeway-toolbox/pkg/inventory"
+)
+
+type DeviceInfoClient interface {
+	Fetch() (*dc.DefewayJuan, error)
+}
+
+type command struct {
+	client DeviceInfoClient
+	output io.Writer
+	params InspectorParams
+}
+
+func NewCommand(client DeviceInfoClient, params InspectorParams) *command {
+	return &command{
+		client: client,
+		output: os.Stdout,
+		params: params,
+	}
+}
+
+func (c *command) Run() error {
+	info, err := c.client.Fetch()
+	if err != nil {
+		return err
+	}
+
+	device := inventory.NewDevice(c.params.Address, info)
+
+	if c.params.Format == FormatJSON {
+		enc := json.NewEncoder(c.output)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(device)
+	}
+
+	return c.writeText(device)
+}
+
+func (c *command) writeText(device inventory.Device) error {
+	lines := [][2]string{{"Address", device.Address}}
+
+	if info := device.DeviceInfo; info != nil {
+		lines = append(lines,
+			[2]string{"Name", info.Name},
+			[2]string{"Model", info.Model},
+			[2]string{"Serial number", info.SerialNumber},
+			[2]string{"Hardware", info.HWVer},
+			[2]string{"Firmware", fmt.Sprintf("%s (released %s)", info.SWVer, info.RelDateTime)},
+			[2]string{"Cameras", fmt.Sprint(info.CamCount)})
+	}
+
+	if network := device.Network; network != nil {
+		lines = append(lines,
+			[2]string{"MAC", network.MAC},
+			[2]string{"IP", fmt.Sprintf("%s/%s gateway %s dns %s", network.IP, network.Submask, network.Gateway, network.DNS)},
+			[2]string{"DHCP", enabled(network.DHCP)},
+			[2]string{"DDNS", strings.TrimSpace(enabled(network.DDNS) + " " + network.DDNSURL)})
+	}
+
+	for i, disk := range device.Disks {
+		lines = append(lines, [2]string{
+			fmt.Sprintf("HDD[%d]", i),
+			fmt.Sprintf("%s %s, used %d of %d MB", disk.Model, disk.StatusName(), disk.Used, disk.Capacity),
+		})
+	}
+
+	for _, line := range lines {
+		if _, err := fmt.Fprintf(c.output, "%-14s %s\n", line[0]+":", line[1]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func enabled(flag uint8) string {
+	if flag != 0 {
+		return "enabled"
+	}
+
+	return "disabled"
+}
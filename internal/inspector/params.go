@@ -0,0 +1,11 @@
+package inspector
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+type InspectorParams struct {
+	Address string
+	Format  string
+}
@@ -0,0 +1,125 @@
+package mosaic
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	dc "github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/crabtree/defeway-toolbox/pkg/snapshot"
+)
+
+const labelTimeLayout = "2006-01-02 15:04:05"
+
+type DeviceInfoClient interface {
+	Fetch() (*dc.DefewayJuan, error)
+}
+
+type command struct {
+	infoClient DeviceInfoClient
+	client     snapshot.Fetcher
+	params     MosaicParams
+}
+
+func NewCommand(infoClient DeviceInfoClient, client snapshot.Fetcher, params MosaicParams) *command {
+	return &command{
+		infoClient: infoClient,
+		client:     client,
+		params:     params,
+	}
+}
+
+func (c *command) Run() error {
+	info, err := c.infoClient.Fetch()
+	if err != nil {
+		return err
+	}
+
+	if info.DeviceInfo == nil || info.DeviceInfo.CamCount == 0 {
+		return fmt.Errorf("the device reports no cameras")
+	}
+
+	tiles := c.fetchTiles(int(info.DeviceInfo.CamCount))
+	img := snapshot.Mosaic(tiles, snapshot.MosaicConfig{
+		Columns:   c.params.Columns,
+		TileWidth: c.params.TileWidth,
+	})
+
+	if err := c.save(img); err != nil {
+		return err
+	}
+
+	log.Printf("Mosaic of %d channels saved to %s\n", len(tiles), c.params.Output)
+
+	return nil
+}
+
+func (c *command) fetchTiles(camCount int) []snapshot.Tile {
+	var wg sync.WaitGroup
+	tiles := make([]snapshot.Tile, camCount)
+	chChan := make(chan int, camCount)
+
+	for ch := 0; ch < camCount; ch++ {
+		chChan <- ch
+	}
+	close(chChan)
+
+	for i := 0; i < c.params.Concurrent; i++ {
+		wg.Add(1)
+		go func(chChan <-chan int) {
+			defer wg.Done()
+			for ch := range chChan {
+				tiles[ch] = c.fetchTile(ch)
+			}
+		}(chChan)
+	}
+
+	wg.Wait()
+
+	return tiles
+}
+
+func (c *command) fetchTile(ch int) snapshot.Tile {
+	var buf bytes.Buffer
+	err := c.client.Fetch(ch, &buf)
+	tile := snapshot.Tile{
+		Label: fmt.Sprintf("CH%d %s", ch+1, time.Now().Format(labelTimeLayout)),
+	}
+
+	if err != nil {
+		log.Printf("Channel %d: cannot fetch snapshot: %s\n", ch, err)
+		return tile
+	}
+
+	img, err := jpeg.Decode(&buf)
+	if err != nil {
+		log.Printf("Channel %d: invalid snapshot: %s\n", ch, err)
+		return tile
+	}
+	tile.Image = img
+
+	return tile
+}
+
+func (c *command) save(img image.Image) error {
+	f, err := os.Create(c.params.Output)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(c.params.Output)) {
+	case ".png":
+		return png.Encode(f, img)
+	default:
+		return jpeg.Encode(f, img, &jpeg.Options{Quality: c.params.Quality})
+	}
+}
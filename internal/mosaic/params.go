@@ -0,0 +1,9 @@
+package mosaic
+
+type MosaicParams struct {
+	Columns    int
+	Concurrent int
+	Output     string
+	Quality    int
+	TileWidth  int
+}
@@ -7,52 +7,89 @@ import (
 	"io/ioutil"
 	"log"
 	"math"
+	"math/rand"
 	"net"
 	"os"
 	"path"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/crabtree/defeway-toolbox/pkg/cmdtoolbox"
 	"github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/crabtree/defeway-toolbox/pkg/hooks"
+	"github.com/crabtree/defeway-toolbox/pkg/inventory"
+	"github.com/crabtree/defeway-toolbox/pkg/snapshot"
 )
 
 type command struct {
-	params ScannerParams
+	inventory   inventory.Inventory
+	inventoryMu sync.Mutex
+	limiter     *defewayclient.Limiter
+	params      ScannerParams
+	stats       stats
 }
 
 func NewCommand(params ScannerParams) *command {
 	return &command{
+		limiter: defewayclient.NewLimiter(defewayclient.LimiterConfig{
+			Jitter:             params.Jitter,
+			PerHostConnections: params.PerHostConnections,
+			RequestsPerSecond:  params.RequestsPerSecond,
+		}),
 		params: params,
 	}
 }
 
 func (c *command) Run() error {
-	var wg sync.WaitGroup
+	var probeWg sync.WaitGroup
+	var scanWg sync.WaitGroup
 	addrChan := make(chan string, 100)
+	liveChan := make(chan string, 100)
 
 	if err := cmdtoolbox.EnsureDir(c.params.LogDir); err != nil {
 		return err
 	}
 
-	wg.Add(1)
-	go func(addrChan chan<- string) {
-		defer wg.Done()
-		c.prepareAddresses(addrChan)
-	}(addrChan)
+	c.inventory.ScannedAt = time.Now()
+	go c.prepareAddresses(addrChan)
 
+	c.stats.probe.start()
+	for i := 0; i < c.params.ProbeConcurrent; i++ {
+		probeWg.Add(1)
+		go func(addrChan <-chan string, liveChan chan<- string) {
+			defer probeWg.Done()
+			c.probe(addrChan, liveChan)
+		}(addrChan, liveChan)
+	}
+
+	go func(liveChan chan<- string) {
+		probeWg.Wait()
+		c.stats.probe.stop()
+		close(liveChan)
+	}(liveChan)
+
+	c.stats.devInfo.start()
 	for i := 0; i < c.params.Concurrent; i++ {
-		wg.Add(1)
-		go func(addrChan <-chan string) {
-			defer wg.Done()
-			if err := c.scan(addrChan); err != nil {
+		scanWg.Add(1)
+		go func(liveChan <-chan string) {
+			defer scanWg.Done()
+			if err := c.scan(liveChan); err != nil {
 				log.Println(err)
 			}
-		}(addrChan)
+		}(liveChan)
 	}
 
-	wg.Wait()
-	return nil
+	scanWg.Wait()
+	c.stats.devInfo.stop()
+
+	log.Printf("Probe stage: %s\n", c.stats.probe.Dump())
+	log.Printf("Device info stage: %s\n", c.stats.devInfo.Dump())
+
+	c.params.Hooks.Fire(hooks.Event{Event: hooks.RunFinished, Summary: c.stats.summary()})
+
+	c.inventory.Sort()
+	return c.inventory.Save(path.Join(c.params.LogDir, inventory.FileName))
 }
 
 func (c *command) prepareAddresses(addrChan chan<- string) {
@@ -62,16 +99,32 @@ func (c *command) prepareAddresses(addrChan chan<- string) {
 	netSize := uint32(math.Pow(2, float64((netBase - netOnes))))
 	ipStart := binary.BigEndian.Uint32(c.params.NetAddr.To4())
 	ipEnd := ipStart + netSize
+	c.params.Progress.SetTotal(int(netSize) * len(c.params.Ports))
 
+	var addrs []string
 	for ipCurr := ipStart; ipCurr < ipEnd; ipCurr++ {
 		for _, port := range c.params.Ports {
 			ip := make(net.IP, 4)
 			binary.BigEndian.PutUint32(ip, ipCurr)
 			addr := fmt.Sprintf("%s:%d", ip.String(), port)
 
-			addrChan <- addr
+			if !c.params.Shuffle {
+				addrChan <- addr
+				continue
+			}
+
+			addrs = append(addrs, addr)
 		}
 	}
+
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	r.Shuffle(len(addrs), func(i, j int) {
+		addrs[i], addrs[j] = addrs[j], addrs[i]
+	})
+
+	for _, addr := range addrs {
+		addrChan <- addr
+	}
 }
 
 func (c *command) scan(addrChan <-chan string) error {
@@ -81,53 +134,95 @@ func (c *command) scan(addrChan <-chan string) error {
 
 		info, err := client.Fetch()
 		if err != nil {
+			c.stats.devInfo.inc(&c.stats.devInfo.Errors)
 			log.Println(err)
+			c.params.Hooks.Fire(hooks.Event{Event: hooks.DeviceError, Address: addr, Error: err.Error()})
 			continue
 		}
 
+		device := inventory.NewDevice(addr, info)
+		found := hooks.Event{Event: hooks.DeviceFound, Address: addr, Device: device.Key(), DeviceInfo: info.DeviceInfo}
+
 		payload := fmt.Sprintf(`<a href="http://%s">http://%s</a>`, addr, addr)
 		fileNameBase := fmt.Sprintf("%s.html", strings.ReplaceAll(addr, ":", "-"))
 
 		if info.EnvLoad.ErrorNo != 0 {
 			logFilePath := path.Join(c.params.LogDir, fmt.Sprintf("s-%s", fileNameBase))
 			writeLog(logFilePath, payload)
+			c.stats.devInfo.inc(&c.stats.devInfo.EnvErrors)
 			log.Printf("Found device http://%s, with env error %d\n", addr, info.EnvLoad.ErrorNo)
+			c.addToInventory(device)
+			found.Error = fmt.Sprintf("env error %d", info.EnvLoad.ErrorNo)
+			c.params.Hooks.Fire(found)
 			continue
 		}
 
+		c.stats.devInfo.inc(&c.stats.devInfo.Found)
 		log.Printf("Found device http://%s\n", addr)
+		c.params.Hooks.Fire(found)
 
 		logFilePath := path.Join(c.params.LogDir, fileNameBase)
 		infoSerialized, err := json.MarshalIndent(info, "", "  ")
 		if err != nil {
 			writeLog(logFilePath, payload)
+			c.addToInventory(device)
 			continue
 		} else {
 			payload += fmt.Sprintf(`<br><pre>%s</pre>`, string(infoSerialized))
 			writeLog(logFilePath, payload)
 		}
 
-		dstPath := path.Join(c.params.LogDir, strings.ReplaceAll(addr, ":", "-"))
-		if err := cmdtoolbox.EnsureDir(dstPath); err != nil {
+		device.Channels, err = c.fetchSnapshots(addr, info.DeviceInfo.CamCount)
+		c.addToInventory(device)
+		if err != nil {
 			return err
 		}
-		var ch uint8 = 0
-		snapshotClient := defewayclient.NewSnapshotClient(c.getClientConfig(addr))
-		for ; ch < info.DeviceInfo.CamCount; ch++ {
-			fp := path.Join(dstPath, fmt.Sprintf("ch-%d.jpg", ch))
-			err := c.fetchSnapshotForCh(snapshotClient, int(ch), fp)
-			if err != nil {
-				log.Printf("Error: %s\n", err)
-				os.Remove(fp)
-			}
-		}
 	}
 
 	return nil
 }
 
+func (c *command) fetchSnapshots(addr string, camCount uint8) ([]snapshot.ChannelResult, error) {
+	dstPath := path.Join(c.params.LogDir, strings.ReplaceAll(addr, ":", "-"))
+	if err := cmdtoolbox.EnsureDir(dstPath); err != nil {
+		return nil, err
+	}
+
+	channels := make([]int, camCount)
+	for ch := range channels {
+		channels[ch] = ch
+	}
+
+	snapshotClient := defewayclient.NewSnapshotClient(c.getClientConfig(addr))
+	results, data := snapshot.CheckChannels(snapshotClient, channels, c.params.FrozenInterval)
+	for i, result := range results {
+		if !result.IsOK() {
+			log.Printf("Channel %d of http://%s: %s\n", result.Channel, addr, result.Problem())
+		}
+
+		if data[i] == nil {
+			continue
+		}
+
+		fp := path.Join(dstPath, fmt.Sprintf("ch-%d.jpg", result.Channel))
+		if err := ioutil.WriteFile(fp, data[i], 0644); err != nil {
+			log.Printf("Error: %s\n", err)
+			os.Remove(fp)
+		}
+	}
+
+	return results, nil
+}
+
+func (c *command) addToInventory(device inventory.Device) {
+	c.inventoryMu.Lock()
+	defer c.inventoryMu.Unlock()
+
+	c.inventory.Add(device)
+}
+
 func (c *command) getClientConfig(addr string) defewayclient.DefewayClientConfig {
-	return defewayclient.DefewayClientConfig{
+	config := defewayclient.DefewayClientConfig{
 		Address:  addr,
 		Username: c.params.Username,
 		Password: c.params.Password,
@@ -135,22 +230,12 @@ func (c *command) getClientConfig(addr string) defewayclient.DefewayClientConfig
 			Timeout:           c.params.Timeout,
 			TLSSkipVerify:     c.params.TLSSkipVerify,
 			DisableKeepAlives: true,
+			Limiter:           c.limiter,
 		},
 	}
-}
 
-func (c *command) fetchSnapshotForCh(client *defewayclient.SnapshotClient, ch int, dstPath string) error {
-	dst, err := os.Create(dstPath)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		err := dst.Close()
-		if err != nil {
-			log.Println(err)
-		}
-	}()
-	return client.Fetch(int(ch), dst)
+	// the identity of the device is not known before it responds
+	return config.WithCredentials(c.params.Credentials)
 }
 
 func writeLog(logFilePath, payload string) {
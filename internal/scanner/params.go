@@ -3,17 +3,31 @@ package scanner
 import (
 	"net"
 	"time"
+
+	"github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/crabtree/defeway-toolbox/pkg/hooks"
+	"github.com/crabtree/defeway-toolbox/pkg/progress"
 )
 
 type ScannerParams struct {
-	Concurrent    int
-	LogDir        string
-	NetAddr       net.IP
-	NetMask       net.IPMask
-	Password      string
-	Ports         []uint
-	Timeout       time.Duration
-	TLSSkipVerify bool
-	Username      string
-	WithSnapshots bool
+	Concurrent         int
+	Credentials        defewayclient.Credentials
+	FrozenInterval     time.Duration
+	Hooks              *hooks.Hooks
+	Jitter             time.Duration
+	LogDir             string
+	NetAddr            net.IP
+	NetMask            net.IPMask
+	Password           string
+	PerHostConnections int
+	Ports              []uint
+	ProbeConcurrent    int
+	ProbeTimeout       time.Duration
+	Progress           *progress.Tracker
+	RequestsPerSecond  float64
+	Shuffle            bool
+	Timeout            time.Duration
+	TLSSkipVerify      bool
+	Username           string
+	WithSnapshots      bool
 }
@@ -0,0 +1,22 @@
+package scanner
+
+import (
+	"net"
+)
+
+func (c *command) probe(addrChan <-chan string, liveChan chan<- string) {
+	for addr := range addrChan {
+		release := c.limiter.Acquire(addr)
+		conn, err := net.DialTimeout("tcp", addr, c.params.ProbeTimeout)
+		release()
+		c.params.Progress.Done(false)
+		if err != nil {
+			c.stats.probe.inc(&c.stats.probe.Closed)
+			continue
+		}
+		conn.Close()
+
+		c.stats.probe.inc(&c.stats.probe.Open)
+		liveChan <- addr
+	}
+}
@@ -0,0 +1,84 @@
+package scanner
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+type counters struct {
+	mu      sync.Mutex
+	started time.Time
+	elapsed time.Duration
+}
+
+func (c *counters) inc(field *int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	*field++
+}
+
+func (c *counters) start() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.started = time.Now()
+}
+
+func (c *counters) stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.elapsed = time.Since(c.started)
+}
+
+type probeStats struct {
+	counters
+	Open   int
+	Closed int
+}
+
+func (s *probeStats) Dump() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return fmt.Sprintf("Probed=%d Open=%d Closed=%d Elapsed=%s",
+		s.Open+s.Closed, s.Open, s.Closed, s.elapsed)
+}
+
+type devInfoStats struct {
+	counters
+	Found     int
+	EnvErrors int
+	Errors    int
+}
+
+func (s *devInfoStats) Dump() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return fmt.Sprintf("Queried=%d Found=%d EnvErrors=%d Errors=%d Elapsed=%s",
+		s.Found+s.EnvErrors+s.Errors, s.Found, s.EnvErrors, s.Errors, s.elapsed)
+}
+
+// summary returns the counters of the run, reported to the hooks.
+func (s *stats) summary() map[string]int {
+	s.probe.mu.Lock()
+	defer s.probe.mu.Unlock()
+	s.devInfo.mu.Lock()
+	defer s.devInfo.mu.Unlock()
+
+	return map[string]int{
+		"probed":     s.probe.Open + s.probe.Closed,
+		"open":       s.probe.Open,
+		"found":      s.devInfo.Found,
+		"env-errors": s.devInfo.EnvErrors,
+		"errors":     s.devInfo.Errors,
+	}
+}
+
+type stats struct {
+	probe   probeStats
+	devInfo devInfoStats
+}
@@ -0,0 +1,80 @@
+package searcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	dc "github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+)
+
+const timeLayout = "2006-01-02 15:04:05"
+
+type RecordingsClient interface {
+	Fetch(fetchParams dc.RecordingsFetchParams) ([]dc.RecordingMeta, error)
+}
+
+type command struct {
+	client RecordingsClient
+	output io.Writer
+	params SearcherParams
+}
+
+func NewCommand(client RecordingsClient, params SearcherParams) *command {
+	return &command{
+		client: client,
+		output: os.Stdout,
+		params: params,
+	}
+}
+
+func (c *command) Run() error {
+	recordings, err := c.client.Fetch(c.params.FetchParams)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(recordings, func(i, j int) bool {
+		if recordings[i].StartTimestamp != recordings[j].StartTimestamp {
+			return recordings[i].StartTimestamp < recordings[j].StartTimestamp
+		}
+
+		return recordings[i].ChannelID < recordings[j].ChannelID
+	})
+
+	if c.params.Format == FormatJSON {
+		enc := json.NewEncoder(c.output)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(recordings)
+	}
+
+	return c.writeText(recordings)
+}
+
+// writeText lists the recordings with the times of the DVR clock, which the
+// DVR reports as UTC timestamps.
+func (c *command) writeText(recordings []dc.RecordingMeta) error {
+	if _, err := fmt.Fprintf(c.output, "%-10s %-7s %-4s %-19s  %-19s  %s\n",
+		"ID", "CHANNEL", "TYPE", "START", "END", "DURATION"); err != nil {
+		return err
+	}
+
+	for _, rec := range recordings {
+		start := time.Unix(int64(rec.StartTimestamp), 0).UTC()
+		end := time.Unix(int64(rec.EndTimestamp), 0).UTC()
+
+		if _, err := fmt.Fprintf(c.output, "%-10d %-7d %-4d %-19s  %-19s  %s\n",
+			rec.RecordingID, rec.ChannelID, rec.TypeID,
+			start.Format(timeLayout), end.Format(timeLayout), end.Sub(start)); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(c.output, "%d recordings\n", len(recordings))
+
+	return err
+}
@@ -0,0 +1,15 @@
+package searcher
+
+import (
+	dc "github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+)
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+type SearcherParams struct {
+	Format      string
+	FetchParams dc.RecordingsFetchParams
+}
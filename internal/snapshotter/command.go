@@ -0,0 +1,94 @@
+package snapshotter
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path"
+
+	dc "github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/crabtree/defeway-toolbox/pkg/snapshot"
+)
+
+type DeviceInfoClient interface {
+	Fetch() (*dc.DefewayJuan, error)
+}
+
+type command struct {
+	infoClient DeviceInfoClient
+	client     snapshot.Fetcher
+	params     SnapshotterParams
+}
+
+func NewCommand(infoClient DeviceInfoClient, client snapshot.Fetcher, params SnapshotterParams) *command {
+	return &command{
+		infoClient: infoClient,
+		client:     client,
+		params:     params,
+	}
+}
+
+func (c *command) Run() error {
+	channels, err := c.channels()
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, ch := range channels {
+		if err := c.save(ch); err != nil {
+			log.Printf("Channel %d: %s\n", ch, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d snapshots failed", failed, len(channels))
+	}
+
+	return nil
+}
+
+func (c *command) channels() ([]int, error) {
+	if len(c.params.Channels) > 0 {
+		return c.params.Channels, nil
+	}
+
+	info, err := c.infoClient.Fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	if info.DeviceInfo == nil || info.DeviceInfo.CamCount == 0 {
+		return nil, fmt.Errorf("the device reports no cameras")
+	}
+
+	var channels []int
+	for ch := 1; ch <= int(info.DeviceInfo.CamCount); ch++ {
+		channels = append(channels, ch)
+	}
+
+	return channels, nil
+}
+
+func (c *command) save(ch int) error {
+	var buf bytes.Buffer
+	if err := c.client.Fetch(ch-1, &buf); err != nil {
+		return err
+	}
+
+	analysis, err := snapshot.Analyze(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("invalid snapshot: %s", err)
+	}
+
+	fp := path.Join(c.params.OutputDir, fmt.Sprintf("ch-%d.jpg", ch))
+	if err := ioutil.WriteFile(fp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+
+	log.Printf("Channel %d: %dx%d %s image saved to %s\n", ch, analysis.Width, analysis.Height, analysis.Verdict, fp)
+
+	return nil
+}
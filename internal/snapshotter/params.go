@@ -0,0 +1,6 @@
+package snapshotter
+
+type SnapshotterParams struct {
+	Channels  []int // counted from 1, empty means all channels
+	OutputDir string
+}
@@ -0,0 +1,42 @@
+package tamper
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/crabtree/defeway-toolbox/pkg/inventory"
+	"github.com/crabtree/defeway-toolbox/pkg/layout"
+)
+
+// baselines stores the reference snapshots in the directory per device and
+// channel. The devices are identified by their serial number, so the
+// baselines survive the IP address changes.
+type baselines struct {
+	dir string
+}
+
+func (b *baselines) path(device inventory.Device, ch int) string {
+	return path.Join(b.dir, layout.Sanitize(device.Key()), fmt.Sprintf("ch-%d.jpg", ch))
+}
+
+func (b *baselines) Save(device inventory.Device, ch int, data []byte) error {
+	fp := b.path(device, ch)
+	if err := os.MkdirAll(path.Dir(fp), os.ModePerm); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(fp, data, 0644)
+}
+
+// Load returns the baseline snapshot of the channel, or nil when the baseline
+// was not captured yet.
+func (b *baselines) Load(device inventory.Device, ch int) ([]byte, error) {
+	data, err := ioutil.ReadFile(b.path(device, ch))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	return data, err
+}
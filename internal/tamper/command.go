@@ -0,0 +1,228 @@
+package tamper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/crabtree/defeway-toolbox/pkg/health"
+	"github.com/crabtree/defeway-toolbox/pkg/inventory"
+	"github.com/crabtree/defeway-toolbox/pkg/snapshot"
+)
+
+type command struct {
+	baselines *baselines
+	output    io.Writer
+	params    TamperParams
+	reports   []deviceReport
+	mu        sync.Mutex
+}
+
+type deviceReport struct {
+	health.Result
+	Status   health.Status
+	Channels []channelReport
+}
+
+type channelReport struct {
+	Channel int
+	Image   snapshot.Verdict `json:",omitempty"`
+	snapshot.Comparison
+	Error string `json:",omitempty"`
+}
+
+func NewCommand(params TamperParams) *command {
+	return &command{
+		baselines: &baselines{dir: params.BaselineDir},
+		output:    os.Stdout,
+		params:    params,
+	}
+}
+
+func (c *command) Run() error {
+	var wg sync.WaitGroup
+
+	inv, err := inventory.Load(c.params.Inventory)
+	if err != nil {
+		return err
+	}
+
+	devices := inv.Devices
+	if c.params.Device != "" {
+		device, ok := inv.Find(c.params.Device)
+		if !ok {
+			return fmt.Errorf("device %s not found in the inventory", c.params.Device)
+		}
+		devices = []inventory.Device{*device}
+	}
+
+	devChan := make(chan inventory.Device, len(devices))
+	for _, device := range devices {
+		devChan <- device
+	}
+	close(devChan)
+
+	for i := 0; i < c.params.Concurrent; i++ {
+		wg.Add(1)
+		go func(devChan <-chan inventory.Device) {
+			defer wg.Done()
+			c.check(devChan)
+		}(devChan)
+	}
+
+	wg.Wait()
+
+	sort.Slice(c.reports, func(i, j int) bool {
+		return c.reports[i].Address < c.reports[j].Address
+	})
+
+	if c.params.Format == FormatJSON {
+		return c.writeJSON()
+	}
+
+	results := make([]health.Result, 0, len(c.reports))
+	for _, report := range c.reports {
+		results = append(results, report.Result)
+	}
+
+	return health.WriteReport(c.output, "TAMPER", results)
+}
+
+// Status returns the worst status of all checked devices.
+func (c *command) Status() health.Status {
+	status := health.Pass
+	for _, report := range c.reports {
+		if report.Status > status {
+			status = report.Status
+		}
+	}
+
+	return status
+}
+
+func (c *command) check(devChan <-chan inventory.Device) {
+	for device := range devChan {
+		report := deviceReport{
+			Result: health.Result{
+				Key:     device.Key(),
+				Address: device.Address,
+			},
+		}
+
+		if device.DeviceInfo == nil {
+			report.Add("tamper", health.Warn, "no device info in the inventory")
+		} else {
+			c.checkChannels(&report, device)
+		}
+		report.Status = report.Result.Status()
+
+		c.mu.Lock()
+		c.reports = append(c.reports, report)
+		c.mu.Unlock()
+	}
+}
+
+func (c *command) checkChannels(report *deviceReport, device inventory.Device) {
+	client := defewayclient.NewSnapshotClient(c.params.ClientConfig(device))
+
+	for ch := 0; ch < int(device.DeviceInfo.CamCount); ch++ {
+		var buf bytes.Buffer
+		if err := client.Fetch(ch, &buf); err != nil {
+			report.Add("snapshot", health.Fail, "channel %d snapshot error: %s", ch, err)
+			report.Channels = append(report.Channels, channelReport{Channel: ch, Error: err.Error()})
+			continue
+		}
+
+		if c.params.Capture {
+			c.capture(report, device, ch, buf.Bytes())
+			continue
+		}
+
+		chReport, err := c.compare(device, ch, buf.Bytes())
+		report.Channels = append(report.Channels, chReport)
+		if err != nil {
+			report.Add("tamper", health.Warn, "channel %d %s", ch, err)
+			continue
+		}
+
+		c.addFinding(report, chReport)
+	}
+}
+
+func (c *command) capture(report *deviceReport, device inventory.Device, ch int, data []byte) {
+	analysis, err := snapshot.Analyze(data)
+	if err != nil {
+		report.Add("baseline", health.Fail, "channel %d invalid snapshot: %s", ch, err)
+		return
+	}
+
+	if err := c.baselines.Save(device, ch, data); err != nil {
+		report.Add("baseline", health.Fail, "channel %d cannot save baseline: %s", ch, err)
+		return
+	}
+
+	if analysis.Verdict != snapshot.VerdictOK {
+		report.Add("baseline", health.Warn, "channel %d baseline captured from %s image", ch, analysis.Verdict)
+		return
+	}
+
+	report.Add("baseline", health.Pass, "channel %d baseline captured", ch)
+}
+
+func (c *command) compare(device inventory.Device, ch int, data []byte) (channelReport, error) {
+	chReport := channelReport{Channel: ch}
+
+	baselineData, err := c.baselines.Load(device, ch)
+	if err != nil {
+		chReport.Error = err.Error()
+		return chReport, err
+	}
+
+	if baselineData == nil {
+		chReport.Error = "no baseline"
+		return chReport, fmt.Errorf("has no baseline, capture it with -capture")
+	}
+
+	baseline, err := snapshot.Analyze(baselineData)
+	if err != nil {
+		chReport.Error = err.Error()
+		return chReport, fmt.Errorf("invalid baseline: %s", err)
+	}
+
+	current, err := snapshot.Analyze(data)
+	if err != nil {
+		chReport.Error = err.Error()
+		return chReport, fmt.Errorf("invalid snapshot: %s", err)
+	}
+
+	chReport.Image = current.Verdict
+	chReport.Comparison = snapshot.Compare(baseline, current, c.params.MinSimilarity)
+
+	return chReport, nil
+}
+
+func (c *command) addFinding(report *deviceReport, ch channelReport) {
+	switch ch.Verdict {
+	case snapshot.TamperOK:
+		report.Add("tamper", health.Pass, "channel %d similarity %.2f", ch.Channel, ch.Similarity)
+	case snapshot.TamperDefocused:
+		report.Add("tamper", health.Warn, "channel %d defocused, sharpness %.0f%% of baseline",
+			ch.Channel, ch.SharpnessRatio*100)
+	case snapshot.TamperBlocked:
+		report.Add("tamper", health.Fail, "channel %d blocked, %s image", ch.Channel, ch.Image)
+	default:
+		report.Add("tamper", health.Fail, "channel %d view changed, similarity %.2f", ch.Channel, ch.Similarity)
+	}
+}
+
+func (c *command) writeJSON() error {
+	enc := json.NewEncoder(c.output)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(c.reports)
+}
@@ -0,0 +1,22 @@
+package tamper
+
+import (
+	"github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/crabtree/defeway-toolbox/pkg/inventory"
+)
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+type TamperParams struct {
+	BaselineDir   string
+	Capture       bool
+	ClientConfig  func(device inventory.Device) defewayclient.DefewayClientConfig
+	Concurrent    int
+	Device        string
+	Format        string
+	Inventory     string
+	MinSimilarity float64
+}
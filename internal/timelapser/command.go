@@ -0,0 +1,135 @@
+package timelapser
+
+import (
+	"bytes"
+	"fmt"
+	"image/jpeg"
+	"log"
+	"os"
+	"path"
+	"time"
+
+	"github.com/crabtree/defeway-toolbox/pkg/snapshot"
+	"github.com/crabtree/defeway-toolbox/pkg/timelapse"
+)
+
+type command struct {
+	client snapshot.Fetcher
+	params TimelapserParams
+	store  *timelapse.Store
+}
+
+func NewCommand(client snapshot.Fetcher, params TimelapserParams) *command {
+	if params.Location == nil {
+		params.Location = time.Local
+	}
+
+	return &command{
+		client: client,
+		params: params,
+		store:  &timelapse.Store{Dir: params.OutputDir, Location: params.Location},
+	}
+}
+
+func (c *command) Run() error {
+	if c.params.Assemble {
+		return c.assemble()
+	}
+
+	ticker := time.NewTicker(c.params.Interval)
+	defer ticker.Stop()
+
+	for now := time.Now(); ; now = <-ticker.C {
+		// the windows are given in the time zone of the DVR
+		if c.params.Windows.Contains(now.In(c.params.Location)) {
+			c.capture(now)
+		}
+
+		if c.params.Retention > 0 {
+			c.prune(now.Add(-c.params.Retention))
+		}
+	}
+}
+
+func (c *command) channels() []int {
+	var channels []int
+	for ch := 1; ch <= 16; ch++ {
+		if c.params.Channels&(1<<uint(ch-1)) != 0 {
+			channels = append(channels, ch)
+		}
+	}
+
+	return channels
+}
+
+func (c *command) capture(now time.Time) {
+	for _, ch := range c.channels() {
+		var buf bytes.Buffer
+		if err := c.client.Fetch(ch-1, &buf); err != nil {
+			log.Printf("Channel %d: cannot fetch snapshot: %s\n", ch, err)
+			continue
+		}
+
+		// the DVR responds with the error page when the channel has no video
+		if _, err := jpeg.DecodeConfig(bytes.NewReader(buf.Bytes())); err != nil {
+			log.Printf("Channel %d: invalid snapshot: %s\n", ch, err)
+			continue
+		}
+
+		if _, err := c.store.Save(ch, now, buf.Bytes()); err != nil {
+			log.Printf("Channel %d: cannot save snapshot: %s\n", ch, err)
+		}
+	}
+}
+
+func (c *command) prune(before time.Time) {
+	for _, ch := range c.channels() {
+		removed, err := c.store.Prune(ch, before)
+		if err != nil {
+			log.Printf("Channel %d: cannot remove old snapshots: %s\n", ch, err)
+		}
+
+		if removed > 0 {
+			log.Printf("Channel %d: removed %d snapshots older than %s\n", ch, removed, before.Format(time.RFC3339))
+		}
+	}
+}
+
+func (c *command) assemble() error {
+	for _, ch := range c.channels() {
+		frames, err := c.store.Frames(ch, c.params.From, c.params.To)
+		if err != nil {
+			return err
+		}
+
+		if len(frames) == 0 {
+			log.Printf("Channel %d: no snapshots to assemble\n", ch)
+			continue
+		}
+
+		fp := path.Join(c.params.OutputDir, fmt.Sprintf("ch-%d.avi", ch))
+		if err := c.assembleFile(fp, frames); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *command) assembleFile(fp string, frames []timelapse.Frame) error {
+	f, err := os.Create(fp)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	written, err := timelapse.Assemble(frames, f, c.params.FPS)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Assembled %d of %d snapshots from %s to %s into %s\n", written, len(frames),
+		frames[0].Time.Format(time.RFC3339), frames[len(frames)-1].Time.Format(time.RFC3339), fp)
+
+	return nil
+}
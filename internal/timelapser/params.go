@@ -0,0 +1,20 @@
+package timelapser
+
+import (
+	"time"
+
+	"github.com/crabtree/defeway-toolbox/pkg/schedule"
+)
+
+type TimelapserParams struct {
+	Assemble  bool
+	Channels  uint16
+	FPS       int
+	From      time.Time
+	Interval  time.Duration
+	Location  *time.Location
+	OutputDir string
+	Retention time.Duration
+	To        time.Time
+	Windows   schedule.Windows
+}
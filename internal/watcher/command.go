@@ -0,0 +1,137 @@
+package watcher
+
+import (
+	"bytes"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/crabtree/defeway-toolbox/pkg/inventory"
+	"github.com/crabtree/defeway-toolbox/pkg/notify"
+)
+
+type command struct {
+	params WatcherParams
+}
+
+func NewCommand(params WatcherParams) *command {
+	if params.Location == nil {
+		params.Location = time.Local
+	}
+
+	if params.Seen == nil {
+		params.Seen, _ = notify.OpenSeen("")
+	}
+
+	return &command{
+		params: params,
+	}
+}
+
+func (c *command) Run() error {
+	ticker := time.NewTicker(c.params.Interval)
+	defer ticker.Stop()
+
+	for now := time.Now(); ; now = <-ticker.C {
+		c.poll(now)
+
+		// the recordings not seen within the lookback are not found again
+		c.params.Seen.Prune(now.Add(-2 * c.params.Lookback))
+		if err := c.params.Seen.Save(); err != nil {
+			log.Printf("Cannot save the seen recordings: %s\n", err)
+		}
+	}
+}
+
+func (c *command) poll(now time.Time) {
+	var wg sync.WaitGroup
+
+	devChan := make(chan inventory.Device, len(c.params.Devices))
+	for _, device := range c.params.Devices {
+		devChan <- device
+	}
+	close(devChan)
+
+	for i := 0; i < c.params.Concurrent; i++ {
+		wg.Add(1)
+		go func(devChan <-chan inventory.Device) {
+			defer wg.Done()
+			for device := range devChan {
+				c.watch(device, now)
+			}
+		}(devChan)
+	}
+
+	wg.Wait()
+}
+
+func (c *command) watch(device inventory.Device, now time.Time) {
+	config := c.params.ClientConfig(device)
+	client := defewayclient.NewRecordingsClient(config, config)
+
+	// the lookback window is given in the time zone of the DVR
+	end := now.In(c.params.Location)
+	recordings, err := client.FetchWindow(end.Add(-c.params.Lookback), end, c.params.Channels, c.params.RecordingTypes)
+	if err != nil {
+		// the recordings found by the other searches are still notified
+		log.Printf("Device %s: cannot search recordings: %s\n", device.Key(), err)
+	}
+
+	for _, rec := range recordings {
+		// some firmwares return all recordings of the channels regardless of
+		// the searched types
+		if uint16(rec.TypeID)&c.params.RecordingTypes == 0 {
+			continue
+		}
+
+		n := notify.New(device.Key(), device.Address, rec, c.params.ChannelNames)
+		if !c.params.Seen.Add(n.Key(), now) {
+			continue
+		}
+
+		// the quiet hours are given in the time zone of the DVR
+		if len(c.params.QuietHours) > 0 && c.params.QuietHours.Contains(now.In(c.params.Location)) {
+			log.Printf("Quiet hours, suppressed: %s\n", n.Subject())
+			continue
+		}
+
+		if c.params.Snapshot {
+			c.attachSnapshot(&n, config)
+		}
+
+		if !c.notify(n) {
+			// none of the notifiers delivered it, retry with the next poll
+			c.params.Seen.Remove(n.Key())
+		}
+	}
+}
+
+func (c *command) attachSnapshot(n *notify.Notification, config defewayclient.DefewayClientConfig) {
+	var buf bytes.Buffer
+	if err := defewayclient.NewSnapshotClient(config).Fetch(n.Channel-1, &buf); err != nil {
+		n.SnapshotError = err.Error()
+		log.Printf("Channel %d: cannot fetch snapshot: %s\n", n.Channel, err)
+		return
+	}
+
+	n.Snapshot = buf.Bytes()
+}
+
+// notify sends the notification with all notifiers, it reports whether any
+// of them delivered it.
+func (c *command) notify(n notify.Notification) bool {
+	log.Printf("Notify: %s\n", n.Subject())
+
+	delivered := false
+	for _, notifier := range c.params.Notifiers {
+		if err := notifier.Notify(n); err != nil {
+			log.Printf("Notifier %s failed: %s\n", notifier, err)
+			continue
+		}
+
+		delivered = true
+	}
+
+	return delivered
+}
@@ -0,0 +1,26 @@
+package watcher
+
+import (
+	"time"
+
+	"github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/crabtree/defeway-toolbox/pkg/inventory"
+	"github.com/crabtree/defeway-toolbox/pkg/notify"
+	"github.com/crabtree/defeway-toolbox/pkg/schedule"
+)
+
+type WatcherParams struct {
+	Channels       uint16
+	ChannelNames   map[int]string
+	ClientConfig   func(device inventory.Device) defewayclient.DefewayClientConfig
+	Concurrent     int
+	Devices        []inventory.Device
+	Interval       time.Duration
+	Location       *time.Location
+	Lookback       time.Duration
+	Notifiers      []notify.Notifier
+	QuietHours     schedule.Windows
+	RecordingTypes uint16
+	Seen           *notify.Seen
+	Snapshot       bool
+}
@@ -0,0 +1,258 @@
+package aimd
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+)
+
+const (
+	// minGain is the improvement of the throughput which keeps the
+	// increased concurrency.
+	minGain = 1.1
+	// maxLatencyRatio is the growth of the time to the first byte over the
+	// best observed one, which stops the increase.
+	maxLatencyRatio = 2.0
+	// holdRounds is the number of the rounds without the increase after the
+	// level was decreased by one, so the level does not swing when the
+	// throughput is capped by the link.
+	holdRounds = 5
+)
+
+type Config struct {
+	Min     int
+	Max     int
+	Initial int
+}
+
+// Result describes the finished transfer.
+type Result struct {
+	Bytes int64
+	// Latency is the time to the first byte of the transfer.
+	Latency time.Duration
+	Err     error
+	// Aborted is the transfer stopped for the reason unrelated to the
+	// device, it does not change the level.
+	Aborted bool
+}
+
+// Change describes the change of the concurrency level.
+type Change struct {
+	From   int
+	To     int
+	Reason string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("concurrency %d -> %d: %s", c.From, c.To, c.Reason)
+}
+
+// Controller adapts the number of concurrent transfers to one device with
+// the additive increase and the multiplicative decrease. The level is halved
+// after the failed transfer, and increased by one after the round of the
+// successful transfers, as long as the throughput grows and the latency does
+// not. A nil Controller does not limit.
+type Controller struct {
+	min int
+	max int
+	now func() time.Time
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	level  int
+	active int
+	// onChange is called with the changes of the level, under the lock.
+	onChange func(Change)
+
+	round      roundStats
+	throughput float64 // of the previous round
+	increased  bool    // the previous round increased the level
+	hold       int
+	minLatency time.Duration
+	peak       int
+	changes    int
+}
+
+type roundStats struct {
+	started   time.Time
+	successes int
+	bytes     int64
+	latency   time.Duration
+}
+
+func NewController(config Config, onChange func(Change)) (*Controller, error) {
+	if config.Min < 1 || config.Max < config.Min {
+		return nil, fmt.Errorf("specify minimum concurrency of at least 1 and not greater than maximum")
+	}
+
+	level := config.Initial
+	if level < config.Min {
+		level = config.Min
+	}
+	if level > config.Max {
+		level = config.Max
+	}
+
+	c := &Controller{
+		min:      config.Min,
+		max:      config.Max,
+		now:      time.Now,
+		level:    level,
+		peak:     level,
+		onChange: onChange,
+	}
+	c.cond = sync.NewCond(&c.mu)
+
+	return c, nil
+}
+
+// Acquire blocks until the transfer is allowed by the current level. The
+// returned function reports the result of the transfer and releases its
+// slot, it must be called once.
+func (c *Controller) Acquire() func(Result) {
+	if c == nil {
+		return func(Result) {}
+	}
+
+	c.mu.Lock()
+	for c.active >= c.level {
+		c.cond.Wait()
+	}
+	c.active++
+	if c.round.started.IsZero() {
+		c.round.started = c.now()
+	}
+	c.mu.Unlock()
+
+	var once sync.Once
+	return func(r Result) {
+		once.Do(func() {
+			c.release(r)
+		})
+	}
+}
+
+func (c *Controller) release(r Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer c.cond.Broadcast()
+
+	c.active--
+
+	if r.Aborted {
+		return
+	}
+
+	if r.Err != nil {
+		c.set(c.level/2, fmt.Sprintf("transfer failed: %s", r.Err))
+		c.newRound(false)
+		return
+	}
+
+	c.round.successes++
+	c.round.bytes += r.Bytes
+	c.round.latency += r.Latency
+	if c.minLatency == 0 || (r.Latency > 0 && r.Latency < c.minLatency) {
+		c.minLatency = r.Latency
+	}
+
+	if c.round.successes < c.level {
+		return
+	}
+
+	c.adjust()
+}
+
+// adjust decides the level after the round of as many successful transfers
+// as the level.
+func (c *Controller) adjust() {
+	elapsed := c.now().Sub(c.round.started)
+	throughput := float64(c.round.bytes) / elapsed.Seconds()
+	latency := c.round.latency / time.Duration(c.round.successes)
+
+	switch {
+	case c.increased && throughput < c.throughput*minGain:
+		c.set(c.level-1, fmt.Sprintf("throughput %s did not grow from %s", formatRate(throughput), formatRate(c.throughput)))
+		c.hold = holdRounds
+	case c.minLatency > 0 && float64(latency) > float64(c.minLatency)*maxLatencyRatio:
+		c.set(c.level-1, fmt.Sprintf("latency %s grew from %s", latency.Round(time.Millisecond), c.minLatency.Round(time.Millisecond)))
+		c.hold = holdRounds
+	case c.hold > 0:
+		c.hold--
+	default:
+		increased := c.set(c.level+1, fmt.Sprintf("throughput %s", formatRate(throughput)))
+		c.throughput = throughput
+		c.newRound(increased)
+		return
+	}
+
+	c.newRound(false)
+}
+
+func (c *Controller) newRound(increased bool) {
+	c.increased = increased
+	c.round = roundStats{}
+	if c.active > 0 {
+		c.round.started = c.now()
+	}
+}
+
+// set changes the level within the limits, it reports whether the level
+// increased.
+func (c *Controller) set(level int, reason string) bool {
+	if level < c.min {
+		level = c.min
+	}
+	if level > c.max {
+		level = c.max
+	}
+
+	if level == c.level {
+		return false
+	}
+
+	change := Change{From: c.level, To: level, Reason: reason}
+	c.level = level
+	c.changes++
+	if level > c.peak {
+		c.peak = level
+	}
+
+	if c.onChange != nil {
+		c.onChange(change)
+	}
+
+	return change.To > change.From
+}
+
+// Stats describes the concurrency of the transfers.
+type Stats struct {
+	Level   int
+	Min     int
+	Max     int
+	Peak    int
+	Changes int
+}
+
+func (s Stats) String() string {
+	return fmt.Sprintf("%d (peak %d, range %d-%d, %d changes)", s.Level, s.Peak, s.Min, s.Max, s.Changes)
+}
+
+func (c *Controller) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return Stats{
+		Level:   c.level,
+		Min:     c.min,
+		Max:     c.max,
+		Peak:    c.peak,
+		Changes: c.changes,
+	}
+}
+
+func formatRate(bytesPerSecond float64) string {
+	return defewayclient.FormatRate(int64(bytesPerSecond))
+}
@@ -0,0 +1,150 @@
+package aimd
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type manualClock struct {
+	t time.Time
+}
+
+func (m *manualClock) now() time.Time {
+	return m.t
+}
+
+// round runs the n parallel transfers lasting the duration with the result.
+func round(c *Controller, clock *manualClock, n int, d time.Duration, r Result) {
+	releases := make([]func(Result), n)
+	for i := range releases {
+		releases[i] = c.Acquire()
+	}
+
+	clock.t = clock.t.Add(d)
+	for _, release := range releases {
+		release(r)
+	}
+}
+
+func newController(t *testing.T, config Config) (*Controller, *manualClock) {
+	c, err := NewController(config, nil)
+	require.NoError(t, err)
+
+	clock := &manualClock{t: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c.now = clock.now
+
+	return c, clock
+}
+
+func TestNewController(t *testing.T) {
+	t.Run("should keep initial level within limits", func(t *testing.T) {
+		c, err := NewController(Config{Min: 2, Max: 4, Initial: 8}, nil)
+
+		require.NoError(t, err)
+		require.Equal(t, 4, c.Stats().Level)
+	})
+
+	t.Run("should return error for invalid limits", func(t *testing.T) {
+		for _, config := range []Config{{Min: 0, Max: 2}, {Min: 3, Max: 2}} {
+			_, err := NewController(config, nil)
+
+			require.Error(t, err)
+		}
+	})
+}
+
+func TestController(t *testing.T) {
+	ok := Result{Bytes: 1 << 20, Latency: 100 * time.Millisecond}
+
+	t.Run("should not limit when controller is nil", func(t *testing.T) {
+		var c *Controller
+
+		c.Acquire()(Result{})
+	})
+
+	t.Run("should halve level after failure", func(t *testing.T) {
+		var changes []Change
+		c, err := NewController(Config{Min: 1, Max: 8, Initial: 6}, func(change Change) {
+			changes = append(changes, change)
+		})
+		require.NoError(t, err)
+
+		c.Acquire()(Result{Err: errors.New("connection reset by peer")})
+		require.Equal(t, 3, c.Stats().Level)
+
+		c.Acquire()(Result{Err: errors.New("connection reset by peer")})
+		c.Acquire()(Result{Err: errors.New("EOF")})
+		c.Acquire()(Result{Err: errors.New("disk full"), Aborted: true})
+		require.Equal(t, 1, c.Stats().Level)
+		require.Len(t, changes, 2)
+		require.Equal(t, "concurrency 6 -> 3: transfer failed: connection reset by peer", changes[0].String())
+	})
+
+	t.Run("should increase level while throughput grows", func(t *testing.T) {
+		c, clock := newController(t, Config{Min: 1, Max: 3, Initial: 1})
+
+		round(c, clock, 1, time.Second, ok)
+		require.Equal(t, 2, c.Stats().Level)
+
+		round(c, clock, 2, time.Second, ok)
+		require.Equal(t, 3, c.Stats().Level)
+
+		round(c, clock, 3, time.Second, ok)
+		require.Equal(t, Stats{Level: 3, Min: 1, Max: 3, Peak: 3, Changes: 2}, c.Stats())
+	})
+
+	t.Run("should decrease level when throughput does not grow", func(t *testing.T) {
+		c, clock := newController(t, Config{Min: 1, Max: 4, Initial: 1})
+
+		round(c, clock, 1, time.Second, ok)
+		require.Equal(t, 2, c.Stats().Level)
+
+		// the parallel transfers take twice as long
+		round(c, clock, 2, 2*time.Second, ok)
+		require.Equal(t, 1, c.Stats().Level)
+
+		// the level holds for the next rounds
+		for i := 0; i < holdRounds; i++ {
+			round(c, clock, 1, time.Second, ok)
+		}
+		require.Equal(t, 1, c.Stats().Level)
+
+		round(c, clock, 1, time.Second, ok)
+		require.Equal(t, 2, c.Stats().Level)
+	})
+
+	t.Run("should decrease level when latency grows", func(t *testing.T) {
+		c, clock := newController(t, Config{Min: 1, Max: 4, Initial: 2})
+
+		release := c.Acquire()
+		round(c, clock, 1, time.Second, Result{Bytes: ok.Bytes, Latency: time.Second})
+		release(ok)
+
+		require.Equal(t, 1, c.Stats().Level)
+	})
+
+	t.Run("should block transfers above level", func(t *testing.T) {
+		c, err := NewController(Config{Min: 1, Max: 1}, nil)
+		require.NoError(t, err)
+
+		release := c.Acquire()
+		acquired := make(chan struct{})
+		go func() {
+			c.Acquire()(ok)
+			close(acquired)
+		}()
+
+		select {
+		case <-acquired:
+			t.Fatal("acquired transfer above level")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		release(ok)
+		release(ok)
+		<-acquired
+	})
+}
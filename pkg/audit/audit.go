@@ -0,0 +1,183 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/crabtree/defeway-toolbox/pkg/health"
+	"github.com/crabtree/defeway-toolbox/pkg/inventory"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	policyDateLayout  = "2006-01-02"
+	releaseDateLayout = "2006/01/02 15:04"
+)
+
+// Policy describes the approved models and firmware versions of the DVRs and
+// the configuration which is considered risky.
+type Policy struct {
+	Allowed              []AllowedFirmware `yaml:"allowed"`
+	AllowDDNSCredentials bool              `yaml:"allow-ddns-credentials"`
+	RequireStatic        bool              `yaml:"require-static"`
+	StaticDevices        []string          `yaml:"static-devices"` // serial numbers or MAC addresses
+}
+
+// AllowedFirmware approves the firmware of the model. Empty Model, HWVer or
+// SWVer match any value, MinRelDate is in YYYY-MM-DD format.
+type AllowedFirmware struct {
+	Model      string   `yaml:"model"`
+	HWVer      []string `yaml:"hw-ver"`
+	SWVer      []string `yaml:"sw-ver"`
+	MinRelDate string   `yaml:"min-rel-date"`
+}
+
+// LoadPolicy reads the policy from the JSON file, or from the YAML file with
+// the .yaml or .yml extension, which has the keys in the style of the
+// configuration file, like allow-ddns-credentials.
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &Policy{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, policy); err != nil {
+			return nil, fmt.Errorf("invalid policy file %s: %s", path, err)
+		}
+	default:
+		if err := json.Unmarshal(data, policy); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, allowed := range policy.Allowed {
+		if allowed.MinRelDate == "" {
+			continue
+		}
+
+		if _, err := time.Parse(policyDateLayout, allowed.MinRelDate); err != nil {
+			return nil, fmt.Errorf("invalid minimum release date of model %s: %s", allowed.Model, err)
+		}
+	}
+
+	return policy, nil
+}
+
+func Audit(device inventory.Device, policy *Policy) health.Result {
+	result := health.Result{
+		Key:     device.Key(),
+		Address: device.Address,
+	}
+
+	checkFirmware(&result, device, policy)
+	checkNetwork(&result, device, policy)
+
+	return result
+}
+
+func checkFirmware(result *health.Result, device inventory.Device, policy *Policy) {
+	info := device.DeviceInfo
+	if info == nil {
+		result.Add("firmware", health.Warn, "no device info in the inventory")
+		return
+	}
+
+	modelKnown := false
+	for _, allowed := range policy.Allowed {
+		if !matches(allowed.Model, info.Model) || !matchesAny(allowed.HWVer, info.HWVer) {
+			continue
+		}
+		modelKnown = true
+
+		if matchesAny(allowed.SWVer, info.SWVer) && releasedAfter(info.RelDateTime, allowed.MinRelDate) {
+			result.Add("firmware", health.Pass, "model %s firmware %s approved", info.Model, info.SWVer)
+			return
+		}
+	}
+
+	if !modelKnown {
+		model := info.Model
+		if info.HWVer != "" {
+			model += " HW " + info.HWVer
+		}
+
+		result.Add("firmware", health.Fail, "model %s not approved", model)
+		return
+	}
+
+	result.Add("firmware", health.Fail, "firmware %s released %s not approved for model %s",
+		info.SWVer, info.RelDateTime, info.Model)
+}
+
+func checkNetwork(result *health.Result, device inventory.Device, policy *Policy) {
+	network := device.Network
+	if network == nil {
+		result.Add("network", health.Warn, "no network configuration in the inventory")
+		return
+	}
+
+	if network.DDNS != 0 && !policy.AllowDDNSCredentials && (network.DDNSUser != "" || network.DDNSPassword != "") {
+		result.Add("ddns", health.Warn, "DDNS enabled with credentials set for %s", network.DDNSURL)
+	}
+
+	if network.DHCP != 0 && shouldBeStatic(device, policy) {
+		result.Add("dhcp", health.Warn, "DHCP enabled on device which should have static address")
+	}
+}
+
+func shouldBeStatic(device inventory.Device, policy *Policy) bool {
+	if policy.RequireStatic {
+		return true
+	}
+
+	for _, id := range policy.StaticDevices {
+		if device.Matches(id) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func releasedAfter(relDateTime, minRelDate string) bool {
+	if minRelDate == "" {
+		return true
+	}
+
+	minDate, err := time.Parse(policyDateLayout, minRelDate)
+	if err != nil {
+		return false
+	}
+
+	relDate, err := time.Parse(releaseDateLayout, relDateTime)
+	if err != nil {
+		return false
+	}
+
+	return !relDate.Before(minDate)
+}
+
+func matches(pattern, value string) bool {
+	return pattern == "" || strings.EqualFold(pattern, value)
+}
+
+func matchesAny(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	for _, pattern := range patterns {
+		if matches(pattern, value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,166 @@
+package audit
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	dc "github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/crabtree/defeway-toolbox/pkg/health"
+	"github.com/crabtree/defeway-toolbox/pkg/inventory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAudit(t *testing.T) {
+	policy := &Policy{
+		Allowed: []AllowedFirmware{
+			{Model: "CS-580", SWVer: []string{"2.5.2.10"}, MinRelDate: "2016-01-01"},
+		},
+		StaticDevices: []string{"AA02"},
+	}
+
+	t.Run("should pass approved firmware", func(t *testing.T) {
+		result := Audit(newTestDevice("CS-580", "2.5.2.10", "2016/08/26 16:45"), policy)
+
+		require.Equal(t, health.Pass, result.Status())
+	})
+
+	t.Run("should fail not approved model", func(t *testing.T) {
+		result := Audit(newTestDevice("CS-990", "2.5.2.10", "2016/08/26 16:45"), policy)
+
+		require.Equal(t, health.Fail, result.Status())
+		require.Equal(t, "model CS-990 HW 2.1.0 not approved", result.Findings[0].Message)
+	})
+
+	t.Run("should fail not approved firmware version", func(t *testing.T) {
+		result := Audit(newTestDevice("CS-580", "2.4.0.1", "2016/08/26 16:45"), policy)
+
+		require.Equal(t, health.Fail, result.Status())
+	})
+
+	t.Run("should fail firmware released before minimum date", func(t *testing.T) {
+		result := Audit(newTestDevice("CS-580", "2.5.2.10", "2015/12/31 23:59"), policy)
+
+		require.Equal(t, health.Fail, result.Status())
+	})
+
+	t.Run("should warn about DDNS with credentials", func(t *testing.T) {
+		device := newTestDevice("CS-580", "2.5.2.10", "2016/08/26 16:45")
+		device.Network.DDNS = 1
+		device.Network.DDNSUser = "user"
+
+		result := Audit(device, policy)
+
+		require.Equal(t, health.Warn, result.Status())
+		require.Equal(t, "ddns", result.Findings[1].Check)
+	})
+
+	t.Run("should allow DDNS with credentials when policy allows it", func(t *testing.T) {
+		device := newTestDevice("CS-580", "2.5.2.10", "2016/08/26 16:45")
+		device.Network.DDNS = 1
+		device.Network.DDNSUser = "user"
+
+		result := Audit(device, &Policy{Allowed: policy.Allowed, AllowDDNSCredentials: true})
+
+		require.Equal(t, health.Pass, result.Status())
+	})
+
+	t.Run("should warn about DHCP on device which should be static", func(t *testing.T) {
+		device := newTestDevice("CS-580", "2.5.2.10", "2016/08/26 16:45")
+		device.DeviceInfo.SerialNumber = "AA02"
+		device.Network.DHCP = 1
+
+		result := Audit(device, policy)
+
+		require.Equal(t, health.Warn, result.Status())
+		require.Equal(t, "dhcp", result.Findings[1].Check)
+	})
+
+	t.Run("should allow DHCP on other devices", func(t *testing.T) {
+		device := newTestDevice("CS-580", "2.5.2.10", "2016/08/26 16:45")
+		device.Network.DHCP = 1
+
+		result := Audit(device, policy)
+
+		require.Equal(t, health.Pass, result.Status())
+	})
+}
+
+func TestLoadPolicy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	t.Run("should load policy from JSON file", func(t *testing.T) {
+		fp := path.Join(dir, "policy.json")
+		data := `{"Allowed": [{"Model": "CS-580", "SWVer": ["2.5.2.10"], "MinRelDate": "2016-01-01"}], "RequireStatic": true}`
+		require.NoError(t, ioutil.WriteFile(fp, []byte(data), 0644))
+
+		policy, err := LoadPolicy(fp)
+
+		require.NoError(t, err)
+		require.Len(t, policy.Allowed, 1)
+		require.True(t, policy.RequireStatic)
+	})
+
+	t.Run("should load policy from YAML file", func(t *testing.T) {
+		fp := path.Join(dir, "policy.yaml")
+		data := `
+allowed:
+  - model: CS-580
+    hw-ver: [2.1.0]
+    sw-ver: ["2.5.2.10"]
+    min-rel-date: "2016-01-01"
+allow-ddns-credentials: true
+require-static: true
+static-devices: [AA000000000000]
+`
+		require.NoError(t, ioutil.WriteFile(fp, []byte(data), 0644))
+
+		policy, err := LoadPolicy(fp)
+
+		require.NoError(t, err)
+		require.Equal(t, &Policy{
+			Allowed: []AllowedFirmware{
+				{Model: "CS-580", HWVer: []string{"2.1.0"}, SWVer: []string{"2.5.2.10"}, MinRelDate: "2016-01-01"},
+			},
+			AllowDDNSCredentials: true,
+			RequireStatic:        true,
+			StaticDevices:        []string{"AA000000000000"},
+		}, policy)
+	})
+
+	t.Run("should return error for invalid YAML file", func(t *testing.T) {
+		fp := path.Join(dir, "invalid.yml")
+		require.NoError(t, ioutil.WriteFile(fp, []byte("allowed: {model"), 0644))
+
+		_, err := LoadPolicy(fp)
+
+		require.Error(t, err)
+	})
+
+	t.Run("should return error for invalid minimum release date", func(t *testing.T) {
+		fp := path.Join(dir, "invalid.json")
+		data := `{"Allowed": [{"Model": "CS-580", "MinRelDate": "2016/01/01"}]}`
+		require.NoError(t, ioutil.WriteFile(fp, []byte(data), 0644))
+
+		_, err := LoadPolicy(fp)
+
+		require.Error(t, err)
+	})
+}
+
+func newTestDevice(model, swVer, relDateTime string) inventory.Device {
+	return inventory.Device{
+		Address: "192.168.1.1:80",
+		DeviceInfo: &dc.DefewayDeviceInfo{
+			SerialNumber: "AA01",
+			Model:        model,
+			HWVer:        "2.1.0",
+			SWVer:        swVer,
+			RelDateTime:  relDateTime,
+		},
+		Network: &dc.DefewayNetwork{},
+	}
+}
@@ -0,0 +1,396 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/crabtree/defeway-toolbox/pkg/secret"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	EnvPrefix = "DEFEWAY_"
+	FileName  = "config.yaml"
+)
+
+// Config holds the global defaults and the named device profiles. The
+// values of the profile take precedence over the defaults.
+type Config struct {
+	Defaults Profile            `yaml:"defaults"`
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+// Profile describes the DVR and the way the commands work with it. The
+// fields correspond to the command line flags of the commands.
+type Profile struct {
+	Address          string         `yaml:"address,omitempty"`
+	Port             uint           `yaml:"port,omitempty"`
+	Device           string         `yaml:"device,omitempty"`
+	Inventory        string         `yaml:"inventory,omitempty"`
+	Creds            string         `yaml:"creds,omitempty"`
+	Username         string         `yaml:"username,omitempty"`
+	Password         string         `yaml:"password,omitempty"`
+	Timeout          time.Duration  `yaml:"timeout,omitempty"`
+	TLSSkipVerify    *bool          `yaml:"tls-skip-verify,omitempty"`
+	Timezone         string         `yaml:"timezone,omitempty"`
+	Channels         []int          `yaml:"channels,omitempty"`
+	ChannelNames     map[int]string `yaml:"channel-names,omitempty"`
+	Output           string         `yaml:"output,omitempty"`
+	Layout           string         `yaml:"layout,omitempty"`
+	Site             string         `yaml:"site,omitempty"`
+	S3Endpoint       string         `yaml:"s3-endpoint,omitempty"`
+	S3Region         string         `yaml:"s3-region,omitempty"`
+	Process          []string       `yaml:"process,omitempty"`
+	MoveTo           string         `yaml:"move-to,omitempty"`
+	FFmpeg           string         `yaml:"ffmpeg,omitempty"`
+	MaxRate          string         `yaml:"max-rate,omitempty"`
+	MaxRatePerDevice string         `yaml:"max-rate-per-device,omitempty"`
+	MinConcurrent    int            `yaml:"min-concurrent,omitempty"`
+	MaxConcurrent    int            `yaml:"max-concurrent,omitempty"`
+	Hooks            []string       `yaml:"hooks,omitempty"`
+	Webhooks         []string       `yaml:"webhooks,omitempty"`
+	WebhookSecret    string         `yaml:"webhook-secret,omitempty"`
+	HookTimeout      time.Duration  `yaml:"hook-timeout,omitempty"`
+	HookRetries      *int           `yaml:"hook-retries,omitempty"`
+	QuietHours       []string       `yaml:"quiet-hours,omitempty"`
+	SMTPAddr         string         `yaml:"smtp-addr,omitempty"`
+	SMTPFrom         string         `yaml:"smtp-from,omitempty"`
+	SMTPTo           []string       `yaml:"smtp-to,omitempty"`
+	SMTPUsername     string         `yaml:"smtp-username,omitempty"`
+	SMTPPassword     string         `yaml:"smtp-password,omitempty"`
+	MQTTBroker       string         `yaml:"mqtt-broker,omitempty"`
+	MQTTTopic        string         `yaml:"mqtt-topic,omitempty"`
+	MQTTUsername     string         `yaml:"mqtt-username,omitempty"`
+	MQTTPassword     string         `yaml:"mqtt-password,omitempty"`
+}
+
+func Load(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %s", path, err)
+	}
+
+	for name, profile := range cfg.Profiles {
+		if err := profile.validate(); err != nil {
+			return nil, fmt.Errorf("invalid profile %s: %s", name, err)
+		}
+	}
+
+	if err := cfg.Defaults.validate(); err != nil {
+		return nil, fmt.Errorf("invalid defaults: %s", err)
+	}
+
+	return cfg, nil
+}
+
+// DefaultPath returns the path of the configuration file in the user
+// configuration directory, like ~/.config/defeway/config.yaml. It returns
+// empty string when the file does not exist.
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	path := filepath.Join(dir, "defeway", FileName)
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+
+	return path
+}
+
+// Profile returns the named profile merged with the defaults. The empty name
+// returns the defaults. The site of the profile defaults to its name.
+func (c *Config) Profile(name string) (Profile, error) {
+	if name == "" {
+		return c.Defaults, nil
+	}
+
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("profile %s not found", name)
+	}
+
+	if profile.Site == "" {
+		profile.Site = name
+	}
+
+	return c.Defaults.merge(profile), nil
+}
+
+func (p Profile) validate() error {
+	if p.Timezone != "" {
+		if _, err := time.LoadLocation(p.Timezone); err != nil {
+			return err
+		}
+	}
+
+	for _, ch := range p.Channels {
+		if ch < 1 || ch > 16 {
+			return fmt.Errorf("the channel id %d is out of range 1-16", ch)
+		}
+	}
+
+	for ch, name := range p.ChannelNames {
+		if ch < 1 || ch > 16 {
+			return fmt.Errorf("the channel id %d is out of range 1-16", ch)
+		}
+
+		if strings.ContainsAny(name, ",=") {
+			return fmt.Errorf("the name of the channel %d must not contain , nor =", ch)
+		}
+	}
+
+	return nil
+}
+
+// merge returns the profile with the fields of the other profile set over
+// the fields of this one.
+func (p Profile) merge(other Profile) Profile {
+	if other.Address != "" {
+		p.Address = other.Address
+	}
+	if other.Port != 0 {
+		p.Port = other.Port
+	}
+	if other.Device != "" {
+		p.Device = other.Device
+	}
+	if other.Inventory != "" {
+		p.Inventory = other.Inventory
+	}
+	if other.Creds != "" {
+		p.Creds = other.Creds
+	}
+	if other.Username != "" {
+		p.Username = other.Username
+	}
+	if other.Password != "" {
+		p.Password = other.Password
+	}
+	if other.Timeout != 0 {
+		p.Timeout = other.Timeout
+	}
+	if other.TLSSkipVerify != nil {
+		p.TLSSkipVerify = other.TLSSkipVerify
+	}
+	if other.Timezone != "" {
+		p.Timezone = other.Timezone
+	}
+	if len(other.Channels) > 0 {
+		p.Channels = other.Channels
+	}
+	if len(other.ChannelNames) > 0 {
+		p.ChannelNames = other.ChannelNames
+	}
+	if other.Output != "" {
+		p.Output = other.Output
+	}
+	if other.Layout != "" {
+		p.Layout = other.Layout
+	}
+	if other.Site != "" {
+		p.Site = other.Site
+	}
+	if other.S3Endpoint != "" {
+		p.S3Endpoint = other.S3Endpoint
+	}
+	if other.S3Region != "" {
+		p.S3Region = other.S3Region
+	}
+	if len(other.Process) > 0 {
+		p.Process = other.Process
+	}
+	if other.MoveTo != "" {
+		p.MoveTo = other.MoveTo
+	}
+	if other.FFmpeg != "" {
+		p.FFmpeg = other.FFmpeg
+	}
+	if other.MaxRate != "" {
+		p.MaxRate = other.MaxRate
+	}
+	if other.MaxRatePerDevice != "" {
+		p.MaxRatePerDevice = other.MaxRatePerDevice
+	}
+	if other.MinConcurrent != 0 {
+		p.MinConcurrent = other.MinConcurrent
+	}
+	if other.MaxConcurrent != 0 {
+		p.MaxConcurrent = other.MaxConcurrent
+	}
+	if len(other.Hooks) > 0 {
+		p.Hooks = other.Hooks
+	}
+	if len(other.Webhooks) > 0 {
+		p.Webhooks = other.Webhooks
+	}
+	if other.WebhookSecret != "" {
+		p.WebhookSecret = other.WebhookSecret
+	}
+	if other.HookTimeout != 0 {
+		p.HookTimeout = other.HookTimeout
+	}
+	if other.HookRetries != nil {
+		p.HookRetries = other.HookRetries
+	}
+	if len(other.QuietHours) > 0 {
+		p.QuietHours = other.QuietHours
+	}
+	if other.SMTPAddr != "" {
+		p.SMTPAddr = other.SMTPAddr
+	}
+	if other.SMTPFrom != "" {
+		p.SMTPFrom = other.SMTPFrom
+	}
+	if len(other.SMTPTo) > 0 {
+		p.SMTPTo = other.SMTPTo
+	}
+	if other.SMTPUsername != "" {
+		p.SMTPUsername = other.SMTPUsername
+	}
+	if other.SMTPPassword != "" {
+		p.SMTPPassword = other.SMTPPassword
+	}
+	if other.MQTTBroker != "" {
+		p.MQTTBroker = other.MQTTBroker
+	}
+	if other.MQTTTopic != "" {
+		p.MQTTTopic = other.MQTTTopic
+	}
+	if other.MQTTUsername != "" {
+		p.MQTTUsername = other.MQTTUsername
+	}
+	if other.MQTTPassword != "" {
+		p.MQTTPassword = other.MQTTPassword
+	}
+
+	return p
+}
+
+// FlagValues returns the values of the profile keyed by the names of the
+// command line flags. The unset fields are omitted.
+func (p Profile) FlagValues() map[string]string {
+	values := make(map[string]string)
+	set := func(name, value string) {
+		if value != "" {
+			values[name] = value
+		}
+	}
+
+	set("addr", p.Address)
+	set("device", p.Device)
+	set("inventory", p.Inventory)
+	set("creds", p.Creds)
+	set("username", p.Username)
+	set("password", p.Password)
+	set("timezone", p.Timezone)
+	set("output", p.Output)
+	set("layout", p.Layout)
+	set("site", p.Site)
+	set("s3-endpoint", p.S3Endpoint)
+	set("s3-region", p.S3Region)
+	set("process", strings.Join(p.Process, ","))
+	set("move-to", p.MoveTo)
+	set("ffmpeg", p.FFmpeg)
+	set("max-rate", p.MaxRate)
+	set("max-rate-per-device", p.MaxRatePerDevice)
+	// the hooks are separated with newlines, as the commands may have
+	// commas
+	set("hook", strings.Join(p.Hooks, "\n"))
+	set("webhook", strings.Join(p.Webhooks, "\n"))
+	set("webhook-secret", p.WebhookSecret)
+	set("quiet-hours", strings.Join(p.QuietHours, ","))
+	set("smtp-addr", p.SMTPAddr)
+	set("smtp-from", p.SMTPFrom)
+	set("smtp-to", strings.Join(p.SMTPTo, ","))
+	set("smtp-username", p.SMTPUsername)
+	set("smtp-password", p.SMTPPassword)
+	set("mqtt-broker", p.MQTTBroker)
+	set("mqtt-topic", p.MQTTTopic)
+	set("mqtt-username", p.MQTTUsername)
+	set("mqtt-password", p.MQTTPassword)
+
+	if p.Port != 0 {
+		set("port", strconv.FormatUint(uint64(p.Port), 10))
+	}
+	if p.Timeout != 0 {
+		set("timeout", p.Timeout.String())
+	}
+	if p.TLSSkipVerify != nil {
+		set("tls-skip-verify", strconv.FormatBool(*p.TLSSkipVerify))
+	}
+	if p.HookTimeout != 0 {
+		set("hook-timeout", p.HookTimeout.String())
+	}
+	if p.MinConcurrent != 0 {
+		set("min-concurrent", strconv.Itoa(p.MinConcurrent))
+	}
+	if p.MaxConcurrent != 0 {
+		set("max-concurrent", strconv.Itoa(p.MaxConcurrent))
+	}
+	if p.HookRetries != nil {
+		set("hook-retries", strconv.Itoa(*p.HookRetries))
+	}
+
+	channels := make([]string, 0, len(p.Channels))
+	for _, ch := range p.Channels {
+		channels = append(channels, strconv.Itoa(ch))
+	}
+	set("chan", strings.Join(channels, ","))
+
+	names := make([]string, 0, len(p.ChannelNames))
+	for ch, name := range p.ChannelNames {
+		names = append(names, fmt.Sprintf("%d=%s", ch, name))
+	}
+	sort.Strings(names)
+	set("channel-name", strings.Join(names, ","))
+
+	return values
+}
+
+// EnvName returns the name of the environment variable overriding the flag,
+// like DEFEWAY_TLS_SKIP_VERIFY for -tls-skip-verify.
+func EnvName(flagName string) string {
+	return EnvPrefix + strings.ToUpper(strings.Replace(flagName, "-", "_", -1))
+}
+
+// IsSecret reports whether the value of the flag must not be printed.
+func IsSecret(flagName string) bool {
+	name := strings.ToLower(flagName)
+
+	return strings.Contains(name, "password") || strings.Contains(name, "passphrase") ||
+		strings.Contains(name, "secret") || strings.Contains(name, "token")
+}
+
+// Redact masks the non empty secret value.
+func Redact(flagName, value string) string {
+	if value != "" && IsSecret(flagName) {
+		return secret.Mask
+	}
+
+	return value
+}
+
+// SortedNames returns the keys of the values in alphabetical order.
+func SortedNames(values map[string]string) []string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
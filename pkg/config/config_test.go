@@ -0,0 +1,140 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `
+defaults:
+  creds: /etc/defeway/credentials.json
+  username: admin
+  timeout: 10s
+  output: /archive
+  s3-endpoint: http://minio.local:9000
+  smtp-addr: mail.local:587
+  smtp-to: [cctv@example.com, security@example.com]
+profiles:
+  site-a:
+    address: 192.168.1.10
+    port: 60002
+    password: secret
+    tls-skip-verify: true
+    timezone: Europe/Warsaw
+    channels: [1, 3]
+    channel-names:
+      1: Main gate
+      3: Yard
+    layout: "{site}/{channel-name}/{start:2006/01/02}/{start:150405}-{type}.flv"
+  site-b:
+    device: AA000000000001
+    inventory: /var/lib/defeway/inventory.json
+    output: /spool/site-b
+    process: [validate, checksum, metadata, move]
+    move-to: s3://recordings/site-b
+    max-rate: 2MiB/s
+    max-concurrent: 4
+    hooks:
+      - download-failed=/usr/local/bin/ticket --queue=cctv,dvr
+      - run-finished=logger -t defeway
+    hook-retries: 0
+    quiet-hours: ["07:00-09:00", "16:00-18:00"]
+`
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	t.Run("should load profiles merged with defaults", func(t *testing.T) {
+		fp := path.Join(dir, FileName)
+		require.NoError(t, ioutil.WriteFile(fp, []byte(testConfig), 0600))
+
+		cfg, err := Load(fp)
+		require.NoError(t, err)
+
+		profile, err := cfg.Profile("site-a")
+		require.NoError(t, err)
+		require.Equal(t, "192.168.1.10", profile.Address)
+		require.Equal(t, "admin", profile.Username)
+		require.Equal(t, 10*time.Second, profile.Timeout)
+		require.Equal(t, "/archive", profile.Output)
+		require.Equal(t, []int{1, 3}, profile.Channels)
+		require.Equal(t, "site-a", profile.Site)
+		require.Equal(t, "1=Main gate,3=Yard", profile.FlagValues()["channel-name"])
+
+		profile, err = cfg.Profile("site-b")
+		require.NoError(t, err)
+		require.Equal(t, "/spool/site-b", profile.Output)
+		require.Equal(t, "validate,checksum,metadata,move", profile.FlagValues()["process"])
+		require.Equal(t, "download-failed=/usr/local/bin/ticket --queue=cctv,dvr\nrun-finished=logger -t defeway", profile.FlagValues()["hook"])
+		require.Equal(t, "0", profile.FlagValues()["hook-retries"])
+		require.Equal(t, "2MiB/s", profile.FlagValues()["max-rate"])
+		require.Equal(t, "4", profile.FlagValues()["max-concurrent"])
+		require.Equal(t, "07:00-09:00,16:00-18:00", profile.FlagValues()["quiet-hours"])
+		require.Equal(t, "cctv@example.com,security@example.com", profile.FlagValues()["smtp-to"])
+		require.Equal(t, "http://minio.local:9000", profile.FlagValues()["s3-endpoint"])
+		require.Equal(t, "/etc/defeway/credentials.json", profile.Creds)
+		require.Empty(t, profile.Password)
+	})
+
+	t.Run("should return error for unknown profile", func(t *testing.T) {
+		cfg := &Config{}
+
+		_, err := cfg.Profile("site-c")
+
+		require.Error(t, err)
+	})
+
+	t.Run("should return error for invalid timezone", func(t *testing.T) {
+		fp := path.Join(dir, "invalid.yaml")
+		require.NoError(t, ioutil.WriteFile(fp, []byte("profiles:\n  a:\n    timezone: Mars/Olympus\n"), 0600))
+
+		_, err := Load(fp)
+
+		require.Error(t, err)
+	})
+}
+
+func TestProfile_FlagValues(t *testing.T) {
+	t.Run("should return values of the set fields", func(t *testing.T) {
+		skip := false
+		profile := Profile{
+			Address:       "192.168.1.10",
+			Port:          60001,
+			Password:      "secret",
+			Timeout:       10 * time.Second,
+			TLSSkipVerify: &skip,
+			Channels:      []int{1, 3},
+		}
+
+		require.Equal(t, map[string]string{
+			"addr":            "192.168.1.10",
+			"chan":            "1,3",
+			"password":        "secret",
+			"port":            "60001",
+			"timeout":         "10s",
+			"tls-skip-verify": "false",
+		}, profile.FlagValues())
+	})
+}
+
+func TestEnvName(t *testing.T) {
+	t.Run("should build variable name from the flag name", func(t *testing.T) {
+		require.Equal(t, "DEFEWAY_TLS_SKIP_VERIFY", EnvName("tls-skip-verify"))
+		require.Equal(t, "DEFEWAY_PASSWORD", EnvName("password"))
+	})
+}
+
+func TestRedact(t *testing.T) {
+	t.Run("should mask secret values only", func(t *testing.T) {
+		require.Equal(t, "******", Redact("password", "secret"))
+		require.Equal(t, "", Redact("password", ""))
+		require.Equal(t, "admin", Redact("username", "admin"))
+	})
+}
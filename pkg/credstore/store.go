@@ -0,0 +1,227 @@
+package credstore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	formatVersion = 1
+	kdfScrypt     = "scrypt"
+	keyLen        = 32
+	saltLen       = 16
+)
+
+// ErrInvalidPassphrase is returned when the store cannot be decrypted.
+var ErrInvalidPassphrase = errors.New("invalid passphrase or corrupted credentials store")
+
+// KDFParams are the scrypt cost parameters stored with the encrypted data.
+type KDFParams struct {
+	N int
+	R int
+	P int
+}
+
+// DefaultKDFParams take about 100 ms and 32 MB of memory to derive the key.
+var DefaultKDFParams = KDFParams{N: 1 << 15, R: 8, P: 1}
+
+type Credential struct {
+	Username string
+	Password string
+}
+
+// Store keeps the credentials of the DVRs keyed by their serial numbers, MAC
+// addresses or IP addresses. The store file is encrypted with AES-256-GCM with
+// the key derived from the passphrase with scrypt.
+type Store struct {
+	credentials map[string]Credential
+}
+
+// envelope is the format of the store file.
+type envelope struct {
+	Version    int
+	KDF        string
+	KDFParams  KDFParams
+	Salt       []byte
+	Nonce      []byte
+	Ciphertext []byte
+}
+
+func New() *Store {
+	return &Store{credentials: make(map[string]Credential)}
+}
+
+// Open decrypts the store file. It returns the empty store when the file does
+// not exist yet.
+func Open(path, passphrase string) (*Store, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return New(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	env := envelope{}
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("invalid credentials store %s: %s", path, err)
+	}
+
+	if env.Version != formatVersion || env.KDF != kdfScrypt {
+		return nil, fmt.Errorf("unsupported credentials store %s version %d with %s", path, env.Version, env.KDF)
+	}
+
+	aead, err := newAEAD(passphrase, env.Salt, env.KDFParams)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(env.Nonce) != aead.NonceSize() {
+		return nil, ErrInvalidPassphrase
+	}
+
+	plaintext, err := aead.Open(nil, env.Nonce, env.Ciphertext, nil)
+	if err != nil {
+		return nil, ErrInvalidPassphrase
+	}
+
+	s := New()
+	if err := json.Unmarshal(plaintext, &s.credentials); err != nil {
+		return nil, fmt.Errorf("invalid credentials store %s: %s", path, err)
+	}
+
+	return s, nil
+}
+
+// Save encrypts the store with the fresh salt and nonce, and replaces the
+// store file, which is readable only by its owner.
+func (s *Store) Save(path, passphrase string) error {
+	if passphrase == "" {
+		return fmt.Errorf("specify non-empty passphrase")
+	}
+
+	plaintext, err := json.Marshal(s.credentials)
+	if err != nil {
+		return err
+	}
+
+	env := envelope{
+		Version:   formatVersion,
+		KDF:       kdfScrypt,
+		KDFParams: DefaultKDFParams,
+		Salt:      make([]byte, saltLen),
+	}
+	if _, err := rand.Read(env.Salt); err != nil {
+		return err
+	}
+
+	aead, err := newAEAD(passphrase, env.Salt, env.KDFParams)
+	if err != nil {
+		return err
+	}
+
+	env.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(env.Nonce); err != nil {
+		return err
+	}
+	env.Ciphertext = aead.Seal(nil, env.Nonce, plaintext, nil)
+
+	data, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func newAEAD(passphrase string, salt []byte, params KDFParams) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, params.N, params.R, params.P, keyLen)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// normalizeKey makes the keys case insensitive, like the MAC addresses.
+func normalizeKey(key string) string {
+	return strings.ToUpper(strings.TrimSpace(key))
+}
+
+func (s *Store) Set(key string, cred Credential) {
+	s.credentials[normalizeKey(key)] = cred
+}
+
+func (s *Store) Get(key string) (Credential, bool) {
+	cred, ok := s.credentials[normalizeKey(key)]
+
+	return cred, ok
+}
+
+// Delete removes the credentials and reports whether they were present.
+func (s *Store) Delete(key string) bool {
+	key = normalizeKey(key)
+	_, ok := s.credentials[key]
+	delete(s.credentials, key)
+
+	return ok
+}
+
+// Keys returns the keys of the store in alphabetical order.
+func (s *Store) Keys() []string {
+	keys := make([]string, 0, len(s.credentials))
+	for key := range s.credentials {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Lookup returns the credentials of the first key found in the store, like
+// the serial number of the device followed by its address. The empty keys
+// are skipped.
+func (s *Store) Lookup(keys ...string) (string, string, bool) {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+
+		if cred, ok := s.Get(key); ok {
+			return cred.Username, cred.Password, true
+		}
+	}
+
+	return "", "", false
+}
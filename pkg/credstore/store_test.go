@@ -0,0 +1,80 @@
+package credstore
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credstore")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// the cheap key derivation keeps the tests fast
+	defaultParams := DefaultKDFParams
+	DefaultKDFParams = KDFParams{N: 16, R: 1, P: 1}
+	defer func() { DefaultKDFParams = defaultParams }()
+
+	fp := path.Join(dir, "credentials.json")
+
+	t.Run("should open empty store when the file does not exist", func(t *testing.T) {
+		s, err := Open(fp, "passphrase")
+
+		require.NoError(t, err)
+		require.Empty(t, s.Keys())
+	})
+
+	t.Run("should save and open encrypted store", func(t *testing.T) {
+		s := New()
+		s.Set("AA000000000001", Credential{Username: "admin", Password: "s3cret"})
+		s.Set("192.168.1.10:60001", Credential{Username: "viewer", Password: "other"})
+		require.NoError(t, s.Save(fp, "passphrase"))
+
+		data, err := ioutil.ReadFile(fp)
+		require.NoError(t, err)
+		require.NotContains(t, string(data), "s3cret")
+
+		info, err := os.Stat(fp)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		s, err = Open(fp, "passphrase")
+		require.NoError(t, err)
+		require.Equal(t, []string{"192.168.1.10:60001", "AA000000000001"}, s.Keys())
+
+		cred, ok := s.Get("aa000000000001")
+		require.True(t, ok)
+		require.Equal(t, Credential{Username: "admin", Password: "s3cret"}, cred)
+	})
+
+	t.Run("should return error for invalid passphrase", func(t *testing.T) {
+		_, err := Open(fp, "wrong")
+
+		require.Equal(t, ErrInvalidPassphrase, err)
+	})
+
+	t.Run("should look up credentials by the first known key", func(t *testing.T) {
+		s := New()
+		s.Set("192.168.1.10:60001", Credential{Username: "viewer", Password: "other"})
+
+		username, password, ok := s.Lookup("", "AA000000000001", "192.168.1.10:60001")
+		require.True(t, ok)
+		require.Equal(t, "viewer", username)
+		require.Equal(t, "other", password)
+
+		_, _, ok = s.Lookup("AA000000000002")
+		require.False(t, ok)
+	})
+
+	t.Run("should delete credentials", func(t *testing.T) {
+		s := New()
+		s.Set("AA000000000001", Credential{Username: "admin"})
+
+		require.True(t, s.Delete("AA000000000001"))
+		require.False(t, s.Delete("AA000000000001"))
+	})
+}
@@ -0,0 +1,216 @@
+package defewayclient
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// bandwidthChunk is the largest read accounted at once, the readers sharing
+// the bandwidth take turns by the chunks.
+const bandwidthChunk = 32 << 10
+
+type BandwidthConfig struct {
+	// Rate is the maximum number of bytes per second read by all downloads,
+	// 0 does not limit.
+	Rate int64
+	// PerHostRate is the maximum number of bytes per second read from one
+	// host, 0 does not limit.
+	PerHostRate int64
+}
+
+// Bandwidth caps the rate of the downloads, globally and per host. The
+// downloads sharing the cap are served in the order of their reads, so they
+// get the fair share of it. The rates can be changed while the downloads
+// run. A nil Bandwidth does not limit.
+type Bandwidth struct {
+	mu     sync.Mutex
+	config BandwidthConfig
+	next   time.Time
+	hosts  map[string]time.Time
+	// changed is closed when the rates change, which releases the waiting
+	// reads
+	changed chan struct{}
+}
+
+func NewBandwidth(config BandwidthConfig) *Bandwidth {
+	return &Bandwidth{
+		config:  config,
+		hosts:   make(map[string]time.Time),
+		changed: make(chan struct{}),
+	}
+}
+
+// Config returns the current rates.
+func (b *Bandwidth) Config() BandwidthConfig {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.config
+}
+
+// SetConfig changes the rates, the reads waiting for the previous rates are
+// released at once.
+func (b *Bandwidth) SetConfig(config BandwidthConfig) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.config = config
+	b.next = time.Time{}
+	b.hosts = make(map[string]time.Time)
+
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// Reader returns the reader of the download from the address, which reads
+// no faster than the rates allow.
+func (b *Bandwidth) Reader(addr string, r io.Reader) io.Reader {
+	if b == nil {
+		return r
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+
+	return &bandwidthReader{
+		bandwidth: b,
+		host:      host,
+		reader:    r,
+	}
+}
+
+// wait blocks until the n bytes read from the host fit the rates.
+// The wait ends early when the rates change.
+func (b *Bandwidth) wait(host string, n int) {
+	delay, changed := b.reserve(host, n)
+	if delay <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-changed:
+	}
+}
+
+func (b *Bandwidth) reserve(host string, n int) (time.Duration, <-chan struct{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	delay := reserveAt(&b.next, now, n, b.config.Rate)
+
+	next := b.hosts[host]
+	if hostDelay := reserveAt(&next, now, n, b.config.PerHostRate); hostDelay > delay {
+		delay = hostDelay
+	}
+	b.hosts[host] = next
+
+	return delay, b.changed
+}
+
+// reserveAt moves the time, when the next read is allowed, by the time of
+// transferring the n bytes with the rate and returns the delay of the read.
+// The time is not kept in the past, so the idle time does not allow bursts.
+func reserveAt(next *time.Time, now time.Time, n int, rate int64) time.Duration {
+	if rate <= 0 {
+		return 0
+	}
+
+	if next.Before(now) {
+		*next = now
+	}
+	*next = next.Add(time.Duration(int64(n) * int64(time.Second) / rate))
+
+	return next.Sub(now)
+}
+
+type bandwidthReader struct {
+	bandwidth *Bandwidth
+	host      string
+	reader    io.Reader
+}
+
+func (r *bandwidthReader) Read(p []byte) (int, error) {
+	if len(p) > bandwidthChunk {
+		p = p[:bandwidthChunk]
+	}
+
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.bandwidth.wait(r.host, n)
+	}
+
+	return n, err
+}
+
+var rateUnits = []struct {
+	suffix string
+	bytes  float64
+}{
+	{"GiB", 1 << 30},
+	{"MiB", 1 << 20},
+	{"KiB", 1 << 10},
+	{"Gbit", 1e9 / 8},
+	{"Mbit", 1e6 / 8},
+	{"Kbit", 1e3 / 8},
+	{"kbit", 1e3 / 8},
+	{"GB", 1e9},
+	{"MB", 1e6},
+	{"KB", 1e3},
+	{"kB", 1e3},
+	{"B", 1},
+}
+
+// ParseRate parses the rate in bytes per second, like 2MiB/s, 500KB/s,
+// 8Mbit/s or 1048576. The /s suffix is optional and 0 or unlimited means
+// unlimited.
+func ParseRate(value string) (int64, error) {
+	s := strings.TrimSuffix(strings.TrimSpace(value), "/s")
+	if s == "unlimited" {
+		return 0, nil
+	}
+
+	multiplier := float64(1)
+	for _, unit := range rateUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			multiplier = unit.bytes
+			break
+		}
+	}
+
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid rate %q, specify the rate like 2MiB/s", value)
+	}
+
+	return int64(v * multiplier), nil
+}
+
+// FormatRate formats the rate in bytes per second with the binary units.
+func FormatRate(rate int64) string {
+	if rate <= 0 {
+		return "unlimited"
+	}
+
+	for _, unit := range rateUnits[:3] {
+		if float64(rate) >= unit.bytes {
+			v := math.Round(float64(rate)/unit.bytes*100) / 100
+			return strconv.FormatFloat(v, 'f', -1, 64) + unit.suffix + "/s"
+		}
+	}
+
+	return strconv.FormatInt(rate, 10) + "B/s"
+}
@@ -0,0 +1,138 @@
+package defewayclient
+
+import (
+	"bytes"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Bandwidth_Reader(t *testing.T) {
+	t.Run("does not limit when bandwidth is nil", func(t *testing.T) {
+		var b *Bandwidth
+		r := bytes.NewReader([]byte("recording"))
+
+		require.Equal(t, r, b.Reader("127.0.0.1:80", r))
+	})
+
+	t.Run("limits the rate of the reads", func(t *testing.T) {
+		b := NewBandwidth(BandwidthConfig{Rate: 100 << 10})
+
+		start := time.Now()
+		data, err := ioutil.ReadAll(b.Reader("127.0.0.1:80", bytes.NewReader(make([]byte, 20<<10))))
+
+		require.NoError(t, err)
+		require.Len(t, data, 20<<10)
+		require.True(t, time.Since(start) >= 190*time.Millisecond)
+	})
+
+	t.Run("shares the rate between the readers", func(t *testing.T) {
+		b := NewBandwidth(BandwidthConfig{Rate: 1 << 20})
+
+		var wg sync.WaitGroup
+		start := time.Now()
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ioutil.ReadAll(b.Reader("127.0.0.1:80", bytes.NewReader(make([]byte, 64<<10))))
+			}()
+		}
+		wg.Wait()
+
+		require.True(t, time.Since(start) >= 240*time.Millisecond)
+	})
+
+	t.Run("limits the rate of the host", func(t *testing.T) {
+		b := NewBandwidth(BandwidthConfig{PerHostRate: 100 << 10})
+		read := func(addrs ...string) time.Duration {
+			var wg sync.WaitGroup
+			start := time.Now()
+			for _, addr := range addrs {
+				wg.Add(1)
+				go func(addr string) {
+					defer wg.Done()
+					ioutil.ReadAll(b.Reader(addr, bytes.NewReader(make([]byte, 10<<10))))
+				}(addr)
+			}
+			wg.Wait()
+
+			return time.Since(start)
+		}
+
+		require.True(t, read("127.0.0.2:80", "127.0.0.3:80") < 150*time.Millisecond)
+		require.True(t, read("127.0.0.2:80", "127.0.0.2:8080") >= 190*time.Millisecond)
+	})
+
+	t.Run("applies the changed rate", func(t *testing.T) {
+		b := NewBandwidth(BandwidthConfig{Rate: 10 << 10})
+		b.SetConfig(BandwidthConfig{Rate: 0})
+
+		start := time.Now()
+		ioutil.ReadAll(b.Reader("127.0.0.1:80", bytes.NewReader(make([]byte, 100<<10))))
+
+		require.True(t, time.Since(start) < 100*time.Millisecond)
+		require.Equal(t, BandwidthConfig{}, b.Config())
+	})
+
+	t.Run("releases the reads waiting when the rate is raised", func(t *testing.T) {
+		b := NewBandwidth(BandwidthConfig{Rate: 10 << 10})
+
+		done := make(chan time.Duration)
+		go func() {
+			start := time.Now()
+			ioutil.ReadAll(b.Reader("127.0.0.1:80", bytes.NewReader(make([]byte, 64<<10))))
+			done <- time.Since(start)
+		}()
+
+		time.Sleep(50 * time.Millisecond)
+		b.SetConfig(BandwidthConfig{Rate: 0})
+
+		select {
+		case elapsed := <-done:
+			require.True(t, elapsed < time.Second)
+		case <-time.After(2 * time.Second):
+			t.Fatal("read still waits for the previous rate")
+		}
+	})
+}
+
+func Test_ParseRate(t *testing.T) {
+	t.Run("parses the rates with units", func(t *testing.T) {
+		for value, expected := range map[string]int64{
+			"2MiB/s":    2 << 20,
+			"512KiB":    512 << 10,
+			"1.5 MB/s":  1500000,
+			"8Mbit/s":   1000000,
+			"100B/s":    100,
+			"4096":      4096,
+			"0":         0,
+			"unlimited": 0,
+		} {
+			rate, err := ParseRate(value)
+
+			require.NoError(t, err, value)
+			require.Equal(t, expected, rate, value)
+		}
+	})
+
+	t.Run("returns error for invalid rate", func(t *testing.T) {
+		for _, value := range []string{"", "fast", "-1MiB/s", "2XB/s"} {
+			_, err := ParseRate(value)
+
+			require.Error(t, err, value)
+		}
+	})
+}
+
+func Test_FormatRate(t *testing.T) {
+	t.Run("formats the rates with binary units", func(t *testing.T) {
+		require.Equal(t, "unlimited", FormatRate(0))
+		require.Equal(t, "2MiB/s", FormatRate(2<<20))
+		require.Equal(t, "1.5KiB/s", FormatRate(1536))
+		require.Equal(t, "100B/s", FormatRate(100))
+	})
+}
@@ -2,6 +2,7 @@ package defewayclient
 
 import (
 	"crypto/tls"
+	"net"
 	"net/http"
 	"time"
 )
@@ -16,6 +17,14 @@ type HTTPClientConfig struct {
 	Timeout           time.Duration
 	DisableKeepAlives bool
 	TLSSkipVerify     bool
+	Limiter           *Limiter
+	Bandwidth         *Bandwidth
+	// StallTimeout aborts the download which receives no data for the
+	// duration, 0 does not limit.
+	StallTimeout time.Duration
+	// DeadlineRatio limits the time of the download to the multiple of the
+	// duration of the recording, plus a minute, 0 does not limit.
+	DeadlineRatio float64
 }
 
 type DefewayClientConfig struct {
@@ -25,11 +34,43 @@ type DefewayClientConfig struct {
 	Password string
 }
 
+// Credentials looks up the username and password of the DVR by the keys
+// identifying it, like its serial number or address.
+type Credentials interface {
+	Lookup(keys ...string) (username, password string, ok bool)
+}
+
+// WithCredentials returns the config with the username and password of the
+// DVR found in the credentials. The config is returned unchanged when the
+// credentials are nil or do not know the DVR. The address of the config is
+// looked up after the given keys, both with and without the port.
+func (config DefewayClientConfig) WithCredentials(creds Credentials, keys ...string) DefewayClientConfig {
+	if creds == nil {
+		return config
+	}
+
+	keys = append(keys, config.Address)
+	if host, _, err := net.SplitHostPort(config.Address); err == nil {
+		keys = append(keys, host)
+	}
+
+	if username, password, ok := creds.Lookup(keys...); ok {
+		config.Username = username
+		config.Password = password
+	}
+
+	return config
+}
+
 type client struct {
-	Client   *http.Client
-	Address  string
-	Username string
-	Password string
+	Client        *http.Client
+	Address       string
+	Username      string
+	Password      string
+	Limiter       *Limiter
+	Bandwidth     *Bandwidth
+	StallTimeout  time.Duration
+	DeadlineRatio float64
 }
 
 func NewDefewayClient(config DefewayClientConfig) *client {
@@ -43,10 +84,21 @@ func NewDefewayClient(config DefewayClientConfig) *client {
 		Transport: t,
 	}
 
+	if config.Limiter != nil {
+		c.Transport = &limitedTransport{
+			base:    t,
+			limiter: config.Limiter,
+		}
+	}
+
 	return &client{
-		Client:   c,
-		Address:  config.Address,
-		Username: config.Username,
-		Password: config.Password,
+		Client:        c,
+		Address:       config.Address,
+		Username:      config.Username,
+		Password:      config.Password,
+		Limiter:       config.Limiter,
+		Bandwidth:     config.Bandwidth,
+		StallTimeout:  config.StallTimeout,
+		DeadlineRatio: config.DeadlineRatio,
 	}
 }
@@ -0,0 +1,45 @@
+package defewayclient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testCredentials map[string][2]string
+
+func (c testCredentials) Lookup(keys ...string) (string, string, bool) {
+	for _, key := range keys {
+		if cred, ok := c[key]; ok {
+			return cred[0], cred[1], true
+		}
+	}
+
+	return "", "", false
+}
+
+func Test_DefewayClientConfig_WithCredentials(t *testing.T) {
+	config := DefewayClientConfig{Address: "192.168.1.10:60001", Username: "admin", Password: "default"}
+
+	t.Run("should use credentials of the device key", func(t *testing.T) {
+		creds := testCredentials{"AA01": {"viewer", "s3cret"}, "192.168.1.10": {"other", "other"}}
+
+		result := config.WithCredentials(creds, "AA01")
+
+		require.Equal(t, "viewer", result.Username)
+		require.Equal(t, "s3cret", result.Password)
+	})
+
+	t.Run("should use credentials of the host without port", func(t *testing.T) {
+		creds := testCredentials{"192.168.1.10": {"other", "other"}}
+
+		result := config.WithCredentials(creds, "AA01")
+
+		require.Equal(t, "other", result.Username)
+	})
+
+	t.Run("should keep credentials of unknown device", func(t *testing.T) {
+		require.Equal(t, config, config.WithCredentials(testCredentials{}, "AA01"))
+		require.Equal(t, config, config.WithCredentials(nil))
+	})
+}
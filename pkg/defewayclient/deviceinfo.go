@@ -97,5 +97,7 @@ func parseDevInfoResp(resp *http.Response) (*DefewayJuan, bool, error) {
 		return devInfo, true, fmt.Errorf("response with empty device info")
 	}
 
+	devInfo.RedactCredentials()
+
 	return devInfo, false, nil
 }
@@ -0,0 +1,159 @@
+package defewayclient
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type LimiterConfig struct {
+	RequestsPerSecond  float64
+	PerHostConnections int
+	Jitter             time.Duration
+}
+
+// Limiter spreads the requests to the DVRs in time. It limits the global rate
+// of requests, the number of connections open at the same time to one host
+// and delays every request by a random jitter. A nil Limiter does not limit.
+type Limiter struct {
+	interval time.Duration
+	jitter   time.Duration
+	perHost  int
+
+	mu    sync.Mutex
+	next  time.Time
+	rand  *rand.Rand
+	hosts map[string]chan struct{}
+}
+
+func NewLimiter(config LimiterConfig) *Limiter {
+	var interval time.Duration
+	if config.RequestsPerSecond > 0 {
+		interval = time.Duration(float64(time.Second) / config.RequestsPerSecond)
+	}
+
+	return &Limiter{
+		interval: interval,
+		jitter:   config.Jitter,
+		perHost:  config.PerHostConnections,
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		hosts:    make(map[string]chan struct{}),
+	}
+}
+
+// Acquire blocks until a request to the given address is allowed. The
+// returned function releases the per host connection slot and must be called
+// once the connection is not used anymore.
+func (l *Limiter) Acquire(addr string) func() {
+	if l == nil {
+		return func() {}
+	}
+
+	slot := l.hostSlot(addr)
+	if slot != nil {
+		slot <- struct{}{}
+	}
+
+	time.Sleep(l.reserve())
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			if slot != nil {
+				<-slot
+			}
+		})
+	}
+}
+
+func (l *Limiter) hostSlot(addr string) chan struct{} {
+	if l.perHost <= 0 {
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	slot, ok := l.hosts[host]
+	if !ok {
+		slot = make(chan struct{}, l.perHost)
+		l.hosts[host] = slot
+	}
+
+	return slot
+}
+
+func (l *Limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+
+	if l.jitter > 0 {
+		at = at.Add(time.Duration(l.rand.Int63n(int64(l.jitter))))
+	}
+
+	l.next = at.Add(l.interval)
+
+	return at.Sub(now)
+}
+
+// acquiredKey marks the context of the request which already acquired the
+// connection slot, the value is the function releasing it.
+type acquiredKey struct{}
+
+// withAcquired returns the context of the request, for which the transport
+// does not acquire the slot again, like of the download which waits for the
+// slot before its stall timeout starts.
+func withAcquired(ctx context.Context, release func()) context.Context {
+	return context.WithValue(ctx, acquiredKey{}, release)
+}
+
+type limitedTransport struct {
+	base    http.RoundTripper
+	limiter *Limiter
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	release, ok := req.Context().Value(acquiredKey{}).(func())
+	if !ok {
+		release = t.limiter.Acquire(req.URL.Host)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	resp.Body = &limitedBody{
+		ReadCloser: resp.Body,
+		release:    release,
+	}
+
+	return resp, nil
+}
+
+type limitedBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *limitedBody) Close() error {
+	defer b.release()
+
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,97 @@
+package defewayclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Limiter_Acquire(t *testing.T) {
+	t.Run("does not limit when limiter is nil", func(t *testing.T) {
+		var l *Limiter
+
+		release := l.Acquire("127.0.0.1:80")
+		release()
+	})
+
+	t.Run("spreads requests according to the requests per second", func(t *testing.T) {
+		l := NewLimiter(LimiterConfig{RequestsPerSecond: 20})
+
+		start := time.Now()
+		for i := 0; i < 5; i++ {
+			l.Acquire("127.0.0.1:80")()
+		}
+
+		require.True(t, time.Since(start) >= 200*time.Millisecond)
+	})
+
+	t.Run("limits the number of connections to the same host", func(t *testing.T) {
+		l := NewLimiter(LimiterConfig{PerHostConnections: 1})
+
+		release := l.Acquire("127.0.0.1:80")
+
+		acquired := make(chan struct{})
+		go func() {
+			l.Acquire("127.0.0.1:8080")()
+			close(acquired)
+		}()
+
+		select {
+		case <-acquired:
+			t.Fatal("acquired second connection to the same host")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		l.Acquire("127.0.0.2:80")()
+
+		release()
+		release()
+		<-acquired
+	})
+}
+
+func Test_Limiter_Transport(t *testing.T) {
+	t.Run("releases the connection slot when response body is closed", func(t *testing.T) {
+		var mu sync.Mutex
+		var active, maxActive int
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			mu.Lock()
+			active++
+			if active > maxActive {
+				maxActive = active
+			}
+			mu.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			active--
+			mu.Unlock()
+		}))
+		defer server.Close()
+
+		c := NewDefewayClient(DefewayClientConfig{
+			HTTPClientConfig: HTTPClientConfig{
+				Limiter: NewLimiter(LimiterConfig{PerHostConnections: 1}),
+			},
+		})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp, err := c.Client.Get(server.URL)
+				require.NoError(t, err)
+				resp.Body.Close()
+			}()
+		}
+		wg.Wait()
+
+		require.Equal(t, 1, maxActive)
+	})
+}
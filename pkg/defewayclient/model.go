@@ -41,6 +41,36 @@ func (dj *DefewayJuan) HasError() (bool, string) {
 	return false, ""
 }
 
+// RedactedCredential replaces the credentials echoed by the DVR.
+const RedactedCredential = "******"
+
+// RedactCredentials masks the credentials of the request echoed in the
+// response and the DDNS password of the network configuration, so they are
+// not stored in the inventory nor the scan logs. The empty values are kept
+// empty, so it is still known whether they are set.
+func (dj *DefewayJuan) RedactCredentials() {
+	redact := func(value *string) {
+		if *value != "" {
+			*value = RedactedCredential
+		}
+	}
+
+	if dj.RecSearch != nil {
+		redact(&dj.RecSearch.Password)
+	}
+
+	if dj.EnvLoad != nil {
+		redact(&dj.EnvLoad.Password)
+		if dj.EnvLoad.Network != nil {
+			redact(&dj.EnvLoad.Network.DDNSPassword)
+		}
+	}
+
+	if dj.HDD != nil {
+		redact(&dj.HDD.Password)
+	}
+}
+
 func UnmarshalJuan(data []byte) (*DefewayJuan, error) {
 	dj := &DefewayJuan{}
 	err := xml.Unmarshal(data, dj)
@@ -79,6 +109,24 @@ type RecordingMeta struct {
 	EndTimestamp   uint64
 }
 
+// recordingTypeNames are the names of the recording types reported by the
+// DVR, the type is the bit of the types mask of the search.
+var recordingTypeNames = map[uint16]string{
+	1: "schedule",
+	2: "motion",
+	4: "alarm",
+	8: "manual",
+}
+
+// TypeName returns the name of the recording type, like "motion".
+func (s *RecordingMeta) TypeName() string {
+	if name, ok := recordingTypeNames[s.TypeID]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("type-%d", s.TypeID)
+}
+
 func (s *RecordingMeta) GetFileShortName() string {
 	return fmt.Sprintf("%d.flv", s.RecordingID)
 }
@@ -131,6 +179,14 @@ func (s *RecordingMeta) UnmarshalXML(d *xml.Decoder, start xml.StartElement) err
 	return nil
 }
 
+// MarshalXML writes the recording in the format of the search results, so
+// the written list of the recordings can be read back.
+func (s RecordingMeta) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	val := fmt.Sprintf("0|%d|%d|%d|%d|%d", s.RecordingID, s.ChannelID, s.TypeID, s.StartTimestamp, s.EndTimestamp)
+
+	return e.EncodeElement(val, start)
+}
+
 type DefewayDeviceInfo struct {
 	Name             string `xml:"name,attr"`
 	Model            string `xml:"model,attr"`
@@ -203,6 +259,12 @@ type DefewayHDD struct {
 	Disks    []HDDMeta `xml:"d,omitempty"`
 }
 
+const (
+	HDDStatusDBError   = 3
+	HDDStatusFormatted = 4
+	HDDStatusOK        = 5
+)
+
 type HDDMeta struct {
 	Model    string
 	Capacity uint64
@@ -210,6 +272,29 @@ type HDDMeta struct {
 	Status   uint8 // 3 - DB error, 4 - Formatted, 5 - OK, else Unformatted
 }
 
+func (hdd *HDDMeta) IsOK() bool {
+	return hdd.Status == HDDStatusOK
+}
+
+func (hdd *HDDMeta) IsUnformatted() bool {
+	return hdd.Status != HDDStatusDBError &&
+		hdd.Status != HDDStatusFormatted &&
+		hdd.Status != HDDStatusOK
+}
+
+func (hdd *HDDMeta) StatusName() string {
+	switch hdd.Status {
+	case HDDStatusDBError:
+		return "DB error"
+	case HDDStatusFormatted:
+		return "Formatted"
+	case HDDStatusOK:
+		return "OK"
+	default:
+		return "Unformatted"
+	}
+}
+
 func (hdd *HDDMeta) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	var val string
 	if err := d.DecodeElement(&val, &start); err != nil {
@@ -79,6 +79,22 @@ func TestDefewayJuan(t *testing.T) {
 	})
 }
 
+func TestDefewayJuan_RedactCredentials(t *testing.T) {
+	t.Run("mask echoed credentials and DDNS password", func(t *testing.T) {
+		juan := NewForDeviceInfo(
+			DefewayEnvLoad{Username: "admin", Password: "p@ssw0rd", Network: &DefewayNetwork{DDNSUser: "user", DDNSPassword: "s3cret"}},
+			DefewayDeviceInfo{},
+			DefewayHDD{Username: "admin"})
+
+		juan.RedactCredentials()
+
+		require.Equal(t, RedactedCredential, juan.EnvLoad.Password)
+		require.Equal(t, RedactedCredential, juan.EnvLoad.Network.DDNSPassword)
+		require.Equal(t, "user", juan.EnvLoad.Network.DDNSUser)
+		require.Empty(t, juan.HDD.Password)
+	})
+}
+
 func TestUnmarshalJuan(t *testing.T) {
 	t.Run("unmarshal XML from bytes with empty body", func(t *testing.T) {
 		juanMarshaled := `<juan ver="" squ="" dir="0" enc="0" errno="0"></juan>`
@@ -139,6 +155,24 @@ func TestUnmarshalJuan(t *testing.T) {
 	})
 }
 
+func TestRecordingMeta_MarshalXML(t *testing.T) {
+	t.Run("should marshal search results which unmarshal to the same recordings", func(t *testing.T) {
+		recordings := []RecordingMeta{
+			{RecordingID: 1, ChannelID: 3, TypeID: 8, StartTimestamp: 1572887777, EndTimestamp: 1572887780},
+			{RecordingID: 2, ChannelID: 3, TypeID: 8, StartTimestamp: 1572888888, EndTimestamp: 1572888890},
+		}
+		juan := NewForRecSearch(DefewayRecSearch{SearchResults: recordings})
+
+		marshaled, err := juan.Marshal()
+		require.NoError(t, err)
+		require.Contains(t, marshaled, "<s>0|1|3|8|1572887777|1572887780</s><s>0|2|3|8|1572888888|1572888890</s>")
+
+		unmarshaled, err := UnmarshalJuan([]byte(marshaled))
+		require.NoError(t, err)
+		require.Equal(t, recordings, unmarshaled.RecSearch.SearchResults)
+	})
+}
+
 func TestRecordingMeta_GetFileName(t *testing.T) {
 	t.Run("should return file name containing RecordingID, ChannelID and TypeID", func(t *testing.T) {
 		rec := RecordingMeta{
@@ -165,6 +199,39 @@ func TestRecordingMeta_GetFileShortName(t *testing.T) {
 	})
 }
 
+func TestRecordingMeta_TypeName(t *testing.T) {
+	t.Run("should return name of known and unknown type", func(t *testing.T) {
+		require.Equal(t, "motion", (&RecordingMeta{TypeID: 2}).TypeName())
+		require.Equal(t, "type-16", (&RecordingMeta{TypeID: 16}).TypeName())
+	})
+}
+
+func TestHDDMeta_Status(t *testing.T) {
+	t.Run("should recognize OK disk", func(t *testing.T) {
+		hdd := HDDMeta{Status: HDDStatusOK}
+
+		require.True(t, hdd.IsOK())
+		require.False(t, hdd.IsUnformatted())
+		require.Equal(t, "OK", hdd.StatusName())
+	})
+
+	t.Run("should recognize disk with DB error", func(t *testing.T) {
+		hdd := HDDMeta{Status: HDDStatusDBError}
+
+		require.False(t, hdd.IsOK())
+		require.False(t, hdd.IsUnformatted())
+		require.Equal(t, "DB error", hdd.StatusName())
+	})
+
+	t.Run("should recognize unformatted disk", func(t *testing.T) {
+		hdd := HDDMeta{Status: 1}
+
+		require.False(t, hdd.IsOK())
+		require.True(t, hdd.IsUnformatted())
+		require.Equal(t, "Unformatted", hdd.StatusName())
+	})
+}
+
 func validateJuan(t *testing.T, juan *DefewayJuan) {
 	require.Equal(t, "", juan.Version)
 	require.Equal(t, "", juan.SQU)
@@ -0,0 +1,74 @@
+package defewayclient
+
+import (
+	"io"
+	"time"
+)
+
+// progressInterval is the shortest interval between the progress reports of
+// the download.
+const progressInterval = 200 * time.Millisecond
+
+// DownloadProgress describes the progress of the download of the recording.
+type DownloadProgress struct {
+	Bytes int64
+	// Total is the size of the recording reported by the DVR, 0 when it is
+	// not known.
+	Total   int64
+	Elapsed time.Duration
+	// Done is set in the last report, after the download succeeded or
+	// failed.
+	Done bool
+}
+
+// ProgressFunc receives the progress of the download, it is called from
+// the downloading goroutine.
+type ProgressFunc func(DownloadProgress)
+
+type progressReader struct {
+	reader   io.Reader
+	progress ProgressFunc
+	started  time.Time
+	reported time.Time
+	bytes    int64
+	total    int64
+}
+
+func newProgressReader(r io.Reader, total int64, progress ProgressFunc) *progressReader {
+	if total < 0 {
+		total = 0
+	}
+
+	now := time.Now()
+	pr := &progressReader{
+		reader:   r,
+		progress: progress,
+		started:  now,
+		reported: now,
+		total:    total,
+	}
+	pr.report(false)
+
+	return pr
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.bytes += int64(n)
+
+	if time.Since(r.reported) >= progressInterval {
+		r.reported = time.Now()
+		r.report(false)
+	}
+
+	return n, err
+}
+
+func (r *progressReader) report(done bool) {
+	r.progress(DownloadProgress{
+		Bytes:   r.bytes,
+		Total:   r.total,
+		Elapsed: time.Since(r.started),
+		Done:    done,
+	})
+}
@@ -1,6 +1,7 @@
 package defewayclient
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -31,6 +32,10 @@ type RecordingsFetchParams struct {
 	EndTime        time.Time
 	RecordingTypes uint16
 	StartTime      time.Time
+	// AllowEmpty returns no recordings, when the DVR found none, instead of
+	// retrying the search, like when the DVR is polled for the new
+	// recordings.
+	AllowEmpty bool
 }
 
 func (rm *RecordingsClient) Fetch(
@@ -49,11 +54,92 @@ func (rm *RecordingsClient) Fetch(
 		Username:     rm.fetchClient.Username,
 	}
 
-	return rm.fetchAllWithRetry(recSearch)
+	return rm.fetchAllWithRetry(recSearch, fetchParams.AllowEmpty)
+}
+
+// FetchWindow returns the recordings of the time window, given in the time
+// zone of the DVR. The DVR searches within the single day, so the window
+// crossing the midnight is searched day by day. The days without recordings
+// are not errors, and the failed search of one day does not stop the search
+// of the other days, the recordings found are returned with its error.
+func (rm *RecordingsClient) FetchWindow(start, end time.Time, channels, recordingTypes uint16) ([]RecordingMeta, error) {
+	search := func(start, end time.Time) RecordingsFetchParams {
+		return RecordingsFetchParams{
+			AllowEmpty:     true,
+			Channels:       channels,
+			Date:           start,
+			EndTime:        end,
+			RecordingTypes: recordingTypes,
+			StartTime:      start,
+		}
+	}
+
+	var searches []RecordingsFetchParams
+	for start.Day() != end.Day() {
+		searches = append(searches, search(start, time.Date(start.Year(), start.Month(), start.Day(), 23, 59, 59, 0, start.Location())))
+		start = time.Date(start.Year(), start.Month(), start.Day()+1, 0, 0, 0, 0, start.Location())
+	}
+	searches = append(searches, search(start, end))
+
+	var recordings []RecordingMeta
+	var firstErr error
+	for _, fetchParams := range searches {
+		found, err := rm.Fetch(fetchParams)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("search of %s: %w", fetchParams.Date.Format("2006-01-02"), err)
+			}
+			continue
+		}
+
+		recordings = append(recordings, found...)
+	}
+
+	return recordings, firstErr
+}
+
+// FetchLatest returns the end timestamps of the latest recordings per
+// channel. The day of now is searched first, then the channels without the
+// recordings are searched back for the given number of days before it. The
+// days without recordings are not errors, and the failed search of one day
+// does not stop the search of the other days, the timestamps found are
+// returned with its error.
+func (rm *RecordingsClient) FetchLatest(now time.Time, days int, channels, recordingTypes uint16) (map[uint16]uint64, error) {
+	latest := make(map[uint16]uint64)
+	var firstErr error
+	for day := 0; day <= days && channels != 0; day++ {
+		date := now.AddDate(0, 0, -day)
+		recordings, err := rm.Fetch(RecordingsFetchParams{
+			AllowEmpty:     true,
+			Channels:       channels,
+			Date:           date,
+			EndTime:        time.Date(0, 0, 0, 23, 59, 59, 999999999, time.UTC),
+			RecordingTypes: recordingTypes,
+			StartTime:      time.Date(0, 0, 0, 0, 0, 0, 0, time.UTC),
+		})
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("search of %s: %w", date.Format("2006-01-02"), err)
+			}
+			continue
+		}
+
+		for _, rec := range recordings {
+			if rec.EndTimestamp > latest[rec.ChannelID] {
+				latest[rec.ChannelID] = rec.EndTimestamp
+			}
+			if rec.ChannelID < 16 {
+				channels &^= 1 << rec.ChannelID
+			}
+		}
+	}
+
+	return latest, firstErr
 }
 
 func (rm *RecordingsClient) fetchAllWithRetry(
 	recSearch DefewayRecSearch,
+	allowEmpty bool,
 ) ([]RecordingMeta, error) {
 	retryCount := 0
 	retryMax := 10
@@ -99,6 +185,10 @@ func (rm *RecordingsClient) fetchAllWithRetry(
 			return nil, err
 		}
 
+		if retry && allowEmpty && isEmptySearch(recSearchRes) {
+			break
+		}
+
 		if retry {
 			if err != nil {
 				log.Println(err.Error())
@@ -124,6 +214,20 @@ func (rm *RecordingsClient) fetchAllWithRetry(
 	return result, nil
 }
 
+// isEmptySearch reports whether the DVR responded that it found no
+// recordings.
+func isEmptySearch(res *DefewayJuan) bool {
+	if res == nil || res.RecSearch == nil {
+		return false
+	}
+
+	if isErr, _ := res.HasError(); isErr {
+		return false
+	}
+
+	return res.RecSearch.SearchResults == nil && res.RecSearch.SessionTotal == 0
+}
+
 func parseRecSearchResp(resp *http.Response) (*DefewayJuan, bool, error) {
 	defer resp.Body.Close()
 
@@ -148,11 +252,19 @@ func parseRecSearchResp(resp *http.Response) (*DefewayJuan, bool, error) {
 	return recSearchRes, false, nil
 }
 
+// Download writes the recording to the destination.
 func (rm *RecordingsClient) Download(recMeta RecordingMeta, dst io.Writer, isPreview bool) error {
+	return rm.DownloadWithProgress(recMeta, dst, isPreview, nil)
+}
+
+// DownloadWithProgress writes the recording to the destination like
+// Download. The progress, when it is not nil, receives the progress of the
+// download.
+func (rm *RecordingsClient) DownloadWithProgress(recMeta RecordingMeta, dst io.Writer, isPreview bool, progress ProgressFunc) error {
 	endTimestamp := rm.computeEndTimestamp(recMeta, isPreview)
 	queryParams := fmt.Sprintf(`u=%s&p=%s&mode=time&chn=%d&begin=%d&end=%d&mute=false&download=1`,
-		rm.downloadClient.Username,
-		rm.downloadClient.Password,
+		url.QueryEscape(rm.downloadClient.Username),
+		url.QueryEscape(rm.downloadClient.Password),
 		recMeta.ChannelID,
 		recMeta.StartTimestamp,
 		endTimestamp)
@@ -164,15 +276,47 @@ func (rm *RecordingsClient) Download(recMeta RecordingMeta, dst io.Writer, isPre
 		RawQuery: queryParams,
 	}
 
-	resp, err := rm.downloadClient.Client.Get(addr.String())
+	// the wait for the connection slot does not count to the stall timeout
+	// and the deadline
+	release := rm.downloadClient.Limiter.Acquire(rm.downloadClient.Address)
+	defer release()
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+	deadline := downloadDeadline(rm.downloadClient.DeadlineRatio, endTimestamp-recMeta.StartTimestamp)
+	if deadline > 0 {
+		ctx, cancel = context.WithTimeout(withAcquired(context.Background(), release), deadline)
+	} else {
+		ctx, cancel = context.WithCancel(withAcquired(context.Background(), release))
+	}
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, addr.String(), nil)
 	if err != nil {
 		return err
 	}
+
+	stall := &stallWatch{timeout: rm.downloadClient.StallTimeout, cancel: cancel}
+
+	var resp *http.Response
+	stall.watch(func() {
+		resp, err = rm.downloadClient.Client.Do(req)
+	})
+	if err != nil {
+		return stall.err(ctx, deadline, err)
+	}
 	defer resp.Body.Close()
 
-	_, err = io.Copy(dst, resp.Body)
+	body := rm.downloadClient.Bandwidth.Reader(rm.downloadClient.Address, &stallReader{reader: resp.Body, watch: stall})
+	if progress != nil {
+		pr := newProgressReader(body, resp.ContentLength, progress)
+		defer pr.report(true)
+		body = pr
+	}
+
+	_, err = io.Copy(dst, body)
 	if err != nil {
-		return err
+		return stall.err(ctx, deadline, err)
 	}
 
 	return nil
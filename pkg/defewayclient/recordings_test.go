@@ -2,10 +2,15 @@ package defewayclient
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -147,6 +152,196 @@ func Test_RecordingsClient_Fetch(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, 4, len(recordings))
 	})
+
+	t.Run("returns no recordings when none found and empty allowed", func(t *testing.T) {
+		calls := 0
+		server := httptest.NewServer(
+			http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				calls++
+				juanMarshaled := `
+			<juan ver="" squ="" dir="0" enc="0" errno="0">
+				<recsearch usr="admin" pwd="passwd" channels="3" types="15" date="2019-01-01" begin="00:00:00" end="23:59:59" session_index="0" session_count="0" session_total="0">
+				</recsearch>
+			</juan>`
+				rw.Write([]byte(juanMarshaled))
+			}))
+		defer server.Close()
+
+		rm := &RecordingsClient{
+			fetchClient: fixClient(server.Client(), server.URL[7:]),
+		}
+		fetchParams := RecordingsFetchParams{AllowEmpty: true}
+
+		recordings, err := rm.Fetch(fetchParams)
+
+		require.NoError(t, err)
+		require.Empty(t, recordings)
+		require.Equal(t, 1, calls)
+	})
+}
+
+func Test_RecordingsClient_FetchWindow(t *testing.T) {
+	newServer := func(searches *[]DefewayRecSearch, responses map[string]string) *httptest.Server {
+		var mu sync.Mutex
+		return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			juan, err := UnmarshalJuan([]byte(req.URL.Query().Get("xml")))
+			if err != nil || juan.RecSearch == nil {
+				rw.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			mu.Lock()
+			*searches = append(*searches, *juan.RecSearch)
+			mu.Unlock()
+
+			rw.Write([]byte(responses[juan.RecSearch.Date]))
+		}))
+	}
+
+	empty := `
+	<juan ver="" squ="" dir="0" enc="0" errno="0">
+		<recsearch usr="admin" pwd="passwd" channels="3" types="15" date="2019-01-01" begin="22:00:00" end="23:59:59" session_index="0" session_count="10" session_total="0">
+		</recsearch>
+	</juan>`
+	found := `
+	<juan ver="" squ="" dir="0" enc="0" errno="0">
+		<recsearch usr="admin" pwd="passwd" channels="3" types="15" date="2019-01-02" begin="00:00:00" end="01:00:00" session_index="0" session_count="10" session_total="1">
+			<s>0|1|1|8|1546389000|1546389060</s>
+		</recsearch>
+	</juan>`
+	start := time.Date(2019, 1, 1, 23, 0, 0, 0, time.UTC)
+	end := time.Date(2019, 1, 2, 1, 0, 0, 0, time.UTC)
+
+	t.Run("searches each day of the window crossing the midnight", func(t *testing.T) {
+		var searches []DefewayRecSearch
+		server := newServer(&searches, map[string]string{
+			"2019-01-01": empty,
+			"2019-01-02": found,
+		})
+		defer server.Close()
+
+		rm := &RecordingsClient{
+			fetchClient: fixClient(server.Client(), server.URL[7:]),
+		}
+
+		recordings, err := rm.FetchWindow(start, end, 3, 15)
+
+		require.NoError(t, err)
+		require.Equal(t, 1, len(recordings))
+		require.Equal(t, uint64(1546389000), recordings[0].StartTimestamp)
+		require.Equal(t, 2, len(searches))
+		require.Equal(t, "2019-01-01", searches[0].Date)
+		require.Equal(t, "23:00:00", searches[0].BeginTime)
+		require.Equal(t, "23:59:59", searches[0].EndTime)
+		require.Equal(t, "2019-01-02", searches[1].Date)
+		require.Equal(t, "00:00:00", searches[1].BeginTime)
+		require.Equal(t, "01:00:00", searches[1].EndTime)
+	})
+
+	t.Run("searches the next day when the search of a day fails", func(t *testing.T) {
+		var searches []DefewayRecSearch
+		server := newServer(&searches, map[string]string{
+			"2019-01-02": found,
+		})
+		defer server.Close()
+
+		rm := &RecordingsClient{
+			fetchClient: fixClient(server.Client(), server.URL[7:]),
+		}
+
+		recordings, err := rm.FetchWindow(start, end, 3, 15)
+
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "2019-01-01")
+		require.Equal(t, 1, len(recordings))
+		require.Equal(t, "2019-01-02", searches[len(searches)-1].Date)
+	})
+}
+
+func Test_RecordingsClient_FetchLatest(t *testing.T) {
+	t.Run("searches back only the channels without recordings", func(t *testing.T) {
+		var mu sync.Mutex
+		var searches []DefewayRecSearch
+		responses := map[string]string{
+			"2019-01-03": `
+			<juan ver="" squ="" dir="0" enc="0" errno="0">
+				<recsearch usr="admin" pwd="passwd" channels="3" types="15" date="2019-01-03" begin="00:00:00" end="23:59:59" session_index="0" session_count="10" session_total="2">
+					<s>0|1|0|1|1546500000|1546500060</s>
+					<s>0|2|0|1|1546510000|1546510060</s>
+				</recsearch>
+			</juan>`,
+			"2019-01-02": `
+			<juan ver="" squ="" dir="0" enc="0" errno="0">
+				<recsearch usr="admin" pwd="passwd" channels="2" types="15" date="2019-01-02" begin="00:00:00" end="23:59:59" session_index="0" session_count="10" session_total="0">
+				</recsearch>
+			</juan>`,
+			"2019-01-01": `
+			<juan ver="" squ="" dir="0" enc="0" errno="0">
+				<recsearch usr="admin" pwd="passwd" channels="2" types="15" date="2019-01-01" begin="00:00:00" end="23:59:59" session_index="0" session_count="10" session_total="1">
+					<s>0|3|1|1|1546340000|1546340060</s>
+				</recsearch>
+			</juan>`,
+		}
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			juan, err := UnmarshalJuan([]byte(req.URL.Query().Get("xml")))
+			if err != nil || juan.RecSearch == nil {
+				rw.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			mu.Lock()
+			searches = append(searches, *juan.RecSearch)
+			mu.Unlock()
+
+			rw.Write([]byte(responses[juan.RecSearch.Date]))
+		}))
+		defer server.Close()
+
+		rm := &RecordingsClient{
+			fetchClient: fixClient(server.Client(), server.URL[7:]),
+		}
+
+		latest, err := rm.FetchLatest(time.Date(2019, 1, 3, 12, 0, 0, 0, time.UTC), 7, 3, 15)
+
+		require.NoError(t, err)
+		require.Equal(t, map[uint16]uint64{0: 1546510060, 1: 1546340060}, latest)
+		require.Equal(t, 3, len(searches))
+		require.Equal(t, "2019-01-03", searches[0].Date)
+		require.Equal(t, uint16(3), searches[0].Channels)
+		require.Equal(t, "2019-01-02", searches[1].Date)
+		require.Equal(t, uint16(2), searches[1].Channels)
+		require.Equal(t, "2019-01-01", searches[2].Date)
+		require.Equal(t, uint16(2), searches[2].Channels)
+	})
+
+	t.Run("searches only the day of now without lookback", func(t *testing.T) {
+		var searches []string
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			juan, err := UnmarshalJuan([]byte(req.URL.Query().Get("xml")))
+			if err != nil || juan.RecSearch == nil {
+				rw.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			searches = append(searches, juan.RecSearch.Date)
+			rw.Write([]byte(`
+			<juan ver="" squ="" dir="0" enc="0" errno="0">
+				<recsearch usr="admin" pwd="passwd" channels="3" types="15" date="2019-01-03" begin="00:00:00" end="23:59:59" session_index="0" session_count="10" session_total="0">
+				</recsearch>
+			</juan>`))
+		}))
+		defer server.Close()
+
+		rm := &RecordingsClient{
+			fetchClient: fixClient(server.Client(), server.URL[7:]),
+		}
+
+		latest, err := rm.FetchLatest(time.Date(2019, 1, 3, 12, 0, 0, 0, time.UTC), 0, 3, 15)
+
+		require.NoError(t, err)
+		require.Empty(t, latest)
+		require.Equal(t, []string{"2019-01-03"}, searches)
+	})
 }
 
 func Test_RecordingsClient_Download(t *testing.T) {
@@ -247,6 +442,146 @@ func Test_RecordingsClient_Download(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, "Hello!", dst.String())
 	})
+
+	t.Run("aborts the download stalled after the first bytes", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rw.Write([]byte("Hello!"))
+			rw.(http.Flusher).Flush()
+			<-release
+		}))
+		defer server.Close()
+		defer close(release)
+
+		downloadClient := fixClient(server.Client(), server.URL[7:])
+		downloadClient.StallTimeout = 50 * time.Millisecond
+		rm := &RecordingsClient{
+			downloadClient: downloadClient,
+		}
+
+		var dst bytes.Buffer
+		err := rm.Download(RecordingMeta{}, &dst, false)
+
+		require.True(t, errors.Is(err, ErrStalled))
+		require.Equal(t, "Hello!", dst.String())
+	})
+
+	t.Run("aborts the download when the DVR does not respond", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			<-release
+		}))
+		defer server.Close()
+		defer close(release)
+
+		downloadClient := fixClient(server.Client(), server.URL[7:])
+		downloadClient.StallTimeout = 50 * time.Millisecond
+		rm := &RecordingsClient{
+			downloadClient: downloadClient,
+		}
+
+		var dst bytes.Buffer
+		err := rm.Download(RecordingMeta{}, &dst, false)
+
+		require.True(t, errors.Is(err, ErrStalled))
+	})
+
+	t.Run("does not abort the slow download which keeps sending data", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			for i := 0; i < 4; i++ {
+				rw.Write([]byte("Hello!"))
+				rw.(http.Flusher).Flush()
+				time.Sleep(30 * time.Millisecond)
+			}
+		}))
+		defer server.Close()
+
+		downloadClient := fixClient(server.Client(), server.URL[7:])
+		downloadClient.StallTimeout = 100 * time.Millisecond
+		rm := &RecordingsClient{
+			downloadClient: downloadClient,
+		}
+
+		var dst bytes.Buffer
+		err := rm.Download(RecordingMeta{}, &dst, false)
+
+		require.NoError(t, err)
+		require.Equal(t, strings.Repeat("Hello!", 4), dst.String())
+	})
+
+	t.Run("does not abort the download waiting for the connection slot", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			for i := 0; i < 4; i++ {
+				rw.Write([]byte("Hello!"))
+				rw.(http.Flusher).Flush()
+				time.Sleep(30 * time.Millisecond)
+			}
+		}))
+		defer server.Close()
+
+		limiter := NewLimiter(LimiterConfig{PerHostConnections: 1})
+		httpCli := server.Client()
+		httpCli.Transport = &limitedTransport{base: httpCli.Transport, limiter: limiter}
+		downloadClient := fixClient(httpCli, server.URL[7:])
+		downloadClient.Limiter = limiter
+		downloadClient.StallTimeout = 100 * time.Millisecond
+		rm := &RecordingsClient{
+			downloadClient: downloadClient,
+		}
+
+		var wg sync.WaitGroup
+		errs := make([]error, 3)
+		for i := range errs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i] = rm.Download(RecordingMeta{}, ioutil.Discard, false)
+			}(i)
+		}
+		wg.Wait()
+
+		for _, err := range errs {
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("reports the progress of the download", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rw.Header().Set("Content-Length", "6")
+			rw.Write([]byte("Hello!"))
+		}))
+		defer server.Close()
+
+		rm := &RecordingsClient{
+			downloadClient: fixClient(server.Client(), server.URL[7:]),
+		}
+
+		var reports []DownloadProgress
+		var dst bytes.Buffer
+		err := rm.DownloadWithProgress(RecordingMeta{}, &dst, false, func(p DownloadProgress) {
+			reports = append(reports, p)
+		})
+
+		require.NoError(t, err)
+		require.True(t, len(reports) >= 2)
+		require.Equal(t, int64(0), reports[0].Bytes)
+		require.False(t, reports[0].Done)
+		last := reports[len(reports)-1]
+		require.Equal(t, int64(6), last.Bytes)
+		require.Equal(t, int64(6), last.Total)
+		require.True(t, last.Done)
+	})
+}
+
+func Test_downloadDeadline(t *testing.T) {
+	t.Run("does not limit without the ratio", func(t *testing.T) {
+		require.Equal(t, time.Duration(0), downloadDeadline(0, 3600))
+	})
+
+	t.Run("multiplies the duration of the recording", func(t *testing.T) {
+		require.Equal(t, 2*time.Hour+deadlineSlack, downloadDeadline(2, 3600))
+		require.Equal(t, 90*time.Second+deadlineSlack, downloadDeadline(1.5, 60))
+	})
 }
 
 func fixClient(httpCli *http.Client, addr string) *client {
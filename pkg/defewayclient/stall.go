@@ -0,0 +1,93 @@
+package defewayclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// deadlineSlack is added to the deadline of the download, so the short
+// recordings are not cut off by the time of connecting to the DVR.
+const deadlineSlack = time.Minute
+
+var (
+	// ErrStalled is returned by the download which received no data for
+	// the stall timeout.
+	ErrStalled = errors.New("download stalled")
+	// ErrDeadline is returned by the download which took longer than the
+	// deadline based on the duration of the recording.
+	ErrDeadline = errors.New("download exceeded deadline")
+)
+
+// downloadDeadline returns the longest time of the download of the recording
+// of the duration in seconds, 0 does not limit.
+func downloadDeadline(ratio float64, seconds uint64) time.Duration {
+	if ratio <= 0 {
+		return 0
+	}
+
+	return time.Duration(ratio*float64(seconds)*float64(time.Second)) + deadlineSlack
+}
+
+// stallWatch cancels the download blocked on the DVR for longer than the
+// timeout. Only the time spent waiting for the DVR counts, not the time of
+// the writes, of the bandwidth throttling or of the wait for the connection
+// slot of the limiter.
+type stallWatch struct {
+	timeout time.Duration
+	cancel  context.CancelFunc
+
+	mu      sync.Mutex
+	stalled bool
+}
+
+// watch runs the call blocked on the DVR.
+func (s *stallWatch) watch(call func()) {
+	if s.timeout <= 0 {
+		call()
+		return
+	}
+
+	timer := time.AfterFunc(s.timeout, func() {
+		s.mu.Lock()
+		s.stalled = true
+		s.mu.Unlock()
+		s.cancel()
+	})
+	call()
+	timer.Stop()
+}
+
+// err returns the error of the download, which is ErrStalled or ErrDeadline
+// when the download was cancelled by them.
+func (s *stallWatch) err(ctx context.Context, deadline time.Duration, err error) error {
+	s.mu.Lock()
+	stalled := s.stalled
+	s.mu.Unlock()
+
+	if stalled {
+		return fmt.Errorf("%w, no data for %s", ErrStalled, s.timeout)
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%w of %s", ErrDeadline, deadline)
+	}
+
+	return err
+}
+
+type stallReader struct {
+	reader io.Reader
+	watch  *stallWatch
+}
+
+func (r *stallReader) Read(p []byte) (n int, err error) {
+	r.watch.watch(func() {
+		n, err = r.reader.Read(p)
+	})
+
+	return n, err
+}
@@ -0,0 +1,208 @@
+package health
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	dc "github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/crabtree/defeway-toolbox/pkg/inventory"
+	"github.com/crabtree/defeway-toolbox/pkg/snapshot"
+)
+
+type Status int
+
+const (
+	Pass Status = iota
+	Warn
+	Fail
+)
+
+// Exit codes compatible with Nagios and Icinga plugins.
+const (
+	ExitOK       = 0
+	ExitWarning  = 1
+	ExitCritical = 2
+	ExitUnknown  = 3
+)
+
+func (s Status) String() string {
+	switch s {
+	case Pass:
+		return "PASS"
+	case Warn:
+		return "WARN"
+	default:
+		return "FAIL"
+	}
+}
+
+// Label returns the name of the status used by the monitoring plugins.
+func (s Status) Label() string {
+	switch s {
+	case Pass:
+		return "OK"
+	case Warn:
+		return "WARNING"
+	default:
+		return "CRITICAL"
+	}
+}
+
+func (s Status) ExitCode() int {
+	switch s {
+	case Pass:
+		return ExitOK
+	case Warn:
+		return ExitWarning
+	default:
+		return ExitCritical
+	}
+}
+
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+type Finding struct {
+	Check   string
+	Status  Status
+	Message string
+}
+
+type Result struct {
+	Key      string
+	Address  string
+	Findings []Finding
+}
+
+func (r Result) Status() Status {
+	status := Pass
+	for _, f := range r.Findings {
+		if f.Status > status {
+			status = f.Status
+		}
+	}
+
+	return status
+}
+
+// Add appends the finding of the check to the result.
+func (r *Result) Add(check string, status Status, format string, args ...interface{}) {
+	r.Findings = append(r.Findings, Finding{
+		Check:   check,
+		Status:  status,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+type Thresholds struct {
+	DiskUsageWarn   float64
+	DiskUsageFail   float64
+	ExpectedCameras int // 0 - the camera count from the inventory is expected
+}
+
+// Check evaluates the current device info of the inventory device. The
+// fetchErr is the error returned when fetching the device info, which makes
+// the device unreachable.
+func Check(device inventory.Device, juan *dc.DefewayJuan, fetchErr error, t Thresholds) Result {
+	result := Result{
+		Key:     device.Key(),
+		Address: device.Address,
+	}
+
+	if fetchErr != nil {
+		result.Add("reachability", Fail, "%s", describeError(fetchErr))
+		return result
+	}
+	result.Add("reachability", Pass, "device responded")
+
+	current := inventory.NewDevice(device.Address, juan)
+	if id := inventory.Identity(current.DeviceInfo, current.Network); id != "" && !device.Matches(id) {
+		result.Add("identity", Warn, "expected device %s, found %s", device.Key(), id)
+	}
+
+	checkDisks(&result, current.Disks, t)
+	checkCameras(&result, device, current, t)
+
+	return result
+}
+
+// describeError strips the request URL from the HTTP client errors, as it is
+// long and contains the credentials.
+func describeError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+
+	return err
+}
+
+// CheckSnapshots adds the findings of the analysed channel snapshots, so the
+// black, blue, overexposed and frozen cameras are reported.
+func CheckSnapshots(result *Result, channels []snapshot.ChannelResult) {
+	for _, ch := range channels {
+		if ch.IsOK() {
+			result.Add("snapshot", Pass, "channel %d OK", ch.Channel)
+			continue
+		}
+
+		result.Add("snapshot", Warn, "channel %d %s", ch.Channel, ch.Problem())
+	}
+}
+
+func checkDisks(result *Result, disks []dc.HDDMeta, t Thresholds) {
+	if len(disks) == 0 {
+		result.Add("disk", Warn, "no disks reported")
+		return
+	}
+
+	for i, disk := range disks {
+		switch {
+		case disk.Status == dc.HDDStatusDBError:
+			result.Add("disk", Fail, "HDD[%d] %s status %s", i, disk.Model, disk.StatusName())
+			continue
+		case disk.IsUnformatted():
+			result.Add("disk", Warn, "HDD[%d] %s is unformatted", i, disk.Model)
+			continue
+		case !disk.IsOK():
+			result.Add("disk", Warn, "HDD[%d] %s status %s", i, disk.Model, disk.StatusName())
+			continue
+		}
+
+		if disk.Capacity == 0 {
+			continue
+		}
+
+		usage := float64(disk.Used) / float64(disk.Capacity) * 100
+		switch {
+		case t.DiskUsageFail > 0 && usage >= t.DiskUsageFail:
+			result.Add("disk", Fail, "HDD[%d] %s usage %.1f%% above %.1f%%", i, disk.Model, usage, t.DiskUsageFail)
+		case t.DiskUsageWarn > 0 && usage >= t.DiskUsageWarn:
+			result.Add("disk", Warn, "HDD[%d] %s usage %.1f%% above %.1f%%", i, disk.Model, usage, t.DiskUsageWarn)
+		default:
+			result.Add("disk", Pass, "HDD[%d] %s usage %.1f%%", i, disk.Model, usage)
+		}
+	}
+}
+
+func checkCameras(result *Result, expected, current inventory.Device, t Thresholds) {
+	if current.DeviceInfo == nil {
+		result.Add("cameras", Warn, "no device info reported")
+		return
+	}
+
+	want := t.ExpectedCameras
+	if want == 0 && expected.DeviceInfo != nil {
+		want = int(expected.DeviceInfo.CamCount)
+	}
+
+	got := int(current.DeviceInfo.CamCount)
+	if want != 0 && got != want {
+		result.Add("cameras", Warn, "expected %d cameras, found %d", want, got)
+		return
+	}
+
+	result.Add("cameras", Pass, "%d cameras", got)
+}
@@ -0,0 +1,134 @@
+package health
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+
+	dc "github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/crabtree/defeway-toolbox/pkg/inventory"
+	"github.com/crabtree/defeway-toolbox/pkg/snapshot"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	thresholds := Thresholds{DiskUsageWarn: 90, DiskUsageFail: 98}
+	device := inventory.Device{
+		Address:    "192.168.1.1:80",
+		DeviceInfo: &dc.DefewayDeviceInfo{SerialNumber: "AA01", CamCount: 4},
+	}
+
+	t.Run("should fail when device is unreachable", func(t *testing.T) {
+		result := Check(device, nil, fmt.Errorf("connection refused"), thresholds)
+
+		require.Equal(t, Fail, result.Status())
+		require.Equal(t, "AA01", result.Key)
+		require.Equal(t, "connection refused", result.Findings[0].Message)
+	})
+
+	t.Run("should strip request URL from the error message", func(t *testing.T) {
+		err := &url.Error{Op: "Get", URL: "http://192.168.1.1/cgi-bin/gw.cgi?pwd=secret", Err: fmt.Errorf("timeout")}
+
+		result := Check(device, nil, err, thresholds)
+
+		require.Equal(t, "timeout", result.Findings[0].Message)
+	})
+
+	t.Run("should pass healthy device", func(t *testing.T) {
+		juan := newTestJuan("AA01", 4, dc.HDDMeta{Model: "WD", Status: dc.HDDStatusOK, Capacity: 100, Used: 50})
+
+		result := Check(device, juan, nil, thresholds)
+
+		require.Equal(t, Pass, result.Status())
+	})
+
+	t.Run("should fail disk with DB error", func(t *testing.T) {
+		juan := newTestJuan("AA01", 4, dc.HDDMeta{Model: "WD", Status: dc.HDDStatusDBError})
+
+		result := Check(device, juan, nil, thresholds)
+
+		require.Equal(t, Fail, result.Status())
+	})
+
+	t.Run("should warn about unformatted disk", func(t *testing.T) {
+		juan := newTestJuan("AA01", 4, dc.HDDMeta{Model: "WD", Status: 1})
+
+		result := Check(device, juan, nil, thresholds)
+
+		require.Equal(t, Warn, result.Status())
+		require.Contains(t, messages(result), "HDD[0] WD is unformatted")
+	})
+
+	t.Run("should report disk usage above thresholds", func(t *testing.T) {
+		warn := newTestJuan("AA01", 4, dc.HDDMeta{Model: "WD", Status: dc.HDDStatusOK, Capacity: 100, Used: 95})
+		fail := newTestJuan("AA01", 4, dc.HDDMeta{Model: "WD", Status: dc.HDDStatusOK, Capacity: 100, Used: 99})
+
+		require.Equal(t, Warn, Check(device, warn, nil, thresholds).Status())
+		require.Equal(t, Fail, Check(device, fail, nil, thresholds).Status())
+	})
+
+	t.Run("should warn when camera count differs from the inventory", func(t *testing.T) {
+		juan := newTestJuan("AA01", 3, dc.HDDMeta{Model: "WD", Status: dc.HDDStatusOK})
+
+		result := Check(device, juan, nil, thresholds)
+
+		require.Equal(t, Warn, result.Status())
+		require.Contains(t, messages(result), "expected 4 cameras, found 3")
+	})
+
+	t.Run("should use expected camera count from thresholds", func(t *testing.T) {
+		juan := newTestJuan("AA01", 4, dc.HDDMeta{Model: "WD", Status: dc.HDDStatusOK})
+
+		result := Check(device, juan, nil, Thresholds{ExpectedCameras: 8})
+
+		require.Contains(t, messages(result), "expected 8 cameras, found 4")
+	})
+
+	t.Run("should warn when another device responds at the address", func(t *testing.T) {
+		juan := newTestJuan("BB02", 4, dc.HDDMeta{Model: "WD", Status: dc.HDDStatusOK})
+
+		result := Check(device, juan, nil, thresholds)
+
+		require.Equal(t, Warn, result.Status())
+		require.Contains(t, messages(result), "expected device AA01, found BB02")
+	})
+}
+
+func TestCheckSnapshots(t *testing.T) {
+	t.Run("should warn about not OK channels", func(t *testing.T) {
+		result := Result{}
+
+		CheckSnapshots(&result, []snapshot.ChannelResult{
+			{Channel: 0, Verdict: snapshot.VerdictOK},
+			{Channel: 1, Verdict: snapshot.VerdictOK, Frozen: true},
+		})
+
+		require.Equal(t, Warn, result.Status())
+		require.Equal(t, []string{"channel 0 OK", "channel 1 frozen image"}, messages(result))
+	})
+}
+
+func TestStatus_ExitCode(t *testing.T) {
+	t.Run("should map statuses to plugin exit codes", func(t *testing.T) {
+		require.Equal(t, ExitOK, Pass.ExitCode())
+		require.Equal(t, ExitWarning, Warn.ExitCode())
+		require.Equal(t, ExitCritical, Fail.ExitCode())
+	})
+}
+
+func newTestJuan(serial string, camCount uint8, disks ...dc.HDDMeta) *dc.DefewayJuan {
+	return &dc.DefewayJuan{
+		DeviceInfo: &dc.DefewayDeviceInfo{SerialNumber: serial, CamCount: camCount},
+		EnvLoad:    &dc.DefewayEnvLoad{},
+		HDD:        &dc.DefewayHDD{Disks: disks},
+	}
+}
+
+func messages(result Result) []string {
+	var msgs []string
+	for _, f := range result.Findings {
+		msgs = append(msgs, f.Message)
+	}
+
+	return msgs
+}
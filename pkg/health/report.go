@@ -0,0 +1,54 @@
+package health
+
+import (
+	"fmt"
+	"io"
+)
+
+// Worst returns the worst status of all results.
+func Worst(results []Result) Status {
+	status := Pass
+	for _, result := range results {
+		if result.Status() > status {
+			status = result.Status()
+		}
+	}
+
+	return status
+}
+
+// WriteReport writes the plugin style report. The first line summarizes all
+// results, the following lines list the devices with their not passed
+// findings.
+func WriteReport(w io.Writer, title string, results []Result) error {
+	counts := make(map[Status]int)
+	for _, result := range results {
+		counts[result.Status()]++
+	}
+
+	_, err := fmt.Fprintf(w, "%s %s - %d devices: %d pass, %d warn, %d fail\n",
+		title, Worst(results).Label(), len(results), counts[Pass], counts[Warn], counts[Fail])
+	if err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		_, err := fmt.Fprintf(w, "%s %s %s\n", result.Status(), result.Key, result.Address)
+		if err != nil {
+			return err
+		}
+
+		for _, f := range result.Findings {
+			if f.Status == Pass {
+				continue
+			}
+
+			_, err := fmt.Fprintf(w, "  %s %s: %s\n", f.Status, f.Check, f.Message)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
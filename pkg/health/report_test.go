@@ -0,0 +1,37 @@
+package health
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteReport(t *testing.T) {
+	t.Run("should write summary and not passed findings", func(t *testing.T) {
+		results := []Result{
+			{Key: "AA01", Address: "192.168.1.1:80", Findings: []Finding{{"reachability", Pass, "device responded"}}},
+			{Key: "AA02", Address: "192.168.1.2:80", Findings: []Finding{{"disk", Warn, "HDD[0] is unformatted"}}},
+		}
+
+		var out bytes.Buffer
+		err := WriteReport(&out, "HEALTH", results)
+
+		require.NoError(t, err)
+		require.Equal(t, `HEALTH WARNING - 2 devices: 1 pass, 1 warn, 0 fail
+PASS AA01 192.168.1.1:80
+WARN AA02 192.168.1.2:80
+  WARN disk: HDD[0] is unformatted
+`, out.String())
+	})
+
+	t.Run("should return the worst status", func(t *testing.T) {
+		results := []Result{
+			{Findings: []Finding{{"disk", Warn, ""}}},
+			{Findings: []Finding{{"reachability", Fail, ""}}},
+		}
+
+		require.Equal(t, Fail, Worst(results))
+		require.Equal(t, Pass, Worst(nil))
+	})
+}
@@ -0,0 +1,84 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const DefaultTimeout = 10 * time.Second
+
+// Command runs the shell command with the JSON event on the standard input
+// and the name of the event in the DEFEWAY_EVENT environment variable.
+type Command struct {
+	Command string
+	Timeout time.Duration
+}
+
+func NewCommand(command string, timeout time.Duration) *Command {
+	return &Command{Command: command, Timeout: timeout}
+}
+
+func (c *Command) String() string {
+	return c.Command
+}
+
+func (c *Command) Fire(e Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", c.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "/bin/sh", "-c", c.Command)
+	}
+
+	var output bytes.Buffer
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	cmd.Env = append(os.Environ(), "DEFEWAY_EVENT="+e.Event)
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	// the children of the shell keep the output open after the shell is
+	// killed, so the command is not waited for after the timeout
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("timed out after %s", timeout)
+	case err = <-done:
+	}
+
+	if err != nil {
+		if msg := strings.TrimSpace(output.String()); msg != "" {
+			return fmt.Errorf("%s: %s", err, msg)
+		}
+		return err
+	}
+
+	return nil
+}
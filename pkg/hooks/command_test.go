@@ -0,0 +1,54 @@
+package hooks
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	dc "github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommand_Fire(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test commands are shell commands")
+	}
+
+	t.Run("should pass event on standard input", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "hooks")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		fp := filepath.Join(dir, "event.json")
+
+		cmd := NewCommand(`echo "$DEFEWAY_EVENT" > `+fp+`.name; cat > `+fp, 0)
+		err = cmd.Fire(Event{Event: RecordingDownloaded, Recording: &dc.RecordingMeta{RecordingID: 7}})
+		require.NoError(t, err)
+
+		data, err := ioutil.ReadFile(fp)
+		require.NoError(t, err)
+		var e Event
+		require.NoError(t, json.Unmarshal(data, &e))
+		require.Equal(t, RecordingDownloaded, e.Event)
+		require.Equal(t, uint(7), e.Recording.RecordingID)
+
+		name, err := ioutil.ReadFile(fp + ".name")
+		require.NoError(t, err)
+		require.Equal(t, RecordingDownloaded+"\n", string(name))
+	})
+
+	t.Run("should return output of failed command", func(t *testing.T) {
+		err := NewCommand("echo no ticket >&2; exit 3", 0).Fire(Event{Event: RunFinished})
+
+		require.EqualError(t, err, "exit status 3: no ticket")
+	})
+
+	t.Run("should stop command after timeout", func(t *testing.T) {
+		err := NewCommand("sleep 5", 100*time.Millisecond).Fire(Event{Event: RunFinished})
+
+		require.EqualError(t, err, "timed out after 100ms")
+	})
+}
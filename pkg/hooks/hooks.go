@@ -0,0 +1,170 @@
+package hooks
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	dc "github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/crabtree/defeway-toolbox/pkg/secret"
+)
+
+// The names of the events.
+const (
+	RecordingDownloaded = "recording-downloaded"
+	DownloadFailed      = "download-failed"
+	DeviceFound         = "device-found"
+	DeviceError         = "device-error"
+	RunFinished         = "run-finished"
+
+	// AllEvents selects all events in the hook specification.
+	AllEvents = "*"
+)
+
+// queueSize is the number of the events waiting for the hooks, the commands
+// firing more events wait for the room in the queue.
+const queueSize = 256
+
+// Events are the names of all events.
+var Events = []string{RecordingDownloaded, DownloadFailed, DeviceFound, DeviceError, RunFinished}
+
+// Event is the payload sent to the hooks as JSON.
+type Event struct {
+	Event      string
+	Time       time.Time
+	Command    string                `json:",omitempty"`
+	Address    string                `json:",omitempty"`
+	Device     string                `json:",omitempty"`
+	Path       string                `json:",omitempty"`
+	Recording  *dc.RecordingMeta     `json:",omitempty"`
+	DeviceInfo *dc.DefewayDeviceInfo `json:",omitempty"`
+	Error      string                `json:",omitempty"`
+	Summary    map[string]int        `json:",omitempty"`
+}
+
+// Hook is notified about the events.
+type Hook interface {
+	Fire(e Event) error
+	String() string
+}
+
+type binding struct {
+	events map[string]bool
+	hook   Hook
+}
+
+// Hooks dispatches the events to the hooks bound to them. The nil Hooks
+// ignores the events, so the commands fire them unconditionally.
+type Hooks struct {
+	command  string
+	bindings []binding
+
+	start sync.Once
+	queue chan queued
+}
+
+// queued is the event waiting for the hooks, the done channel is closed once
+// the hooks finished with it.
+type queued struct {
+	event Event
+	done  chan struct{}
+}
+
+// New returns the hooks of the command, which is added to the events.
+func New(command string) *Hooks {
+	return &Hooks{command: command}
+}
+
+// Add binds the hook to the events, or to all events with AllEvents.
+func (h *Hooks) Add(events []string, hook Hook) error {
+	selected := make(map[string]bool)
+	for _, event := range events {
+		if event != AllEvents && !isEvent(event) {
+			return fmt.Errorf("unknown event %s, use one of %s or %s", event, strings.Join(Events, ", "), AllEvents)
+		}
+		selected[event] = true
+	}
+
+	h.bindings = append(h.bindings, binding{events: selected, hook: hook})
+
+	return nil
+}
+
+// Fire queues the event for the hooks bound to it, so the slow or retried
+// hooks do not hold up the workers of the command. The events are sent in
+// the order they were fired, one hook after another. RunFinished waits until
+// all events are sent, so the command does not exit before. The failed hooks
+// are logged and do not stop the command.
+func (h *Hooks) Fire(e Event) {
+	if h == nil {
+		return
+	}
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e.Command = h.command
+	// the errors of the HTTP clients contain the request URLs with the
+	// credentials
+	e.Error = secret.Redact(e.Error)
+
+	h.start.Do(func() {
+		h.queue = make(chan queued, queueSize)
+		go h.dispatch()
+	})
+
+	q := queued{event: e, done: make(chan struct{})}
+	h.queue <- q
+
+	if e.Event == RunFinished {
+		<-q.done
+	}
+}
+
+func (h *Hooks) dispatch() {
+	for q := range h.queue {
+		h.send(q.event)
+		close(q.done)
+	}
+}
+
+func (h *Hooks) send(e Event) {
+	for _, b := range h.bindings {
+		if !b.events[e.Event] && !b.events[AllEvents] {
+			continue
+		}
+
+		if err := b.hook.Fire(e); err != nil {
+			log.Printf("Hook %s of %s failed: %s\n", b.hook, e.Event, secret.Redact(err.Error()))
+		}
+	}
+}
+
+// ParseSpec parses the hook specification in format <events>=<target>, where
+// the events are the comma separated names of the events or *, like
+// download-failed,device-error=/usr/local/bin/ticket.
+func ParseSpec(spec string) ([]string, string, error) {
+	kv := strings.SplitN(spec, "=", 2)
+	if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" || strings.TrimSpace(kv[1]) == "" {
+		return nil, "", fmt.Errorf("specify hook in format <events>=<target>")
+	}
+
+	var events []string
+	for _, event := range strings.Split(kv[0], ",") {
+		events = append(events, strings.TrimSpace(event))
+	}
+
+	return events, strings.TrimSpace(kv[1]), nil
+}
+
+func isEvent(name string) bool {
+	for _, event := range Events {
+		if event == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,113 @@
+package hooks
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeHook struct {
+	events []Event
+}
+
+func (h *fakeHook) Fire(e Event) error {
+	h.events = append(h.events, e)
+
+	return errors.New("failure")
+}
+
+func (h *fakeHook) String() string {
+	return "fake"
+}
+
+type slowHook struct {
+	release chan struct{}
+	count   int
+}
+
+func (h *slowHook) Fire(e Event) error {
+	<-h.release
+	h.count++
+
+	return nil
+}
+
+func (h *slowHook) String() string {
+	return "slow"
+}
+
+func TestParseSpec(t *testing.T) {
+	t.Run("should parse events and target", func(t *testing.T) {
+		events, target, err := ParseSpec("download-failed, device-error=/usr/local/bin/ticket --queue=cctv")
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"download-failed", "device-error"}, events)
+		require.Equal(t, "/usr/local/bin/ticket --queue=cctv", target)
+	})
+
+	t.Run("should return error for invalid spec", func(t *testing.T) {
+		_, _, err := ParseSpec("/usr/local/bin/ticket")
+		require.Error(t, err)
+
+		_, _, err = ParseSpec("device-error=")
+		require.Error(t, err)
+	})
+}
+
+func TestHooks_Fire(t *testing.T) {
+	t.Run("should send events to bound hooks", func(t *testing.T) {
+		failures := &fakeHook{}
+		all := &fakeHook{}
+		h := New("download")
+		require.NoError(t, h.Add([]string{DownloadFailed}, failures))
+		require.NoError(t, h.Add([]string{AllEvents}, all))
+
+		h.Fire(Event{Event: RecordingDownloaded})
+		h.Fire(Event{Event: DownloadFailed, Error: `Get "http://dvr/?u=admin&p=secret": timeout`})
+		h.Fire(Event{Event: RunFinished})
+
+		require.Len(t, failures.events, 1)
+		require.Equal(t, "download", failures.events[0].Command)
+		require.Equal(t, `Get "http://dvr/?u=******&p=******": timeout`, failures.events[0].Error)
+		require.False(t, failures.events[0].Time.IsZero())
+		require.Len(t, all.events, 3)
+		require.Equal(t, RunFinished, all.events[2].Event)
+	})
+
+	t.Run("should not wait for the slow hooks until the run finishes", func(t *testing.T) {
+		release := make(chan struct{})
+		slow := &slowHook{release: release}
+		h := New("download")
+		require.NoError(t, h.Add([]string{AllEvents}, slow))
+
+		fired := make(chan struct{})
+		go func() {
+			h.Fire(Event{Event: DownloadFailed})
+			h.Fire(Event{Event: DownloadFailed})
+			close(fired)
+		}()
+
+		select {
+		case <-fired:
+		case <-time.After(time.Second):
+			t.Fatal("fire waits for the slow hook")
+		}
+
+		close(release)
+		h.Fire(Event{Event: RunFinished})
+
+		require.Equal(t, 3, slow.count)
+	})
+
+	t.Run("should reject unknown event", func(t *testing.T) {
+		require.Error(t, New("scan").Add([]string{"device-lost"}, &fakeHook{}))
+	})
+
+	t.Run("should ignore events without hooks", func(t *testing.T) {
+		var h *Hooks
+
+		h.Fire(Event{Event: RunFinished})
+	})
+}
@@ -0,0 +1,137 @@
+package hooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+const (
+	DefaultRetries = 3
+
+	// SignatureHeader holds the HMAC-SHA256 signature of the body, like
+	// sha256=<hex>, when the webhook has the secret.
+	SignatureHeader = "X-Defeway-Signature"
+	EventHeader     = "X-Defeway-Event"
+	DeliveryHeader  = "X-Defeway-Delivery"
+)
+
+// Webhook posts the JSON event to the URL. The failed deliveries are
+// retried with the exponential backoff, when the receiver is unavailable or
+// responds with the server error.
+type Webhook struct {
+	URL     string
+	Secret  string
+	Timeout time.Duration
+	Retries int
+	// Backoff is the delay before the first retry, doubled before each
+	// next one.
+	Backoff time.Duration
+	Client  *http.Client
+}
+
+func NewWebhook(url, secret string, timeout time.Duration, retries int) *Webhook {
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	return &Webhook{
+		URL:     url,
+		Secret:  secret,
+		Timeout: timeout,
+		Retries: retries,
+		Backoff: time.Second,
+		Client:  &http.Client{Timeout: timeout},
+	}
+}
+
+func (w *Webhook) String() string {
+	return w.URL
+}
+
+// Sign returns the value of the signature header of the body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (w *Webhook) Fire(e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return w.Post(e.Event, body)
+}
+
+// Post delivers the JSON body of the event, which lets the other commands
+// send their own payloads with the signature and the retries of the webhook.
+func (w *Webhook) Post(event string, body []byte) error {
+	// the same delivery id lets the receiver drop the retried duplicates
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	delivery := hex.EncodeToString(id)
+
+	backoff := w.Backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := w.post(event, delivery, body)
+		if err == nil {
+			return nil
+		}
+
+		if !retry || attempt >= w.Retries {
+			return err
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// post delivers the event and reports whether the failed delivery should be
+// retried.
+func (w *Webhook) post(event, delivery string, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(DeliveryHeader, delivery)
+	if w.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(w.Secret, body))
+	}
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode/100 == 2 {
+		return false, nil
+	}
+
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout
+
+	return retry, fmt.Errorf("unexpected status %s", resp.Status)
+}
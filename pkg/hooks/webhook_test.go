@@ -0,0 +1,71 @@
+package hooks
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhook_Fire(t *testing.T) {
+	t.Run("should post signed event and retry server errors", func(t *testing.T) {
+		var deliveries []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			require.Equal(t, Sign("key", body), r.Header.Get(SignatureHeader))
+			require.Equal(t, DeviceFound, r.Header.Get(EventHeader))
+
+			var e Event
+			require.NoError(t, json.Unmarshal(body, &e))
+			require.Equal(t, "192.168.1.10:60001", e.Address)
+
+			deliveries = append(deliveries, r.Header.Get(DeliveryHeader))
+			if len(deliveries) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		defer server.Close()
+
+		w := NewWebhook(server.URL, "key", time.Second, 3)
+		w.Backoff = time.Millisecond
+
+		require.NoError(t, w.Fire(Event{Event: DeviceFound, Address: "192.168.1.10:60001"}))
+		require.Len(t, deliveries, 3)
+		require.Equal(t, deliveries[0], deliveries[2])
+	})
+
+	t.Run("should not retry client errors", func(t *testing.T) {
+		calls := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			require.Empty(t, r.Header.Get(SignatureHeader))
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		w := NewWebhook(server.URL, "", time.Second, 3)
+		w.Backoff = time.Millisecond
+
+		require.EqualError(t, w.Fire(Event{Event: RunFinished}), "unexpected status 400 Bad Request")
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("should give up after retries", func(t *testing.T) {
+		calls := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		w := NewWebhook(server.URL, "", time.Second, 2)
+		w.Backoff = time.Millisecond
+
+		require.Error(t, w.Fire(Event{Event: RunFinished}))
+		require.Equal(t, 3, calls)
+	})
+}
@@ -0,0 +1,151 @@
+package inventory
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	dc "github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+)
+
+type Change struct {
+	Field string
+	Old   string
+	New   string
+}
+
+type DeviceChanges struct {
+	Key     string
+	Address string
+	Changes []Change
+}
+
+type Diff struct {
+	Added   []Device
+	Removed []Device
+	Changed []DeviceChanges
+}
+
+func (d *Diff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Compare returns the differences between two inventories. The devices are
+// matched by their Key, so a device which changed its IP address is reported
+// as changed and not as removed and added.
+func Compare(oldInv, newInv *Inventory) *Diff {
+	oldEntries, oldKeys := index(oldInv)
+	newEntries, newKeys := index(newInv)
+	diff := &Diff{}
+
+	for _, key := range newKeys {
+		newEntry := newEntries[key]
+		oldEntry, ok := oldEntries[key]
+		if !ok {
+			diff.Added = append(diff.Added, newEntry.device)
+			continue
+		}
+
+		changes := compareEntries(oldEntry, newEntry)
+		if len(changes) > 0 {
+			diff.Changed = append(diff.Changed, DeviceChanges{
+				Key:     key,
+				Address: newEntry.address(),
+				Changes: changes,
+			})
+		}
+	}
+
+	for _, key := range oldKeys {
+		if _, ok := newEntries[key]; !ok {
+			diff.Removed = append(diff.Removed, oldEntries[key].device)
+		}
+	}
+
+	return diff
+}
+
+type entry struct {
+	device    Device
+	addresses []string
+}
+
+func (e *entry) address() string {
+	return strings.Join(e.addresses, ", ")
+}
+
+func index(inv *Inventory) (map[string]*entry, []string) {
+	entries := make(map[string]*entry)
+	var keys []string
+
+	for _, device := range inv.Devices {
+		key := device.Key()
+		e, ok := entries[key]
+		if !ok {
+			e = &entry{device: device}
+			entries[key] = e
+			keys = append(keys, key)
+		}
+		e.addresses = append(e.addresses, device.Address)
+	}
+
+	for _, e := range entries {
+		sort.Strings(e.addresses)
+	}
+	sort.Strings(keys)
+
+	return entries, keys
+}
+
+func compareEntries(oldEntry, newEntry *entry) []Change {
+	var changes []Change
+
+	if oldEntry.address() != newEntry.address() {
+		changes = append(changes, Change{"Address", oldEntry.address(), newEntry.address()})
+	}
+
+	oldInfo := oldEntry.device.DeviceInfo
+	newInfo := newEntry.device.DeviceInfo
+	if oldInfo != nil && newInfo != nil {
+		if oldInfo.SWVer != newInfo.SWVer {
+			changes = append(changes, Change{"SWVer", oldInfo.SWVer, newInfo.SWVer})
+		}
+
+		if oldInfo.CamCount != newInfo.CamCount {
+			changes = append(changes, Change{"CamCount",
+				fmt.Sprintf("%d", oldInfo.CamCount), fmt.Sprintf("%d", newInfo.CamCount)})
+		}
+	}
+
+	changes = append(changes, compareDisks(oldEntry.device.Disks, newEntry.device.Disks)...)
+
+	return changes
+}
+
+// compareDisks reports only the disks which are not OK in the new inventory
+// and were OK or missing in the old one.
+func compareDisks(oldDisks, newDisks []dc.HDDMeta) []Change {
+	var changes []Change
+
+	for i, newDisk := range newDisks {
+		if newDisk.IsOK() {
+			continue
+		}
+
+		oldStatus := "missing"
+		if i < len(oldDisks) {
+			if !oldDisks[i].IsOK() {
+				continue
+			}
+			oldStatus = oldDisks[i].StatusName()
+		}
+
+		changes = append(changes, Change{
+			Field: fmt.Sprintf("HDD[%d]", i),
+			Old:   oldStatus,
+			New:   newDisk.StatusName(),
+		})
+	}
+
+	return changes
+}
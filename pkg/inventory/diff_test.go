@@ -0,0 +1,90 @@
+package inventory
+
+import (
+	"testing"
+
+	dc "github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompare(t *testing.T) {
+	t.Run("should return empty diff for the same inventories", func(t *testing.T) {
+		inv := &Inventory{Devices: []Device{newTestDevice("192.168.1.1:80", "AA01", "1.0", 4)}}
+
+		diff := Compare(inv, inv)
+
+		require.True(t, diff.IsEmpty())
+	})
+
+	t.Run("should report added and removed devices", func(t *testing.T) {
+		oldInv := &Inventory{Devices: []Device{newTestDevice("192.168.1.1:80", "AA01", "1.0", 4)}}
+		newInv := &Inventory{Devices: []Device{newTestDevice("192.168.1.2:80", "AA02", "1.0", 4)}}
+
+		diff := Compare(oldInv, newInv)
+
+		require.Len(t, diff.Added, 1)
+		require.Equal(t, "AA02", diff.Added[0].Key())
+		require.Len(t, diff.Removed, 1)
+		require.Equal(t, "AA01", diff.Removed[0].Key())
+		require.Empty(t, diff.Changed)
+	})
+
+	t.Run("should report address, firmware and camera count changes", func(t *testing.T) {
+		oldInv := &Inventory{Devices: []Device{newTestDevice("192.168.1.1:80", "AA01", "1.0", 4)}}
+		newInv := &Inventory{Devices: []Device{newTestDevice("192.168.1.7:80", "AA01", "1.1", 8)}}
+
+		diff := Compare(oldInv, newInv)
+
+		require.Empty(t, diff.Added)
+		require.Empty(t, diff.Removed)
+		require.Len(t, diff.Changed, 1)
+		require.Equal(t, "AA01", diff.Changed[0].Key)
+		require.Equal(t, []Change{
+			{"Address", "192.168.1.1:80", "192.168.1.7:80"},
+			{"SWVer", "1.0", "1.1"},
+			{"CamCount", "4", "8"},
+		}, diff.Changed[0].Changes)
+	})
+
+	t.Run("should report only new HDD errors", func(t *testing.T) {
+		oldDevice := newTestDevice("192.168.1.1:80", "AA01", "1.0", 4)
+		oldDevice.Disks = []dc.HDDMeta{{Status: dc.HDDStatusOK}, {Status: dc.HDDStatusDBError}}
+		newDevice := newTestDevice("192.168.1.1:80", "AA01", "1.0", 4)
+		newDevice.Disks = []dc.HDDMeta{{Status: dc.HDDStatusDBError}, {Status: dc.HDDStatusDBError}, {Status: 0}}
+
+		diff := Compare(
+			&Inventory{Devices: []Device{oldDevice}},
+			&Inventory{Devices: []Device{newDevice}})
+
+		require.Len(t, diff.Changed, 1)
+		require.Equal(t, []Change{
+			{"HDD[0]", "OK", "DB error"},
+			{"HDD[2]", "missing", "Unformatted"},
+		}, diff.Changed[0].Changes)
+	})
+
+	t.Run("should match device by MAC address when serial number is missing", func(t *testing.T) {
+		oldDevice := newTestDevice("192.168.1.1:80", "", "1.0", 4)
+		oldDevice.Network = &dc.DefewayNetwork{MAC: "00:11:22:33:44:55"}
+		newDevice := newTestDevice("192.168.1.9:80", "", "1.0", 4)
+		newDevice.Network = &dc.DefewayNetwork{MAC: "00:11:22:33:44:55"}
+
+		diff := Compare(
+			&Inventory{Devices: []Device{oldDevice}},
+			&Inventory{Devices: []Device{newDevice}})
+
+		require.Len(t, diff.Changed, 1)
+		require.Equal(t, "00:11:22:33:44:55", diff.Changed[0].Key)
+	})
+}
+
+func newTestDevice(addr, serial, swVer string, camCount uint8) Device {
+	return Device{
+		Address: addr,
+		DeviceInfo: &dc.DefewayDeviceInfo{
+			SerialNumber: serial,
+			SWVer:        swVer,
+			CamCount:     camCount,
+		},
+	}
+}
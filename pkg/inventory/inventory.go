@@ -0,0 +1,150 @@
+package inventory
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	dc "github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/crabtree/defeway-toolbox/pkg/snapshot"
+)
+
+const FileName = "inventory.json"
+
+type Inventory struct {
+	ScannedAt time.Time
+	Devices   []Device
+}
+
+type Device struct {
+	Address    string
+	EnvError   uint8                    `json:",omitempty"`
+	DeviceInfo *dc.DefewayDeviceInfo    `json:",omitempty"`
+	Network    *dc.DefewayNetwork       `json:",omitempty"`
+	Disks      []dc.HDDMeta             `json:",omitempty"`
+	Channels   []snapshot.ChannelResult `json:",omitempty"`
+}
+
+func NewDevice(addr string, juan *dc.DefewayJuan) Device {
+	device := Device{
+		Address:    addr,
+		DeviceInfo: juan.DeviceInfo,
+	}
+
+	if juan.EnvLoad != nil {
+		device.EnvError = juan.EnvLoad.ErrorNo
+		device.Network = juan.EnvLoad.Network
+	}
+
+	if juan.HDD != nil {
+		device.Disks = juan.HDD.Disks
+	}
+
+	return device
+}
+
+// Identity returns the stable identity of the device, which is the serial
+// number, or the MAC address when the serial is unknown. It returns empty
+// string when none of them is known.
+func Identity(info *dc.DefewayDeviceInfo, network *dc.DefewayNetwork) string {
+	if info != nil && info.SerialNumber != "" {
+		return info.SerialNumber
+	}
+
+	if network != nil && network.MAC != "" {
+		return network.MAC
+	}
+
+	return ""
+}
+
+// Key identifies the device across scans. It is the Identity of the device,
+// or the address as the last resort.
+func (d *Device) Key() string {
+	if id := Identity(d.DeviceInfo, d.Network); id != "" {
+		return id
+	}
+
+	return d.Address
+}
+
+// Identities returns the known serial number and MAC address of the device,
+// like the keys of its credentials.
+func (d *Device) Identities() []string {
+	var ids []string
+	if d.DeviceInfo != nil && d.DeviceInfo.SerialNumber != "" {
+		ids = append(ids, d.DeviceInfo.SerialNumber)
+	}
+
+	if d.Network != nil && d.Network.MAC != "" {
+		ids = append(ids, d.Network.MAC)
+	}
+
+	return ids
+}
+
+// Matches reports whether the device has the given serial number or MAC
+// address. The MAC address is compared regardless of case and separators.
+func (d *Device) Matches(id string) bool {
+	if d.DeviceInfo != nil && d.DeviceInfo.SerialNumber != "" &&
+		strings.EqualFold(d.DeviceInfo.SerialNumber, id) {
+		return true
+	}
+
+	if d.Network != nil && d.Network.MAC != "" &&
+		normalizeMAC(d.Network.MAC) == normalizeMAC(id) {
+		return true
+	}
+
+	return false
+}
+
+func normalizeMAC(mac string) string {
+	return strings.ToLower(strings.NewReplacer(":", "", "-", "", ".", "").Replace(mac))
+}
+
+func (inv *Inventory) Add(device Device) {
+	inv.Devices = append(inv.Devices, device)
+}
+
+// Find returns the device with the given serial number or MAC address.
+func (inv *Inventory) Find(id string) (*Device, bool) {
+	for i := range inv.Devices {
+		if inv.Devices[i].Matches(id) {
+			return &inv.Devices[i], true
+		}
+	}
+
+	return nil, false
+}
+
+func (inv *Inventory) Sort() {
+	sort.Slice(inv.Devices, func(i, j int) bool {
+		return inv.Devices[i].Address < inv.Devices[j].Address
+	})
+}
+
+func (inv *Inventory) Save(path string) error {
+	data, err := json.MarshalIndent(inv, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+func Load(path string) (*Inventory, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	inv := &Inventory{}
+	if err := json.Unmarshal(data, inv); err != nil {
+		return nil, err
+	}
+
+	return inv, nil
+}
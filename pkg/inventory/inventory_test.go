@@ -0,0 +1,84 @@
+package inventory
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	dc "github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDevice(t *testing.T) {
+	t.Run("should copy device info, network and disks", func(t *testing.T) {
+		juan := &dc.DefewayJuan{
+			DeviceInfo: &dc.DefewayDeviceInfo{SerialNumber: "AA000000000000"},
+			EnvLoad:    &dc.DefewayEnvLoad{Network: &dc.DefewayNetwork{MAC: "00:11:22:33:44:55"}},
+			HDD:        &dc.DefewayHDD{Disks: []dc.HDDMeta{{Model: "Seagate", Status: 5}}},
+		}
+
+		device := NewDevice("192.168.1.1:80", juan)
+
+		require.Equal(t, "192.168.1.1:80", device.Address)
+		require.Equal(t, "AA000000000000", device.DeviceInfo.SerialNumber)
+		require.Equal(t, "00:11:22:33:44:55", device.Network.MAC)
+		require.Len(t, device.Disks, 1)
+	})
+}
+
+func TestDevice_Key(t *testing.T) {
+	t.Run("should return serial number", func(t *testing.T) {
+		device := Device{
+			Address:    "192.168.1.1:80",
+			DeviceInfo: &dc.DefewayDeviceInfo{SerialNumber: "AA000000000000"},
+			Network:    &dc.DefewayNetwork{MAC: "00:11:22:33:44:55"},
+		}
+
+		require.Equal(t, "AA000000000000", device.Key())
+	})
+
+	t.Run("should return MAC address when serial number is empty", func(t *testing.T) {
+		device := Device{
+			Address:    "192.168.1.1:80",
+			DeviceInfo: &dc.DefewayDeviceInfo{},
+			Network:    &dc.DefewayNetwork{MAC: "00:11:22:33:44:55"},
+		}
+
+		require.Equal(t, "00:11:22:33:44:55", device.Key())
+	})
+
+	t.Run("should return address when device info is missing", func(t *testing.T) {
+		device := Device{Address: "192.168.1.1:80"}
+
+		require.Equal(t, "192.168.1.1:80", device.Key())
+	})
+}
+
+func TestInventory_SaveLoad(t *testing.T) {
+	t.Run("should load saved inventory", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "inventory")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		inv := &Inventory{}
+		inv.Add(Device{Address: "192.168.1.2:80"})
+		inv.Add(Device{Address: "192.168.1.1:80"})
+		inv.Sort()
+
+		fp := path.Join(dir, FileName)
+		require.NoError(t, inv.Save(fp))
+
+		loaded, err := Load(fp)
+
+		require.NoError(t, err)
+		require.Len(t, loaded.Devices, 2)
+		require.Equal(t, "192.168.1.1:80", loaded.Devices[0].Address)
+	})
+
+	t.Run("should return error when file does not exist", func(t *testing.T) {
+		_, err := Load("not-existing.json")
+
+		require.Error(t, err)
+	})
+}
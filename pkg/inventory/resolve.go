@@ -0,0 +1,145 @@
+package inventory
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	dc "github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+)
+
+type ResolverConfig struct {
+	Client       dc.DefewayClientConfig
+	Concurrent   int
+	ProbeTimeout time.Duration
+}
+
+// Resolver finds the current address of a device by its serial number or MAC
+// address. It starts with the address known from the inventory and when the
+// device is not there anymore, it rescans the /24 network around it.
+type Resolver struct {
+	config    ResolverConfig
+	inventory *Inventory
+}
+
+func NewResolver(inv *Inventory, config ResolverConfig) *Resolver {
+	if config.Concurrent < 1 {
+		config.Concurrent = 16
+	}
+
+	if config.ProbeTimeout == 0 {
+		config.ProbeTimeout = 500 * time.Millisecond
+	}
+
+	return &Resolver{
+		config:    config,
+		inventory: inv,
+	}
+}
+
+// Resolve returns the current address of the device. The lastAddr is used
+// when the inventory is not available or it does not contain the device.
+func (r *Resolver) Resolve(id, lastAddr string) (string, error) {
+	if r.inventory != nil {
+		if device, ok := r.inventory.Find(id); ok {
+			lastAddr = device.Address
+		}
+	}
+
+	if lastAddr == "" {
+		return "", fmt.Errorf("device %s not found in the inventory", id)
+	}
+
+	if r.verify(lastAddr, id) {
+		return lastAddr, nil
+	}
+
+	log.Printf("Device %s not found at %s, rescanning its network\n", id, lastAddr)
+
+	addr, ok := r.rescan(lastAddr, id)
+	if !ok {
+		return "", fmt.Errorf("device %s not found in the network of %s", id, lastAddr)
+	}
+
+	return addr, nil
+}
+
+func (r *Resolver) verify(addr, id string) bool {
+	cfg := r.config.Client
+	cfg.Address = addr
+
+	juan, err := dc.NewDeviceInfoClient(cfg).Fetch()
+	if err != nil {
+		return false
+	}
+
+	device := NewDevice(addr, juan)
+	return device.Matches(id)
+}
+
+func (r *Resolver) rescan(lastAddr, id string) (string, bool) {
+	host, port, err := net.SplitHostPort(lastAddr)
+	if err != nil {
+		return "", false
+	}
+
+	ip := net.ParseIP(host).To4()
+	if ip == nil {
+		return "", false
+	}
+
+	var wg sync.WaitGroup
+	addrChan := make(chan string)
+	found := make(chan string, 1)
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		defer close(addrChan)
+		for i := 1; i < 255; i++ {
+			addr := net.JoinHostPort(net.IPv4(ip[0], ip[1], ip[2], byte(i)).String(), port)
+			select {
+			case addrChan <- addr:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < r.config.Concurrent; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for addr := range addrChan {
+				if !r.probe(addr) || !r.verify(addr, id) {
+					continue
+				}
+
+				select {
+				case found <- addr:
+				default:
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(found)
+	}()
+
+	addr, ok := <-found
+	return addr, ok
+}
+
+func (r *Resolver) probe(addr string) bool {
+	conn, err := net.DialTimeout("tcp", addr, r.config.ProbeTimeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+
+	return true
+}
@@ -0,0 +1,58 @@
+package inventory
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolver_Resolve(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		juanMarshaled := `
+		<juan ver="" squ="" dir="0" enc="0" errno="0">
+			<envload usr="admin" pwd="p@ssw0rd" type="0" errno="0"><network mac="00:11:22:33:44:55"></network></envload>
+			<devinfo name="NVR" model="CS-580" serialnumber="AA000000000000" camcnt="4"></devinfo>
+		</juan>`
+		rw.Write([]byte(juanMarshaled))
+	}))
+	defer server.Close()
+	serverAddr := server.URL[7:]
+
+	t.Run("should return address from the inventory when device is still there", func(t *testing.T) {
+		inv := &Inventory{Devices: []Device{newTestDevice(serverAddr, "AA000000000000", "1.0", 4)}}
+		r := NewResolver(inv, ResolverConfig{})
+
+		addr, err := r.Resolve("AA000000000000", "")
+
+		require.NoError(t, err)
+		require.Equal(t, serverAddr, addr)
+	})
+
+	t.Run("should resolve by MAC address", func(t *testing.T) {
+		r := NewResolver(nil, ResolverConfig{})
+
+		addr, err := r.Resolve("00-11-22-33-44-55", serverAddr)
+
+		require.NoError(t, err)
+		require.Equal(t, serverAddr, addr)
+	})
+
+	t.Run("should return error when device is not in the inventory", func(t *testing.T) {
+		r := NewResolver(&Inventory{}, ResolverConfig{})
+
+		_, err := r.Resolve("AA000000000000", "")
+
+		require.EqualError(t, err, "device AA000000000000 not found in the inventory")
+	})
+
+	t.Run("should return error when device is not found in the network", func(t *testing.T) {
+		inv := &Inventory{Devices: []Device{newTestDevice(serverAddr, "BB000000000000", "1.0", 4)}}
+		r := NewResolver(inv, ResolverConfig{})
+
+		_, err := r.Resolve("BB000000000000", "")
+
+		require.EqualError(t, err, "device BB000000000000 not found in the network of "+serverAddr)
+	})
+}
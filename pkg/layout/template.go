@@ -0,0 +1,292 @@
+package layout
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	dc "github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+)
+
+// Legacy is the layout of the archives downloaded before the templates were
+// introduced, relative to the output directory.
+const Legacy = "{device}/{date}/{id}-{channel-id}-{type-id}.flv"
+
+// LegacyShort is the layout of the oldest archives, which were named with
+// the recording id only.
+const LegacyShort = "{device}/{date}/{id}.flv"
+
+// LegacyAddress and LegacyAddressShort are the legacy layouts of the
+// archives stored in the <ip>-<port> directories, before the DVRs were
+// identified with the serial number.
+const (
+	LegacyAddress      = "{address}/{date}/{id}-{channel-id}-{type-id}.flv"
+	LegacyAddressShort = "{address}/{date}/{id}.flv"
+)
+
+// Legacies are the legacy layouts, the archives stored with them are always
+// migrated to the current layout.
+var Legacies = []string{Legacy, LegacyShort, LegacyAddress, LegacyAddressShort}
+
+const (
+	defaultDateLayout = "2006-01-02"
+	defaultTimeLayout = "20060102-150405"
+)
+
+// fields are the placeholders of the template, the ones with the time value
+// accept the Go time layout after the colon, like {start:2006/01/02}.
+var fields = map[string]bool{
+	"address":      false,
+	"channel":      false,
+	"channel-id":   false,
+	"channel-name": false,
+	"date":         true,
+	"device":       false,
+	"duration":     false,
+	"end":          true,
+	"id":           false,
+	"name":         false,
+	"serial":       false,
+	"site":         false,
+	"start":        true,
+	"type":         false,
+	"type-id":      false,
+}
+
+// Device holds the values of the placeholders describing the DVR and the
+// search, which are the same for all recordings.
+type Device struct {
+	Address      string // <ip>-<port>
+	ChannelNames map[int]string
+	Date         time.Time // the date of the recordings search
+	Device       string    // serial number, MAC address or address of the DVR
+	Name         string
+	Serial       string
+	Site         string
+}
+
+type part struct {
+	literal string
+	field   string
+	layout  string
+}
+
+// Template is the path of the recording relative to the output directory,
+// like {site}/{channel}/{start:2006/01/02}/{start:150405}-{type}.flv.
+type Template struct {
+	raw   string
+	parts []part
+}
+
+func Parse(s string) (*Template, error) {
+	t := &Template{raw: s}
+
+	for rest := s; rest != ""; {
+		open := strings.IndexByte(rest, '{')
+		if close := strings.IndexByte(rest, '}'); close >= 0 && (open < 0 || close < open) {
+			return nil, fmt.Errorf("unexpected } in template %s", s)
+		}
+
+		if open < 0 {
+			t.parts = append(t.parts, part{literal: rest})
+			break
+		}
+
+		if open > 0 {
+			t.parts = append(t.parts, part{literal: rest[:open]})
+		}
+
+		close := strings.IndexByte(rest[open:], '}')
+		if close < 0 {
+			return nil, fmt.Errorf("unclosed { in template %s", s)
+		}
+
+		p, err := parsePlaceholder(rest[open+1 : open+close])
+		if err != nil {
+			return nil, fmt.Errorf("invalid template %s: %s", s, err)
+		}
+		t.parts = append(t.parts, p)
+
+		rest = rest[open+close+1:]
+	}
+
+	if len(t.parts) == 0 {
+		return nil, fmt.Errorf("empty template")
+	}
+
+	if strings.HasPrefix(s, "/") {
+		return nil, fmt.Errorf("template %s must be relative to the output directory", s)
+	}
+
+	for _, segment := range strings.Split(s, "/") {
+		if segment == ".." {
+			return nil, fmt.Errorf("template %s must not leave the output directory", s)
+		}
+	}
+
+	return t, nil
+}
+
+func parsePlaceholder(s string) (part, error) {
+	name, layout := s, ""
+	if i := strings.IndexByte(s, ':'); i >= 0 {
+		name, layout = s[:i], s[i+1:]
+	}
+
+	isTime, ok := fields[name]
+	if !ok {
+		return part{}, fmt.Errorf("unknown placeholder {%s}, use one of %s", name, strings.Join(fieldNames(), ", "))
+	}
+
+	if layout != "" && !isTime {
+		return part{}, fmt.Errorf("placeholder {%s} does not accept the format", name)
+	}
+
+	if isTime && layout == "" {
+		layout = defaultTimeLayout
+		if name == "date" {
+			layout = defaultDateLayout
+		}
+	}
+
+	return part{field: name, layout: layout}, nil
+}
+
+func fieldNames() []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, "{"+name+"}")
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func (t *Template) String() string {
+	return t.raw
+}
+
+// Render returns the path of the recording. The values of the placeholders
+// cannot add directories, the separators in them are replaced, only the time
+// layouts and the template itself can.
+func (t *Template) Render(d Device, rec dc.RecordingMeta) (string, error) {
+	var sb strings.Builder
+
+	for _, p := range t.parts {
+		if p.field == "" {
+			sb.WriteString(p.literal)
+			continue
+		}
+
+		value, err := p.value(d, rec)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(value)
+	}
+
+	rendered := path.Clean(sb.String())
+	for _, segment := range strings.Split(rendered, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", fmt.Errorf("template %s renders invalid path %s for recording %d", t.raw, sb.String(), rec.RecordingID)
+		}
+	}
+
+	return rendered, nil
+}
+
+// RenderAll returns the paths of the recordings in the same order. It returns
+// error when two recordings have the same path, so one would overwrite the
+// other.
+func (t *Template) RenderAll(d Device, recs []dc.RecordingMeta) ([]string, error) {
+	paths := make([]string, 0, len(recs))
+	owners := make(map[string]uint, len(recs))
+
+	for _, rec := range recs {
+		p, err := t.Render(d, rec)
+		if err != nil {
+			return nil, err
+		}
+
+		// the file systems of Windows and macOS are case insensitive
+		key := strings.ToLower(p)
+		if id, ok := owners[key]; ok && id != rec.RecordingID {
+			return nil, fmt.Errorf("template %s renders the same path %s for recordings %d and %d, add {id} or {start} to the template", t.raw, p, id, rec.RecordingID)
+		}
+		owners[key] = rec.RecordingID
+
+		paths = append(paths, p)
+	}
+
+	return paths, nil
+}
+
+func (p part) value(d Device, rec dc.RecordingMeta) (string, error) {
+	// the DVR reports the times of its clock as UTC timestamps
+	start := time.Unix(int64(rec.StartTimestamp), 0).UTC()
+	end := time.Unix(int64(rec.EndTimestamp), 0).UTC()
+
+	switch p.field {
+	case "date":
+		if d.Date.IsZero() {
+			return start.Format(p.layout), nil
+		}
+		return d.Date.Format(p.layout), nil
+	case "start":
+		return start.Format(p.layout), nil
+	case "end":
+		return end.Format(p.layout), nil
+	case "duration":
+		return end.Sub(start).String(), nil
+	case "id":
+		return strconv.FormatUint(uint64(rec.RecordingID), 10), nil
+	case "channel":
+		return strconv.Itoa(int(rec.ChannelID) + 1), nil
+	case "channel-id":
+		return strconv.Itoa(int(rec.ChannelID)), nil
+	case "channel-name":
+		if name := d.ChannelNames[int(rec.ChannelID)+1]; name != "" {
+			return Sanitize(name), nil
+		}
+		return fmt.Sprintf("ch%d", rec.ChannelID+1), nil
+	case "type":
+		return rec.TypeName(), nil
+	case "type-id":
+		return strconv.Itoa(int(rec.TypeID)), nil
+	}
+
+	value := map[string]string{
+		"address": d.Address,
+		"device":  d.Device,
+		"name":    d.Name,
+		"serial":  d.Serial,
+		"site":    d.Site,
+	}[p.field]
+
+	if value == "" {
+		return "", fmt.Errorf("placeholder {%s} has no value", p.field)
+	}
+
+	return Sanitize(value), nil
+}
+
+// Sanitize replaces the characters which are not allowed in the file names
+// on Linux or Windows, like the colons of the MAC address naming the device
+// directory.
+func Sanitize(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < ' ' {
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(s))
+
+	if s == "." || s == ".." {
+		return strings.Repeat("-", len(s))
+	}
+
+	return s
+}
@@ -0,0 +1,125 @@
+package layout
+
+import (
+	"testing"
+	"time"
+
+	dc "github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("should parse template with placeholders and formats", func(t *testing.T) {
+		tmpl, err := Parse("{site}/{channel}/{start:2006/01/02}/{start:150405}-{type}.flv")
+
+		require.NoError(t, err)
+		require.Equal(t, "{site}/{channel}/{start:2006/01/02}/{start:150405}-{type}.flv", tmpl.String())
+	})
+
+	t.Run("should return error for invalid templates", func(t *testing.T) {
+		for _, s := range []string{
+			"",
+			"{site",
+			"site}",
+			"{unknown}.flv",
+			"{id:2006}.flv",
+			"/archive/{id}.flv",
+			"../{id}.flv",
+		} {
+			_, err := Parse(s)
+			require.Error(t, err, s)
+		}
+	})
+}
+
+func TestTemplate_Render(t *testing.T) {
+	device := Device{
+		Address:      "192.168.1.10-60001",
+		ChannelNames: map[int]string{1: "Main gate"},
+		Date:         time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC),
+		Device:       "AA000000000001",
+		Name:         "NVR",
+		Serial:       "AA000000000001",
+		Site:         "warehouse",
+	}
+	rec := dc.RecordingMeta{
+		RecordingID:    42,
+		ChannelID:      0,
+		TypeID:         2,
+		StartTimestamp: uint64(time.Date(2020, 6, 1, 12, 30, 15, 0, time.UTC).Unix()),
+		EndTimestamp:   uint64(time.Date(2020, 6, 1, 12, 40, 15, 0, time.UTC).Unix()),
+	}
+
+	t.Run("should render the legacy layout", func(t *testing.T) {
+		tmpl, err := Parse(Legacy)
+		require.NoError(t, err)
+
+		p, err := tmpl.Render(device, rec)
+
+		require.NoError(t, err)
+		require.Equal(t, "AA000000000001/2020-06-01/42-0-2.flv", p)
+	})
+
+	t.Run("should render all placeholders", func(t *testing.T) {
+		tmpl, err := Parse("{site}/{channel}-{channel-name}/{start:2006/01/02}/{start:150405}-{end}-{duration}-{type}-{name}-{address}.flv")
+		require.NoError(t, err)
+
+		p, err := tmpl.Render(device, rec)
+
+		require.NoError(t, err)
+		require.Equal(t, "warehouse/1-Main gate/2020/06/01/123015-20200601-124015-10m0s-motion-NVR-192.168.1.10-60001.flv", p)
+	})
+
+	t.Run("should not add directories with placeholder values", func(t *testing.T) {
+		tmpl, err := Parse("{site}/{id}.flv")
+		require.NoError(t, err)
+
+		p, err := tmpl.Render(Device{Site: "../north/gate"}, rec)
+
+		require.NoError(t, err)
+		require.Equal(t, "..-north-gate/42.flv", p)
+	})
+
+	t.Run("should return error for placeholder without value", func(t *testing.T) {
+		tmpl, err := Parse("{serial}/{id}.flv")
+		require.NoError(t, err)
+
+		_, err = tmpl.Render(Device{}, rec)
+
+		require.Error(t, err)
+	})
+}
+
+func TestTemplate_RenderAll(t *testing.T) {
+	recs := []dc.RecordingMeta{
+		{RecordingID: 1, ChannelID: 0, StartTimestamp: 1591014615},
+		{RecordingID: 2, ChannelID: 1, StartTimestamp: 1591014615},
+	}
+
+	t.Run("should render unique paths", func(t *testing.T) {
+		tmpl, err := Parse("{channel}/{start}.flv")
+		require.NoError(t, err)
+
+		paths, err := tmpl.RenderAll(Device{}, recs)
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"1/20200601-123015.flv", "2/20200601-123015.flv"}, paths)
+	})
+
+	t.Run("should detect collisions", func(t *testing.T) {
+		tmpl, err := Parse("{start}.flv")
+		require.NoError(t, err)
+
+		_, err = tmpl.RenderAll(Device{}, recs)
+
+		require.Error(t, err)
+	})
+}
+
+func TestSanitize(t *testing.T) {
+	t.Run("should replace the characters not allowed in the file names", func(t *testing.T) {
+		require.Equal(t, "00-11-22-33-44-55", Sanitize("00:11:22:33:44:55"))
+		require.Equal(t, "a-b-c--d", Sanitize(` a/b\c*?d `))
+		require.Equal(t, "--", Sanitize(".."))
+	})
+}
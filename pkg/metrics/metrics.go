@@ -0,0 +1,158 @@
+// Package metrics writes the metrics of the DVRs in the Prometheus text
+// format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	dc "github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/crabtree/defeway-toolbox/pkg/inventory"
+)
+
+// Device holds the metrics of the DVR collected by the poll. Only the Device
+// and Up are set when the DVR is down.
+type Device struct {
+	Device  inventory.Device
+	Up      bool
+	Latency time.Duration
+	Juan    *dc.DefewayJuan
+	Latest  map[uint16]uint64 // channel id - end timestamp of the latest recording
+}
+
+// Write writes the metrics of the DVRs and the time of the poll, which is
+// not written when it is zero.
+func Write(w io.Writer, devices []Device, polledAt time.Time) error {
+	return writeFamilies(w, newFamilies(devices, polledAt))
+}
+
+type label struct {
+	name  string
+	value string
+}
+
+type sample struct {
+	labels []label
+	value  float64
+}
+
+type family struct {
+	name    string
+	help    string
+	typ     string
+	samples []sample
+}
+
+func (f *family) add(value float64, labels ...label) {
+	f.samples = append(f.samples, sample{labels: labels, value: value})
+}
+
+func newFamilies(devices []Device, polledAt time.Time) []*family {
+	up := &family{name: "defeway_up", typ: "gauge",
+		help: "Whether the DVR responded to the device info request."}
+	latency := &family{name: "defeway_response_seconds", typ: "gauge",
+		help: "Duration of the device info request."}
+	info := &family{name: "defeway_device_info", typ: "gauge",
+		help: "Model and firmware of the DVR."}
+	cameras := &family{name: "defeway_camera_count", typ: "gauge",
+		help: "Number of cameras reported by the DVR."}
+	diskCapacity := &family{name: "defeway_disk_capacity", typ: "gauge",
+		help: "Capacity of the disk in the units reported by the DVR."}
+	diskUsed := &family{name: "defeway_disk_used", typ: "gauge",
+		help: "Used space of the disk in the units reported by the DVR."}
+	diskStatus := &family{name: "defeway_disk_status", typ: "gauge",
+		help: "Status code of the disk: 3 - DB error, 4 - formatted, 5 - OK, other - unformatted."}
+	latest := &family{name: "defeway_latest_recording_timestamp_seconds", typ: "gauge",
+		help: "End timestamp of the latest recording of the channel."}
+	lastPoll := &family{name: "defeway_exporter_last_poll_timestamp_seconds", typ: "gauge",
+		help: "Time of the last poll of the DVRs."}
+
+	for _, m := range devices {
+		dl := []label{{"device", m.Device.Key()}, {"address", m.Device.Address}}
+
+		if !m.Up {
+			up.add(0, dl...)
+			continue
+		}
+		up.add(1, dl...)
+		latency.add(m.Latency.Seconds(), dl...)
+
+		if di := m.Juan.DeviceInfo; di != nil {
+			info.add(1, append(dl,
+				label{"name", di.Name},
+				label{"model", di.Model},
+				label{"hwver", di.HWVer},
+				label{"swver", di.SWVer},
+				label{"reldatetime", di.RelDateTime})...)
+			cameras.add(float64(di.CamCount), dl...)
+		}
+
+		if m.Juan.HDD != nil {
+			for i, disk := range m.Juan.HDD.Disks {
+				ll := append(dl[:len(dl):len(dl)], label{"disk", strconv.Itoa(i)}, label{"model", disk.Model})
+				diskCapacity.add(float64(disk.Capacity), ll...)
+				diskUsed.add(float64(disk.Used), ll...)
+				diskStatus.add(float64(disk.Status), ll...)
+			}
+		}
+
+		channels := make([]int, 0, len(m.Latest))
+		for ch := range m.Latest {
+			channels = append(channels, int(ch))
+		}
+		sort.Ints(channels)
+		for _, ch := range channels {
+			ll := append(dl[:len(dl):len(dl)], label{"channel", strconv.Itoa(ch)})
+			latest.add(float64(m.Latest[uint16(ch)]), ll...)
+		}
+	}
+
+	if !polledAt.IsZero() {
+		lastPoll.add(float64(polledAt.Unix()))
+	}
+
+	return []*family{up, latency, info, cameras, diskCapacity, diskUsed, diskStatus, latest, lastPoll}
+}
+
+func writeFamilies(w io.Writer, families []*family) error {
+	for _, f := range families {
+		if len(f.samples) == 0 {
+			continue
+		}
+
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.typ); err != nil {
+			return err
+		}
+
+		for _, s := range f.samples {
+			if _, err := fmt.Fprintf(w, "%s%s %s\n", f.name, formatLabels(s.labels), formatValue(s.value)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func formatLabels(labels []label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	parts := make([]string, 0, len(labels))
+	for _, l := range labels {
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, l.name, labelEscaper.Replace(l.value)))
+	}
+
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
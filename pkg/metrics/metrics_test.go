@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	dc "github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/crabtree/defeway-toolbox/pkg/inventory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFamilies(t *testing.T) {
+	up := Device{
+		Device:  inventory.Device{Address: "192.168.1.10:80"},
+		Up:      true,
+		Latency: 250 * time.Millisecond,
+		Juan: &dc.DefewayJuan{
+			DeviceInfo: &dc.DefewayDeviceInfo{Name: "NVR", Model: "N4", SerialNumber: "AA000000000001", CamCount: 4},
+			HDD:        &dc.DefewayHDD{Disks: []dc.HDDMeta{{Model: "WD", Capacity: 1000, Used: 250, Status: dc.HDDStatusOK}}},
+		},
+		Latest: map[uint16]uint64{2: 1634900000, 0: 1634890000},
+	}
+	down := Device{
+		Device: inventory.Device{Address: "192.168.1.11:80"},
+	}
+
+	find := func(families []*family, name string) *family {
+		for _, f := range families {
+			if f.name == name {
+				return f
+			}
+		}
+
+		return nil
+	}
+
+	t.Run("should add the samples of the DVR which is up", func(t *testing.T) {
+		families := newFamilies([]Device{up}, time.Time{})
+
+		require.Equal(t, []sample{{labels: []label{{"device", "192.168.1.10:80"}, {"address", "192.168.1.10:80"}}, value: 1}}, find(families, "defeway_up").samples)
+		require.Equal(t, 0.25, find(families, "defeway_response_seconds").samples[0].value)
+		require.Equal(t, float64(4), find(families, "defeway_camera_count").samples[0].value)
+		require.Equal(t, float64(250), find(families, "defeway_disk_used").samples[0].value)
+		require.Equal(t, float64(dc.HDDStatusOK), find(families, "defeway_disk_status").samples[0].value)
+		require.Empty(t, find(families, "defeway_exporter_last_poll_timestamp_seconds").samples)
+	})
+
+	t.Run("should add the latest recordings ordered by the channel", func(t *testing.T) {
+		latest := find(newFamilies([]Device{up}, time.Time{}), "defeway_latest_recording_timestamp_seconds")
+
+		require.Len(t, latest.samples, 2)
+		require.Equal(t, label{"channel", "0"}, latest.samples[0].labels[2])
+		require.Equal(t, float64(1634890000), latest.samples[0].value)
+		require.Equal(t, label{"channel", "2"}, latest.samples[1].labels[2])
+		require.Equal(t, float64(1634900000), latest.samples[1].value)
+	})
+
+	t.Run("should add only the up sample of the DVR which is down", func(t *testing.T) {
+		families := newFamilies([]Device{down}, time.Unix(1634900000, 0))
+
+		for _, f := range families {
+			switch f.name {
+			case "defeway_up":
+				require.Equal(t, float64(0), f.samples[0].value)
+			case "defeway_exporter_last_poll_timestamp_seconds":
+				require.Equal(t, []sample{{value: 1634900000}}, f.samples)
+			default:
+				require.Empty(t, f.samples, f.name)
+			}
+		}
+	})
+}
+
+func TestWrite(t *testing.T) {
+	t.Run("should write the families in the text format", func(t *testing.T) {
+		devices := []Device{{
+			Device: inventory.Device{Address: "192.168.1.10:80"},
+			Up:     true,
+			Juan: &dc.DefewayJuan{
+				DeviceInfo: &dc.DefewayDeviceInfo{Name: `Gate "A"`, Model: "N4", CamCount: 2},
+			},
+			Latency: 500 * time.Millisecond,
+			Latest:  map[uint16]uint64{1: 1634900000},
+		}}
+
+		var buf bytes.Buffer
+		err := Write(&buf, devices, time.Unix(1634900060, 0))
+
+		require.NoError(t, err)
+		require.Equal(t, `# HELP defeway_up Whether the DVR responded to the device info request.
+# TYPE defeway_up gauge
+defeway_up{device="192.168.1.10:80",address="192.168.1.10:80"} 1
+# HELP defeway_response_seconds Duration of the device info request.
+# TYPE defeway_response_seconds gauge
+defeway_response_seconds{device="192.168.1.10:80",address="192.168.1.10:80"} 0.5
+# HELP defeway_device_info Model and firmware of the DVR.
+# TYPE defeway_device_info gauge
+defeway_device_info{device="192.168.1.10:80",address="192.168.1.10:80",name="Gate \"A\"",model="N4",hwver="",swver="",reldatetime=""} 1
+# HELP defeway_camera_count Number of cameras reported by the DVR.
+# TYPE defeway_camera_count gauge
+defeway_camera_count{device="192.168.1.10:80",address="192.168.1.10:80"} 2
+# HELP defeway_latest_recording_timestamp_seconds End timestamp of the latest recording of the channel.
+# TYPE defeway_latest_recording_timestamp_seconds gauge
+defeway_latest_recording_timestamp_seconds{device="192.168.1.10:80",address="192.168.1.10:80",channel="1"} 1.6349e+09
+# HELP defeway_exporter_last_poll_timestamp_seconds Time of the last poll of the DVRs.
+# TYPE defeway_exporter_last_poll_timestamp_seconds gauge
+defeway_exporter_last_poll_timestamp_seconds 1.63490006e+09
+`, buf.String())
+	})
+
+	t.Run("should escape the label values", func(t *testing.T) {
+		require.Equal(t, `{name="a\\b\"c\nd"}`, formatLabels([]label{{"name", "a\\b\"c\nd"}}))
+		require.Equal(t, "", formatLabels(nil))
+	})
+}
@@ -0,0 +1,204 @@
+package mjpeg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	aviFlagHasIndex  = 0x10
+	aviFlagKeyFrame  = 0x10
+	frameChunkID     = "00dc"
+	headerListsSize  = 192
+	moviListOffset   = 12 + 8 + headerListsSize
+	maxRIFFChunkSize = 1<<32 - 1
+)
+
+type indexEntry struct {
+	offset uint32
+	size   uint32
+}
+
+// Writer writes the Motion-JPEG AVI file. Every frame is the complete JPEG
+// image. The header is written with zero counters first and rewritten on
+// Close, when the number and sizes of the frames are known.
+type Writer struct {
+	w            io.WriteSeeker
+	width        int
+	height       int
+	fps          int
+	index        []indexEntry
+	moviSize     uint32
+	maxFrameSize uint32
+}
+
+func NewWriter(w io.WriteSeeker, width, height, fps int) (*Writer, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("invalid frame size %dx%d", width, height)
+	}
+
+	if fps <= 0 {
+		return nil, fmt.Errorf("invalid frame rate %d", fps)
+	}
+
+	aw := &Writer{
+		w:      w,
+		width:  width,
+		height: height,
+		fps:    fps,
+	}
+
+	if _, err := w.Write(aw.header()); err != nil {
+		return nil, err
+	}
+
+	return aw, nil
+}
+
+// Frames returns the number of written frames.
+func (aw *Writer) Frames() int {
+	return len(aw.index)
+}
+
+func (aw *Writer) WriteFrame(frame []byte) error {
+	chunkSize := 8 + uint32(len(frame)) + uint32(len(frame)%2)
+	if uint64(aw.moviSize)+uint64(chunkSize)+uint64(16*(len(aw.index)+1))+moviListOffset >= maxRIFFChunkSize {
+		return fmt.Errorf("the AVI file exceeds 4 GB")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(frameChunkID)
+	writeUint32(&buf, uint32(len(frame)))
+	buf.Write(frame)
+	if len(frame)%2 == 1 {
+		buf.WriteByte(0)
+	}
+
+	if _, err := aw.w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+
+	// the offsets in the index are relative to the "movi" list type
+	aw.index = append(aw.index, indexEntry{offset: 4 + aw.moviSize, size: uint32(len(frame))})
+	aw.moviSize += chunkSize
+	if uint32(len(frame)) > aw.maxFrameSize {
+		aw.maxFrameSize = uint32(len(frame))
+	}
+
+	return nil
+}
+
+// Close writes the index and updates the header. It does not close the
+// underlying writer.
+func (aw *Writer) Close() error {
+	var buf bytes.Buffer
+	buf.WriteString("idx1")
+	writeUint32(&buf, uint32(16*len(aw.index)))
+	for _, entry := range aw.index {
+		buf.WriteString(frameChunkID)
+		writeUint32(&buf, aviFlagKeyFrame)
+		writeUint32(&buf, entry.offset)
+		writeUint32(&buf, entry.size)
+	}
+
+	if _, err := aw.w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+
+	if _, err := aw.w.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	if _, err := aw.w.Write(aw.header()); err != nil {
+		return err
+	}
+
+	_, err := aw.w.Seek(0, io.SeekEnd)
+
+	return err
+}
+
+// header returns the RIFF header, the hdrl list and the beginning of the
+// movi list.
+func (aw *Writer) header() []byte {
+	frames := uint32(len(aw.index))
+	width, height := uint32(aw.width), uint32(aw.height)
+	riffSize := 4 + 8 + headerListsSize + 8 + 4 + aw.moviSize + 8 + 16*frames
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	writeUint32(&buf, riffSize)
+	buf.WriteString("AVI ")
+
+	buf.WriteString("LIST")
+	writeUint32(&buf, headerListsSize)
+	buf.WriteString("hdrl")
+
+	buf.WriteString("avih")
+	writeUint32(&buf, 56)
+	writeUint32(&buf, uint32(1000000/aw.fps))         // microseconds per frame
+	writeUint32(&buf, aw.maxFrameSize*uint32(aw.fps)) // max bytes per second
+	writeUint32(&buf, 0)                              // padding granularity
+	writeUint32(&buf, aviFlagHasIndex)                // flags
+	writeUint32(&buf, frames)                         // total frames
+	writeUint32(&buf, 0)                              // initial frames
+	writeUint32(&buf, 1)                              // streams
+	writeUint32(&buf, aw.maxFrameSize)                // suggested buffer size
+	writeUint32(&buf, width)
+	writeUint32(&buf, height)
+	buf.Write(make([]byte, 16)) // reserved
+
+	buf.WriteString("LIST")
+	writeUint32(&buf, 116)
+	buf.WriteString("strl")
+
+	buf.WriteString("strh")
+	writeUint32(&buf, 56)
+	buf.WriteString("vids")
+	buf.WriteString("MJPG")
+	writeUint32(&buf, 0)              // flags
+	writeUint32(&buf, 0)              // priority and language
+	writeUint32(&buf, 0)              // initial frames
+	writeUint32(&buf, 1)              // scale
+	writeUint32(&buf, uint32(aw.fps)) // rate
+	writeUint32(&buf, 0)              // start
+	writeUint32(&buf, frames)         // length
+	writeUint32(&buf, aw.maxFrameSize)
+	writeUint32(&buf, 0xFFFFFFFF) // quality, -1 means default
+	writeUint32(&buf, 0)          // sample size
+	writeUint16(&buf, 0)          // frame rectangle
+	writeUint16(&buf, 0)
+	writeUint16(&buf, uint16(width))
+	writeUint16(&buf, uint16(height))
+
+	buf.WriteString("strf")
+	writeUint32(&buf, 40)
+	writeUint32(&buf, 40) // size of the bitmap info header
+	writeUint32(&buf, width)
+	writeUint32(&buf, height)
+	writeUint16(&buf, 1)  // planes
+	writeUint16(&buf, 24) // bit count
+	buf.WriteString("MJPG")
+	writeUint32(&buf, width*height*3) // image size
+	buf.Write(make([]byte, 16))       // resolution and colors
+
+	buf.WriteString("LIST")
+	writeUint32(&buf, 4+aw.moviSize)
+	buf.WriteString("movi")
+
+	return buf.Bytes()
+}
+
+func writeUint32(buf *bytes.Buffer, v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	buf.Write(b[:])
+}
+
+func writeUint16(buf *bytes.Buffer, v uint16) {
+	var b [2]byte
+	binary.LittleEndian.PutUint16(b[:], v)
+	buf.Write(b[:])
+}
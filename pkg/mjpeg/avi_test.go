@@ -0,0 +1,58 @@
+package mjpeg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter(t *testing.T) {
+	t.Run("should write AVI with frames and index", func(t *testing.T) {
+		f, err := ioutil.TempFile("", "mjpeg")
+		require.NoError(t, err)
+		defer os.Remove(f.Name())
+		defer f.Close()
+
+		w, err := NewWriter(f, 352, 288, 25)
+		require.NoError(t, err)
+		require.NoError(t, w.WriteFrame([]byte("frame1")))
+		require.NoError(t, w.WriteFrame([]byte("frame-2")))
+		require.NoError(t, w.Close())
+		require.Equal(t, 2, w.Frames())
+
+		data, err := ioutil.ReadFile(f.Name())
+		require.NoError(t, err)
+
+		require.Equal(t, "RIFF", string(data[0:4]))
+		require.Equal(t, uint32(len(data)-8), binary.LittleEndian.Uint32(data[4:8]))
+		require.Equal(t, "AVI ", string(data[8:12]))
+		require.Equal(t, "avih", string(data[24:28]))
+		require.Equal(t, uint32(40000), binary.LittleEndian.Uint32(data[32:36]))
+		require.Equal(t, uint32(2), binary.LittleEndian.Uint32(data[48:52]))
+		require.Equal(t, uint32(352), binary.LittleEndian.Uint32(data[64:68]))
+		require.Equal(t, uint32(288), binary.LittleEndian.Uint32(data[68:72]))
+
+		movi := bytes.Index(data, []byte("movi"))
+		require.Equal(t, moviListOffset+8, movi)
+		require.Equal(t, "00dc", string(data[movi+4:movi+8]))
+		require.Equal(t, "frame1", string(data[movi+12:movi+18]))
+		// the odd sized frame is padded
+		require.Equal(t, "frame-2", string(data[movi+26:movi+33]))
+
+		idx := bytes.Index(data, []byte("idx1"))
+		require.Equal(t, len(data)-8-32, idx)
+		require.Equal(t, uint32(4), binary.LittleEndian.Uint32(data[idx+16:idx+20]))
+		require.Equal(t, uint32(18), binary.LittleEndian.Uint32(data[idx+32:idx+36]))
+		require.Equal(t, uint32(7), binary.LittleEndian.Uint32(data[idx+36:idx+40]))
+	})
+
+	t.Run("should return error for invalid frame size", func(t *testing.T) {
+		_, err := NewWriter(nil, 0, 288, 25)
+
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,234 @@
+package notify
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultMQTTTopic   = "defeway/{device}/{type}"
+	DefaultMQTTTimeout = 10 * time.Second
+
+	mqttConnect    = 0x10
+	mqttConnAck    = 0x20
+	mqttPublishQoS = 0x32 // PUBLISH with QoS 1
+	mqttPubAck     = 0x40
+	mqttDisconnect = 0xe0
+)
+
+// MQTT publishes the notifications as JSON to the MQTT 3.1.1 broker with
+// QoS 1. The broker is given as host:port, tcp://host:port or
+// tls://host:port. The topic accepts the {device}, {channel} and {type}
+// placeholders.
+type MQTT struct {
+	Broker   string
+	Topic    string
+	ClientID string
+	Username string
+	Password string
+	Timeout  time.Duration
+}
+
+func (m *MQTT) String() string {
+	return "mqtt://" + m.Broker
+}
+
+func (m *MQTT) topic(n Notification) string {
+	topic := m.Topic
+	if topic == "" {
+		topic = DefaultMQTTTopic
+	}
+
+	return strings.NewReplacer(
+		"{device}", n.Device,
+		"{channel}", strconv.Itoa(n.Channel),
+		"{type}", n.Type,
+	).Replace(topic)
+}
+
+func (m *MQTT) timeout() time.Duration {
+	if m.Timeout == 0 {
+		return DefaultMQTTTimeout
+	}
+
+	return m.Timeout
+}
+
+func (m *MQTT) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: m.timeout()}
+
+	switch {
+	case strings.HasPrefix(m.Broker, "tls://"), strings.HasPrefix(m.Broker, "mqtts://"):
+		addr := m.Broker[strings.Index(m.Broker, "://")+3:]
+		return tls.DialWithDialer(dialer, "tcp", addr, nil)
+	case strings.HasPrefix(m.Broker, "tcp://"), strings.HasPrefix(m.Broker, "mqtt://"):
+		addr := m.Broker[strings.Index(m.Broker, "://")+3:]
+		return dialer.Dial("tcp", addr)
+	}
+
+	return dialer.Dial("tcp", m.Broker)
+}
+
+// Notify connects to the broker, publishes the notification and waits for
+// its acknowledgement. The alarms are rare, so the connection is not kept.
+func (m *MQTT) Notify(n Notification) error {
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	conn, err := m.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(m.timeout())); err != nil {
+		return err
+	}
+
+	r := bufio.NewReader(conn)
+
+	if _, err := conn.Write(m.connectPacket()); err != nil {
+		return err
+	}
+
+	header, body, err := readPacket(r)
+	if err != nil {
+		return fmt.Errorf("cannot read MQTT CONNACK: %s", err)
+	}
+	if header != mqttConnAck || len(body) != 2 {
+		return fmt.Errorf("unexpected MQTT packet 0x%02x instead of CONNACK", header)
+	}
+	if body[1] != 0 {
+		return fmt.Errorf("MQTT connection refused: %s", connAckReason(body[1]))
+	}
+
+	const packetID = 1
+	var publish []byte
+	publish = appendString(publish, m.topic(n))
+	publish = append(publish, byte(packetID>>8), byte(packetID&0xff))
+	publish = append(publish, payload...)
+
+	if _, err := conn.Write(packet(mqttPublishQoS, publish)); err != nil {
+		return err
+	}
+
+	header, body, err = readPacket(r)
+	if err != nil {
+		return fmt.Errorf("cannot read MQTT PUBACK: %s", err)
+	}
+	if header != mqttPubAck || len(body) != 2 || int(body[0])<<8|int(body[1]) != packetID {
+		return fmt.Errorf("unexpected MQTT packet 0x%02x instead of PUBACK", header)
+	}
+
+	_, err = conn.Write(packet(mqttDisconnect, nil))
+
+	return err
+}
+
+func (m *MQTT) connectPacket() []byte {
+	clientID := m.ClientID
+	if clientID == "" {
+		clientID = fmt.Sprintf("defeway-%d", time.Now().UnixNano())
+	}
+
+	flags := byte(0x02) // clean session
+	if m.Username != "" {
+		flags |= 0x80
+		if m.Password != "" {
+			flags |= 0x40
+		}
+	}
+
+	var body []byte
+	body = appendString(body, "MQTT")
+	body = append(body, 4, flags, 0, 60) // protocol level 3.1.1, keep alive 60s
+	body = appendString(body, clientID)
+	if m.Username != "" {
+		body = appendString(body, m.Username)
+		if m.Password != "" {
+			body = appendString(body, m.Password)
+		}
+	}
+
+	return packet(mqttConnect, body)
+}
+
+func connAckReason(code byte) string {
+	reasons := map[byte]string{
+		1: "unacceptable protocol version",
+		2: "identifier rejected",
+		3: "server unavailable",
+		4: "bad user name or password",
+		5: "not authorized",
+	}
+	if reason, ok := reasons[code]; ok {
+		return reason
+	}
+
+	return fmt.Sprintf("code %d", code)
+}
+
+func appendString(b []byte, s string) []byte {
+	b = append(b, byte(len(s)>>8), byte(len(s)))
+	return append(b, s...)
+}
+
+// packet returns the control packet with the remaining length encoded as
+// the variable length integer.
+func packet(header byte, body []byte) []byte {
+	p := []byte{header}
+	length := len(body)
+	for {
+		digit := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			digit |= 0x80
+		}
+		p = append(p, digit)
+		if length == 0 {
+			break
+		}
+	}
+
+	return append(p, body...)
+}
+
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		digit, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		if i == 3 && digit&0x80 != 0 {
+			return 0, nil, fmt.Errorf("invalid remaining length")
+		}
+
+		length += int(digit&0x7f) * multiplier
+		multiplier *= 128
+		if digit&0x80 == 0 {
+			break
+		}
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+
+	return header, body, nil
+}
@@ -0,0 +1,110 @@
+package notify
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type published struct {
+	connect []byte
+	topic   string
+	payload []byte
+}
+
+// fakeBroker accepts one connection and acknowledges the connection with
+// the code and the published message.
+func fakeBroker(t *testing.T, code byte) (string, <-chan published) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	result := make(chan published, 1)
+	go func() {
+		defer l.Close()
+		defer close(result)
+
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+
+		var p published
+		header, body, err := readPacket(r)
+		if err != nil || header != mqttConnect {
+			return
+		}
+		p.connect = body
+		conn.Write([]byte{mqttConnAck, 2, 0, code})
+		if code != 0 {
+			result <- p
+			return
+		}
+
+		header, body, err = readPacket(r)
+		if err != nil || header != mqttPublishQoS {
+			return
+		}
+		topicLen := int(body[0])<<8 | int(body[1])
+		p.topic = string(body[2 : 2+topicLen])
+		id := body[2+topicLen : 4+topicLen]
+		p.payload = body[4+topicLen:]
+		conn.Write([]byte{mqttPubAck, 2, id[0], id[1]})
+
+		if header, _, err := readPacket(r); err == nil && header == mqttDisconnect {
+			result <- p
+		}
+	}()
+
+	return l.Addr().String(), result
+}
+
+func TestMQTT(t *testing.T) {
+	t.Run("should publish notification", func(t *testing.T) {
+		addr, result := fakeBroker(t, 0)
+		m := &MQTT{Broker: "tcp://" + addr, Username: "user", Password: "pass", ClientID: "test", Timeout: time.Second}
+
+		n := testNotification()
+		n.Snapshot = make([]byte, 200)
+		require.NoError(t, m.Notify(n))
+
+		p := <-result
+		require.Equal(t, "defeway/AA000000000001/alarm", p.topic)
+		require.Contains(t, string(p.connect), "MQTT")
+		require.Contains(t, string(p.connect), "user")
+
+		var decoded Notification
+		require.NoError(t, json.Unmarshal(p.payload, &decoded))
+		require.Equal(t, 7, int(decoded.Recording.RecordingID))
+		require.Len(t, decoded.Snapshot, 200)
+	})
+
+	t.Run("should return refused connection", func(t *testing.T) {
+		addr, result := fakeBroker(t, 5)
+		m := &MQTT{Broker: addr, Topic: "cctv/{channel}", Timeout: time.Second}
+
+		err := m.Notify(testNotification())
+
+		require.EqualError(t, err, "MQTT connection refused: not authorized")
+		<-result
+	})
+}
+
+func TestPacket(t *testing.T) {
+	t.Run("should encode remaining length", func(t *testing.T) {
+		p := packet(mqttPublishQoS, make([]byte, 321))
+
+		require.Equal(t, []byte{mqttPublishQoS, 0xc1, 0x02}, p[:3])
+
+		header, body, err := readPacket(bufio.NewReader(bytes.NewReader(p)))
+		require.NoError(t, err)
+		require.Equal(t, byte(mqttPublishQoS), header)
+		require.Len(t, body, 321)
+	})
+}
@@ -0,0 +1,38 @@
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// NDJSON writes the notifications as the JSON lines, like to the standard
+// output read by the other program. The snapshots are omitted.
+type NDJSON struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewNDJSON(w io.Writer) *NDJSON {
+	return &NDJSON{w: w}
+}
+
+func (nd *NDJSON) String() string {
+	return "ndjson"
+}
+
+func (nd *NDJSON) Notify(n Notification) error {
+	n.Snapshot = nil
+
+	data, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	nd.mu.Lock()
+	defer nd.mu.Unlock()
+
+	_, err = nd.w.Write(append(data, '\n'))
+
+	return err
+}
@@ -0,0 +1,68 @@
+package notify
+
+import (
+	"fmt"
+	"time"
+
+	dc "github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+)
+
+// TimeLayout formats the times of the DVR clock, which has no time zone.
+const TimeLayout = "2006-01-02T15:04:05"
+
+// Notification describes the new recording, like the alarm or the motion
+// detected by the DVR.
+type Notification struct {
+	Time          time.Time
+	Device        string
+	Address       string
+	Channel       int
+	ChannelName   string `json:",omitempty"`
+	Type          string
+	Start         string
+	End           string
+	Recording     dc.RecordingMeta
+	Snapshot      []byte `json:",omitempty"`
+	SnapshotError string `json:",omitempty"`
+}
+
+// New returns the notification of the recording of the device.
+func New(device, address string, rec dc.RecordingMeta, channelNames map[int]string) Notification {
+	// the DVR reports the times of its clock as UTC timestamps
+	start := time.Unix(int64(rec.StartTimestamp), 0).UTC()
+	end := time.Unix(int64(rec.EndTimestamp), 0).UTC()
+	channel := int(rec.ChannelID) + 1
+
+	return Notification{
+		Time:        time.Now(),
+		Device:      device,
+		Address:     address,
+		Channel:     channel,
+		ChannelName: channelNames[channel],
+		Type:        rec.TypeName(),
+		Start:       start.Format(TimeLayout),
+		End:         end.Format(TimeLayout),
+		Recording:   rec,
+	}
+}
+
+// Key identifies the recording across the polls of the DVR.
+func (n *Notification) Key() string {
+	return fmt.Sprintf("%s/%d/%d/%d", n.Device, n.Channel, n.Recording.TypeID, n.Recording.StartTimestamp)
+}
+
+// Subject returns the one line summary of the notification.
+func (n *Notification) Subject() string {
+	channel := fmt.Sprintf("channel %d", n.Channel)
+	if n.ChannelName != "" {
+		channel = fmt.Sprintf("channel %d (%s)", n.Channel, n.ChannelName)
+	}
+
+	return fmt.Sprintf("%s on %s %s at %s", n.Type, n.Device, channel, n.Start)
+}
+
+// Notifier delivers the notifications.
+type Notifier interface {
+	Notify(n Notification) error
+	String() string
+}
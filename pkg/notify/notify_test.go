@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	dc "github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/crabtree/defeway-toolbox/pkg/hooks"
+	"github.com/stretchr/testify/require"
+)
+
+func testNotification() Notification {
+	return New("AA000000000001", "192.168.1.10:60001", dc.RecordingMeta{
+		RecordingID:    7,
+		ChannelID:      0,
+		TypeID:         4,
+		StartTimestamp: 1577872800,
+		EndTimestamp:   1577873400,
+	}, map[int]string{1: "Gate"})
+}
+
+func TestNew(t *testing.T) {
+	t.Run("should describe recording", func(t *testing.T) {
+		n := testNotification()
+
+		require.Equal(t, 1, n.Channel)
+		require.Equal(t, "Gate", n.ChannelName)
+		require.Equal(t, "alarm", n.Type)
+		require.Equal(t, "2020-01-01T10:00:00", n.Start)
+		require.Equal(t, "2020-01-01T10:10:00", n.End)
+		require.Equal(t, "AA000000000001/1/4/1577872800", n.Key())
+		require.Equal(t, "alarm on AA000000000001 channel 1 (Gate) at 2020-01-01T10:00:00", n.Subject())
+	})
+}
+
+func TestNDJSON(t *testing.T) {
+	t.Run("should write notification line without snapshot", func(t *testing.T) {
+		var buf bytes.Buffer
+		n := testNotification()
+		n.Snapshot = []byte("jpeg")
+
+		require.NoError(t, NewNDJSON(&buf).Notify(n))
+		require.NoError(t, NewNDJSON(&buf).Notify(n))
+
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		require.Len(t, lines, 2)
+
+		var decoded Notification
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &decoded))
+		require.Equal(t, "alarm", decoded.Type)
+		require.Nil(t, decoded.Snapshot)
+	})
+}
+
+func TestWebhook(t *testing.T) {
+	t.Run("should post signed notification with snapshot", func(t *testing.T) {
+		var received Notification
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			require.Equal(t, hooks.Sign("key", body), r.Header.Get(hooks.SignatureHeader))
+			require.Equal(t, Event, r.Header.Get(hooks.EventHeader))
+			require.NoError(t, json.Unmarshal(body, &received))
+		}))
+		defer server.Close()
+
+		n := testNotification()
+		n.Snapshot = []byte("jpeg")
+
+		require.NoError(t, NewWebhook(hooks.NewWebhook(server.URL, "key", time.Second, 0)).Notify(n))
+		require.Equal(t, []byte("jpeg"), received.Snapshot)
+	})
+}
+
+func TestMessage(t *testing.T) {
+	date := time.Date(2020, 1, 1, 10, 1, 0, 0, time.UTC)
+
+	t.Run("should build plain text message", func(t *testing.T) {
+		msg, err := Message(testNotification(), "dvr@example.com", []string{"a@example.com", "b@example.com"}, date)
+
+		require.NoError(t, err)
+		require.Contains(t, string(msg), "To: a@example.com, b@example.com\r\n")
+		require.Contains(t, string(msg), "Subject: alarm on AA000000000001 channel 1 (Gate) at 2020-01-01T10:00:00\r\n")
+		require.Contains(t, string(msg), "Content-Type: text/plain; charset=utf-8\r\n")
+		require.Contains(t, string(msg), "Channel name: Gate\r\n")
+	})
+
+	t.Run("should attach snapshot and encode subject", func(t *testing.T) {
+		n := testNotification()
+		n.ChannelName = "Brama wjazdowa ł"
+		n.Snapshot = bytes.Repeat([]byte{0xff, 0xd8}, 100)
+
+		msg, err := Message(n, "dvr@example.com", []string{"a@example.com"}, date)
+
+		require.NoError(t, err)
+		require.Contains(t, string(msg), "Subject: =?utf-8?q?")
+		require.Contains(t, string(msg), "Content-Type: multipart/mixed; boundary=")
+		require.Contains(t, string(msg), "Content-Disposition: attachment; filename=\"snapshot-ch1.jpg\"\r\n")
+		for _, line := range strings.Split(string(msg), "\r\n") {
+			require.True(t, len(line) <= 998, line)
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package notify
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Seen keeps the keys of the notified recordings, so the recordings found
+// again by the next polls, or after the restart, are not notified twice.
+type Seen struct {
+	path string
+
+	mu   sync.Mutex
+	keys map[string]time.Time
+}
+
+// OpenSeen reads the file of the seen recordings. The missing file is the
+// empty set, and the empty path keeps the set only in memory.
+func OpenSeen(fp string) (*Seen, error) {
+	s := &Seen{path: fp, keys: make(map[string]time.Time)}
+	if fp == "" {
+		return s, nil
+	}
+
+	data, err := ioutil.ReadFile(fp)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &s.keys); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Add marks the key as last seen at the time, it reports whether the key is
+// new. The long recordings are found by many polls, so they are kept as long
+// as they are seen.
+func (s *Seen) Add(key string, t time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.keys[key]
+	s.keys[key] = t
+
+	return !ok
+}
+
+// Remove forgets the key, like of the recording which was not notified.
+func (s *Seen) Remove(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.keys, key)
+}
+
+// Prune forgets the keys seen before the time, which are too old to be found
+// by the polls again.
+func (s *Seen) Prune(before time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, t := range s.keys {
+		if t.Before(before) {
+			delete(s.keys, key)
+		}
+	}
+}
+
+func (s *Seen) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.keys)
+}
+
+// Save writes the seen keys to the temporary file which replaces the file.
+func (s *Seen) Save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	data, err := json.Marshal(s.keys)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	return os.Rename(f.Name(), s.path)
+}
@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeen(t *testing.T) {
+	t.Run("should keep seen keys across restarts", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "seen")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		fp := filepath.Join(dir, "seen.json")
+		now := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+
+		s, err := OpenSeen(fp)
+		require.NoError(t, err)
+		require.True(t, s.Add("a", now.Add(-48*time.Hour)))
+		require.True(t, s.Add("b", now))
+		require.False(t, s.Add("b", now))
+		require.NoError(t, s.Save())
+
+		s, err = OpenSeen(fp)
+		require.NoError(t, err)
+		require.False(t, s.Add("b", now))
+
+		s.Prune(now.Add(-24 * time.Hour))
+		require.Equal(t, 1, s.Len())
+		require.True(t, s.Add("a", now))
+
+		s.Remove("a")
+		require.True(t, s.Add("a", now))
+	})
+
+	t.Run("should keep key seen again", func(t *testing.T) {
+		now := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+		s, err := OpenSeen("")
+		require.NoError(t, err)
+
+		require.True(t, s.Add("a", now.Add(-48*time.Hour)))
+		require.False(t, s.Add("a", now))
+		s.Prune(now.Add(-24 * time.Hour))
+
+		require.Equal(t, 1, s.Len())
+		require.NoError(t, s.Save())
+	})
+}
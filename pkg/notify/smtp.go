@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTP sends the notifications by email, with the snapshot attached. The
+// connection is upgraded with STARTTLS when the server supports it.
+type SMTP struct {
+	Addr     string
+	From     string
+	To       []string
+	Username string
+	Password string
+}
+
+func (s *SMTP) String() string {
+	return "smtp://" + s.Addr
+}
+
+func (s *SMTP) Notify(n Notification) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		host, _, err := net.SplitHostPort(s.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+
+	msg, err := Message(n, s.From, s.To, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return smtp.SendMail(s.Addr, auth, s.From, s.To, msg)
+}
+
+// Message returns the email message of the notification.
+func Message(n Notification, from string, to []string, date time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", n.Subject()))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	body := messageBody(n)
+	if len(n.Snapshot) == 0 {
+		buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+		buf.WriteString(body)
+		return buf.Bytes(), nil
+	}
+
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	boundary := "defeway-" + hex.EncodeToString(id)
+
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", boundary)
+	fmt.Fprintf(&buf, "--%s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s\r\n", boundary, body)
+	fmt.Fprintf(&buf, "--%s\r\nContent-Type: image/jpeg\r\nContent-Transfer-Encoding: base64\r\n", boundary)
+	fmt.Fprintf(&buf, "Content-Disposition: attachment; filename=\"snapshot-ch%d.jpg\"\r\n\r\n", n.Channel)
+
+	encoded := base64.StdEncoding.EncodeToString(n.Snapshot)
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+
+	return buf.Bytes(), nil
+}
+
+func messageBody(n Notification) string {
+	lines := []string{
+		n.Subject(),
+		"",
+		"Device: " + n.Device,
+		"Address: " + n.Address,
+		fmt.Sprintf("Channel: %d", n.Channel),
+	}
+	if n.ChannelName != "" {
+		lines = append(lines, "Channel name: "+n.ChannelName)
+	}
+	lines = append(lines,
+		"Type: "+n.Type,
+		"Start: "+n.Start,
+		"End: "+n.End,
+		fmt.Sprintf("Recording: %d", n.Recording.RecordingID),
+	)
+	if n.SnapshotError != "" {
+		lines = append(lines, "Snapshot: "+n.SnapshotError)
+	}
+
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
@@ -0,0 +1,33 @@
+package notify
+
+import (
+	"encoding/json"
+
+	"github.com/crabtree/defeway-toolbox/pkg/hooks"
+)
+
+// Event is the name of the event sent in the header of the webhook request.
+const Event = "recording-detected"
+
+// Webhook posts the notification as JSON, with the snapshot encoded in
+// base64, signed and retried like the hooks of the other commands.
+type Webhook struct {
+	webhook *hooks.Webhook
+}
+
+func NewWebhook(webhook *hooks.Webhook) *Webhook {
+	return &Webhook{webhook: webhook}
+}
+
+func (w *Webhook) String() string {
+	return w.webhook.String()
+}
+
+func (w *Webhook) Notify(n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	return w.webhook.Post(Event, body)
+}
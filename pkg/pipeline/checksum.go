@@ -0,0 +1,48 @@
+package pipeline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+)
+
+// checksum computes the SHA-256 checksum of the recording and writes it to
+// the sidecar file in the sha256sum format.
+type checksum struct{}
+
+func NewChecksum() Processor {
+	return checksum{}
+}
+
+func (checksum) Name() string {
+	return "checksum"
+}
+
+func (checksum) Process(rec *Recording) error {
+	f, err := os.Open(rec.File(rec.Path))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+
+	name := rec.Path + ".sha256"
+	line := fmt.Sprintf("%s  %s\n", sum, path.Base(rec.Path))
+	if err := ioutil.WriteFile(rec.File(name), []byte(line), 0644); err != nil {
+		return err
+	}
+
+	rec.Checksum = sum
+	rec.AddFile(name)
+
+	return nil
+}
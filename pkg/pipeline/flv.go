@@ -0,0 +1,114 @@
+package pipeline
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+const (
+	flvHeaderSize    = 9
+	flvTagHeaderSize = 11
+
+	flvTagAudio  = 8
+	flvTagVideo  = 9
+	flvTagScript = 18
+)
+
+// flvValidator checks the structure of the FLV recording, which detects the
+// truncated and corrupted downloads.
+type flvValidator struct{}
+
+func NewFLVValidator() Processor {
+	return flvValidator{}
+}
+
+func (flvValidator) Name() string {
+	return "validate"
+}
+
+func (flvValidator) Process(rec *Recording) error {
+	f, err := os.Open(rec.File(rec.Path))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return ValidateFLV(bufio.NewReader(f))
+}
+
+// ValidateFLV checks the header and the chain of the tags of the FLV stream.
+// The stream must hold at least one video tag, and the last tag must be
+// complete.
+func ValidateFLV(r io.Reader) error {
+	header := make([]byte, flvHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return fmt.Errorf("the FLV header is truncated")
+	}
+
+	if string(header[:3]) != "FLV" {
+		return fmt.Errorf("the file is not FLV")
+	}
+	if header[3] != 1 {
+		return fmt.Errorf("unsupported FLV version %d", header[3])
+	}
+
+	dataOffset := int64(binary.BigEndian.Uint32(header[5:]))
+	if dataOffset < flvHeaderSize {
+		return fmt.Errorf("invalid FLV header size %d", dataOffset)
+	}
+	if _, err := io.CopyN(ioutil.Discard, r, dataOffset-flvHeaderSize); err != nil {
+		return fmt.Errorf("the FLV header is truncated")
+	}
+
+	offset := dataOffset
+	prevSize := uint32(0)
+	videoTags := 0
+	buf := make([]byte, flvTagHeaderSize)
+
+	for tag := 0; ; tag++ {
+		// the size of the previous tag, which ends the stream as well
+		if n, err := io.ReadFull(r, buf[:4]); err != nil {
+			if n == 0 && err == io.EOF {
+				break
+			}
+			return fmt.Errorf("the FLV stream is truncated at offset %d", offset)
+		}
+		if size := binary.BigEndian.Uint32(buf[:4]); size != prevSize {
+			return fmt.Errorf("invalid size %d of the FLV tag at offset %d, expected %d", size, offset, prevSize)
+		}
+		offset += 4
+
+		if n, err := io.ReadFull(r, buf); err != nil {
+			if n == 0 && err == io.EOF {
+				break
+			}
+			return fmt.Errorf("the FLV tag at offset %d is truncated", offset)
+		}
+
+		tagType := buf[0] & 0x1f
+		if tagType != flvTagAudio && tagType != flvTagVideo && tagType != flvTagScript {
+			return fmt.Errorf("invalid type %d of the FLV tag at offset %d", tagType, offset)
+		}
+		if tagType == flvTagVideo {
+			videoTags++
+		}
+
+		dataSize := uint32(buf[1])<<16 | uint32(buf[2])<<8 | uint32(buf[3])
+		if _, err := io.CopyN(ioutil.Discard, r, int64(dataSize)); err != nil {
+			return fmt.Errorf("the FLV tag at offset %d is truncated", offset)
+		}
+
+		offset += flvTagHeaderSize + int64(dataSize)
+		prevSize = flvTagHeaderSize + dataSize
+	}
+
+	if videoTags == 0 {
+		return fmt.Errorf("the FLV stream has no video")
+	}
+
+	return nil
+}
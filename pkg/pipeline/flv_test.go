@@ -0,0 +1,63 @@
+package pipeline
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func flvTag(tagType byte, data []byte) []byte {
+	tag := []byte{tagType, byte(len(data) >> 16), byte(len(data) >> 8), byte(len(data)), 0, 0, 0, 0, 0, 0, 0}
+	tag = append(tag, data...)
+
+	size := make([]byte, 4)
+	binary.BigEndian.PutUint32(size, uint32(len(tag)))
+
+	return append(tag, size...)
+}
+
+func flvStream(tags ...[]byte) []byte {
+	stream := []byte{'F', 'L', 'V', 1, 5, 0, 0, 0, 9, 0, 0, 0, 0}
+	for _, tag := range tags {
+		stream = append(stream, tag...)
+	}
+
+	return stream
+}
+
+func TestValidateFLV(t *testing.T) {
+	t.Run("should accept valid stream", func(t *testing.T) {
+		stream := flvStream(flvTag(flvTagScript, []byte("meta")), flvTag(flvTagVideo, []byte("frame")), flvTag(flvTagAudio, []byte("au")))
+
+		require.NoError(t, ValidateFLV(bytes.NewReader(stream)))
+	})
+
+	t.Run("should accept stream without trailing tag size", func(t *testing.T) {
+		stream := flvStream(flvTag(flvTagVideo, []byte("frame")))
+
+		require.NoError(t, ValidateFLV(bytes.NewReader(stream[:len(stream)-4])))
+	})
+
+	t.Run("should reject truncated stream", func(t *testing.T) {
+		stream := flvStream(flvTag(flvTagVideo, []byte("frame")), flvTag(flvTagVideo, []byte("frame")))
+
+		err := ValidateFLV(bytes.NewReader(stream[:len(stream)-6]))
+
+		require.EqualError(t, err, "the FLV tag at offset 33 is truncated")
+	})
+
+	t.Run("should reject invalid streams", func(t *testing.T) {
+		for name, stream := range map[string][]byte{
+			"not FLV":        []byte("<html></html>"),
+			"no video":       flvStream(flvTag(flvTagAudio, []byte("au"))),
+			"invalid tag":    flvStream(flvTag(3, []byte("x"))),
+			"invalid size":   append(flvStream(flvTag(flvTagVideo, []byte("frame")))[:29], 0, 0, 0, 1),
+			"short header":   []byte("FLV"),
+			"invalid header": []byte{'F', 'L', 'V', 1, 5, 0, 0, 0, 3},
+		} {
+			require.Error(t, ValidateFLV(bytes.NewReader(stream)), name)
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path"
+	"time"
+)
+
+// metadataTimeLayout formats the times of the DVR clock, which has no time
+// zone.
+const metadataTimeLayout = "2006-01-02T15:04:05"
+
+// metadata writes the JSON sidecar file describing the recording, which
+// keeps the details lost in the file name, like the channel name.
+type metadata struct{}
+
+type metadataFile struct {
+	File        string `json:"file"`
+	SHA256      string `json:"sha256,omitempty"`
+	RecordingID uint   `json:"recording_id"`
+	Channel     int    `json:"channel"`
+	ChannelName string `json:"channel_name,omitempty"`
+	Type        string `json:"type"`
+	Start       string `json:"start"`
+	End         string `json:"end"`
+	Duration    string `json:"duration"`
+	Site        string `json:"site,omitempty"`
+	Device      string `json:"device,omitempty"`
+	Serial      string `json:"serial,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Address     string `json:"address,omitempty"`
+}
+
+func NewMetadata() Processor {
+	return metadata{}
+}
+
+func (metadata) Name() string {
+	return "metadata"
+}
+
+func (metadata) Process(rec *Recording) error {
+	// the DVR reports the times of its clock as UTC timestamps
+	start := time.Unix(int64(rec.Meta.StartTimestamp), 0).UTC()
+	end := time.Unix(int64(rec.Meta.EndTimestamp), 0).UTC()
+	channel := int(rec.Meta.ChannelID) + 1
+
+	data, err := json.MarshalIndent(metadataFile{
+		File:        path.Base(rec.Path),
+		SHA256:      rec.Checksum,
+		RecordingID: rec.Meta.RecordingID,
+		Channel:     channel,
+		ChannelName: rec.Device.ChannelNames[channel],
+		Type:        rec.Meta.TypeName(),
+		Start:       start.Format(metadataTimeLayout),
+		End:         end.Format(metadataTimeLayout),
+		Duration:    end.Sub(start).String(),
+		Site:        rec.Device.Site,
+		Device:      rec.Device.Device,
+		Serial:      rec.Device.Serial,
+		Name:        rec.Device.Name,
+		Address:     rec.Device.Address,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	name := sidecarPath(rec.Path, ".json")
+	if err := ioutil.WriteFile(rec.File(name), append(data, '\n'), 0644); err != nil {
+		return err
+	}
+
+	rec.AddFile(name)
+
+	return nil
+}
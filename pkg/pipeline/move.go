@@ -0,0 +1,62 @@
+package pipeline
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/crabtree/defeway-toolbox/pkg/sink"
+)
+
+// move stores the recording and its sidecar files in the sink, like the S3
+// bucket, and removes the local files once all of them are stored.
+type move struct {
+	sink sink.Sink
+}
+
+func NewMove(s sink.Sink) Processor {
+	return move{sink: s}
+}
+
+func (move) Name() string {
+	return "move"
+}
+
+func (m move) Process(rec *Recording) error {
+	names := append([]string{rec.Path}, rec.Files...)
+
+	for _, name := range names {
+		if err := m.store(rec.File(name), name); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range names {
+		if err := os.Remove(rec.File(name)); err != nil {
+			return err
+		}
+	}
+	sink.RemoveEmptyDirs(filepath.Dir(rec.File(rec.Path)), rec.Root)
+
+	return nil
+}
+
+func (m move) store(fp, name string) error {
+	src, err := os.Open(fp)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := m.sink.Create(name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Abort()
+		return err
+	}
+
+	return dst.Close()
+}
@@ -0,0 +1,223 @@
+package pipeline
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	dc "github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/crabtree/defeway-toolbox/pkg/layout"
+)
+
+// Recording is the downloaded recording passed through the processors. The
+// processors may replace the file of the recording, like the remux does, and
+// add the sidecar files. All paths are slash separated and relative to the
+// root directory.
+type Recording struct {
+	Root     string
+	Path     string
+	Files    []string
+	Checksum string
+	Meta     dc.RecordingMeta
+	Device   layout.Device
+}
+
+// File returns the local path of the file of the recording.
+func (r *Recording) File(name string) string {
+	return filepath.Join(r.Root, filepath.FromSlash(name))
+}
+
+// AddFile adds the sidecar file of the recording.
+func (r *Recording) AddFile(name string) {
+	for _, f := range r.Files {
+		if f == name {
+			return
+		}
+	}
+
+	r.Files = append(r.Files, name)
+}
+
+// Processor is the step of the pipeline run on each downloaded recording.
+type Processor interface {
+	Name() string
+	Process(rec *Recording) error
+}
+
+type Status string
+
+const (
+	OK      Status = "ok"
+	Failed  Status = "failed"
+	Skipped Status = "skipped"
+)
+
+// Result is the outcome of the step run on the recording.
+type Result struct {
+	Step   string
+	Status Status
+	Err    error
+}
+
+// StepSummary counts the outcomes of the step in the run.
+type StepSummary struct {
+	Step    string
+	OK      int
+	Failed  int
+	Skipped int
+}
+
+// Failure is the failed step of the recording.
+type Failure struct {
+	Recording string
+	Step      string
+	Err       error
+}
+
+// Pipeline runs the chain of the processors on the downloaded recordings.
+// The outcomes of the steps are kept in the state, so the steps which
+// failed are retried in the next run without downloading the recording
+// again, and the steps which succeeded are not repeated.
+type Pipeline struct {
+	processors []Processor
+	state      *State
+
+	mu       sync.Mutex
+	counts   map[string]map[Status]int
+	failures []Failure
+}
+
+func New(processors []Processor, state *State) *Pipeline {
+	return &Pipeline{
+		processors: processors,
+		state:      state,
+		counts:     make(map[string]map[Status]int),
+	}
+}
+
+// Done reports whether all steps succeeded on the recording.
+func (p *Pipeline) Done(name string) bool {
+	entry, ok := p.state.get(name)
+	if !ok {
+		return false
+	}
+
+	for _, proc := range p.processors {
+		if entry.Steps[proc.Name()].Status != OK {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Pending reports whether the recording was processed, but some of the
+// steps did not succeed.
+func (p *Pipeline) Pending(name string) bool {
+	_, ok := p.state.get(name)
+
+	return ok && !p.Done(name)
+}
+
+// Reset forgets the outcomes of the steps of the recording, which is
+// downloaded again.
+func (p *Pipeline) Reset(name string) error {
+	return p.state.delete(name)
+}
+
+// Process runs the steps on the recording, which is stored under the name.
+// The steps which succeeded earlier are skipped, and the chain stops at the
+// first failed step, as the following steps depend on it.
+func (p *Pipeline) Process(name string, rec Recording) []Result {
+	entry, ok := p.state.get(name)
+	if ok {
+		rec.Path = entry.Path
+		rec.Files = entry.Files
+		rec.Checksum = entry.Checksum
+	} else {
+		entry = Entry{Steps: make(map[string]StepState)}
+	}
+
+	results := make([]Result, 0, len(p.processors))
+	failed := false
+
+	for _, proc := range p.processors {
+		step := proc.Name()
+
+		if failed {
+			results = append(results, Result{Step: step, Status: Skipped})
+			continue
+		}
+
+		if entry.Steps[step].Status == OK {
+			continue
+		}
+
+		state := StepState{Status: OK}
+		if err := proc.Process(&rec); err != nil {
+			failed = true
+			state = StepState{Status: Failed, Error: err.Error()}
+			results = append(results, Result{Step: step, Status: Failed, Err: err})
+		} else {
+			results = append(results, Result{Step: step, Status: OK})
+		}
+
+		entry.Path = rec.Path
+		entry.Files = rec.Files
+		entry.Checksum = rec.Checksum
+		entry.Steps[step] = state
+
+		if err := p.state.put(name, entry); err != nil {
+			results[len(results)-1] = Result{Step: step, Status: Failed, Err: fmt.Errorf("cannot save pipeline state: %w", err)}
+			failed = true
+		}
+	}
+
+	p.record(name, results)
+
+	return results
+}
+
+func (p *Pipeline) record(name string, results []Result) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, result := range results {
+		if p.counts[result.Step] == nil {
+			p.counts[result.Step] = make(map[Status]int)
+		}
+		p.counts[result.Step][result.Status]++
+
+		if result.Status == Failed {
+			p.failures = append(p.failures, Failure{Recording: name, Step: result.Step, Err: result.Err})
+		}
+	}
+}
+
+// Summary returns the outcomes of the steps run so far, in the order of
+// the steps.
+func (p *Pipeline) Summary() []StepSummary {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	summary := make([]StepSummary, 0, len(p.processors))
+	for _, proc := range p.processors {
+		counts := p.counts[proc.Name()]
+		summary = append(summary, StepSummary{
+			Step:    proc.Name(),
+			OK:      counts[OK],
+			Failed:  counts[Failed],
+			Skipped: counts[Skipped],
+		})
+	}
+
+	return summary
+}
+
+// Failures returns the failed steps of the run.
+func (p *Pipeline) Failures() []Failure {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]Failure(nil), p.failures...)
+}
@@ -0,0 +1,119 @@
+package pipeline
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProcessor struct {
+	name  string
+	calls int
+	err   error
+}
+
+func (p *fakeProcessor) Name() string {
+	return p.name
+}
+
+func (p *fakeProcessor) Process(rec *Recording) error {
+	p.calls++
+	if p.err != nil {
+		return p.err
+	}
+
+	rec.AddFile(rec.Path + "." + p.name)
+
+	return nil
+}
+
+func TestPipeline_Process(t *testing.T) {
+	t.Run("should stop at failed step and retry it in the next run", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "pipeline")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		statePath := filepath.Join(dir, StateFileName)
+
+		first := &fakeProcessor{name: "first"}
+		second := &fakeProcessor{name: "second", err: errors.New("failure")}
+		third := &fakeProcessor{name: "third"}
+
+		state, err := OpenState(statePath)
+		require.NoError(t, err)
+		p := New([]Processor{first, second, third}, state)
+
+		results := p.Process("dev/1.flv", Recording{Root: dir, Path: "dev/1.flv"})
+		require.Equal(t, []Result{
+			{Step: "first", Status: OK},
+			{Step: "second", Status: Failed, Err: second.err},
+			{Step: "third", Status: Skipped},
+		}, results)
+		require.True(t, p.Pending("dev/1.flv"))
+		require.False(t, p.Done("dev/1.flv"))
+		require.Equal(t, []StepSummary{
+			{Step: "first", OK: 1},
+			{Step: "second", Failed: 1},
+			{Step: "third", Skipped: 1},
+		}, p.Summary())
+		require.Equal(t, []Failure{{Recording: "dev/1.flv", Step: "second", Err: second.err}}, p.Failures())
+
+		second.err = nil
+		state, err = OpenState(statePath)
+		require.NoError(t, err)
+		p = New([]Processor{first, second, third}, state)
+
+		results = p.Process("dev/1.flv", Recording{Root: dir, Path: "dev/1.flv"})
+		require.Equal(t, []Result{
+			{Step: "second", Status: OK},
+			{Step: "third", Status: OK},
+		}, results)
+		require.Equal(t, 1, first.calls)
+		require.True(t, p.Done("dev/1.flv"))
+		require.False(t, p.Pending("dev/1.flv"))
+
+		entry, ok := state.get("dev/1.flv")
+		require.True(t, ok)
+		require.Equal(t, []string{"dev/1.flv.first", "dev/1.flv.second", "dev/1.flv.third"}, entry.Files)
+	})
+
+	t.Run("should run all steps again after reset", func(t *testing.T) {
+		first := &fakeProcessor{name: "first"}
+		p := New([]Processor{first}, NewState())
+
+		p.Process("dev/1.flv", Recording{Path: "dev/1.flv"})
+		require.True(t, p.Done("dev/1.flv"))
+
+		require.NoError(t, p.Reset("dev/1.flv"))
+		require.False(t, p.Done("dev/1.flv"))
+		require.False(t, p.Pending("dev/1.flv"))
+
+		p.Process("dev/1.flv", Recording{Path: "dev/1.flv"})
+		require.Equal(t, 2, first.calls)
+	})
+}
+
+func TestNewProcessors(t *testing.T) {
+	t.Run("should return processors in order", func(t *testing.T) {
+		processors, err := NewProcessors([]string{"validate", "checksum", "metadata"}, Options{})
+		require.NoError(t, err)
+		require.Len(t, processors, 3)
+		require.Equal(t, "validate", processors[0].Name())
+		require.Equal(t, "checksum", processors[1].Name())
+		require.Equal(t, "metadata", processors[2].Name())
+	})
+
+	t.Run("should return error for invalid steps", func(t *testing.T) {
+		_, err := NewProcessors([]string{"upload"}, Options{})
+		require.Error(t, err)
+
+		_, err = NewProcessors([]string{"checksum", "checksum"}, Options{})
+		require.Error(t, err)
+
+		_, err = NewProcessors([]string{"move"}, Options{})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,62 @@
+package pipeline
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/crabtree/defeway-toolbox/pkg/sink"
+)
+
+// Steps are the names of the built-in processors.
+var Steps = []string{"checksum", "validate", "mp4", "metadata", "move"}
+
+// Options configure the built-in processors.
+type Options struct {
+	// FFmpeg is the path of the ffmpeg binary used by the mp4 step.
+	FFmpeg string
+	// Sink is the destination of the move step.
+	Sink sink.Sink
+}
+
+// NewProcessors returns the built-in processors in the order of the names.
+func NewProcessors(names []string, opts Options) ([]Processor, error) {
+	processors := make([]Processor, 0, len(names))
+	seen := make(map[string]bool)
+
+	for i, name := range names {
+		if seen[name] {
+			return nil, fmt.Errorf("the step %s is specified more than once", name)
+		}
+		seen[name] = true
+
+		switch name {
+		case "checksum":
+			processors = append(processors, NewChecksum())
+		case "validate":
+			processors = append(processors, NewFLVValidator())
+		case "mp4":
+			processors = append(processors, NewRemux(opts.FFmpeg))
+		case "metadata":
+			processors = append(processors, NewMetadata())
+		case "move":
+			if opts.Sink == nil {
+				return nil, fmt.Errorf("specify the destination of the move step")
+			}
+			// the files are not available to the steps after the move
+			if i != len(names)-1 {
+				return nil, fmt.Errorf("the move step must be the last one")
+			}
+			processors = append(processors, NewMove(opts.Sink))
+		default:
+			return nil, fmt.Errorf("unknown step %s, use one of %v", name, Steps)
+		}
+	}
+
+	return processors, nil
+}
+
+// sidecarPath returns the path of the sidecar file of the recording, which
+// replaces its extension.
+func sidecarPath(name, ext string) string {
+	return name[:len(name)-len(path.Ext(name))] + ext
+}
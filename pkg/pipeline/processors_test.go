@@ -0,0 +1,149 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	dc "github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/crabtree/defeway-toolbox/pkg/layout"
+	"github.com/crabtree/defeway-toolbox/pkg/sink"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRecording(t *testing.T) (Recording, func()) {
+	dir, err := ioutil.TempDir("", "pipeline")
+	require.NoError(t, err)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "dev"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "dev", "1.flv"), []byte("recording"), 0644))
+
+	rec := Recording{
+		Root: dir,
+		Path: "dev/1.flv",
+		Meta: dc.RecordingMeta{
+			RecordingID:    1,
+			ChannelID:      0,
+			TypeID:         2,
+			StartTimestamp: 1577872800,
+			EndTimestamp:   1577873400,
+		},
+		Device: layout.Device{
+			ChannelNames: map[int]string{1: "Gate"},
+			Device:       "AA000000000001",
+			Site:         "warehouse",
+		},
+	}
+
+	return rec, func() { os.RemoveAll(dir) }
+}
+
+func TestChecksum(t *testing.T) {
+	t.Run("should write checksum file", func(t *testing.T) {
+		rec, cleanup := newTestRecording(t)
+		defer cleanup()
+
+		require.NoError(t, NewChecksum().Process(&rec))
+
+		sum := "3ebb153fb24e4411400e94a9a92b0ec458c3a8473e51e03cd37d4a34c99dfda6"
+		require.Equal(t, sum, rec.Checksum)
+		data, err := ioutil.ReadFile(filepath.Join(rec.Root, "dev", "1.flv.sha256"))
+		require.NoError(t, err)
+		require.Equal(t, sum+"  1.flv\n", string(data))
+		require.Equal(t, []string{"dev/1.flv.sha256"}, rec.Files)
+	})
+}
+
+func TestMetadata(t *testing.T) {
+	t.Run("should write metadata file", func(t *testing.T) {
+		rec, cleanup := newTestRecording(t)
+		defer cleanup()
+		rec.Checksum = "abc"
+
+		require.NoError(t, NewMetadata().Process(&rec))
+
+		data, err := ioutil.ReadFile(filepath.Join(rec.Root, "dev", "1.json"))
+		require.NoError(t, err)
+
+		var meta metadataFile
+		require.NoError(t, json.Unmarshal(data, &meta))
+		require.Equal(t, metadataFile{
+			File:        "1.flv",
+			SHA256:      "abc",
+			RecordingID: 1,
+			Channel:     1,
+			ChannelName: "Gate",
+			Type:        "motion",
+			Start:       "2020-01-01T10:00:00",
+			End:         "2020-01-01T10:10:00",
+			Duration:    "10m0s",
+			Site:        "warehouse",
+			Device:      "AA000000000001",
+		}, meta)
+		require.Equal(t, []string{"dev/1.json"}, rec.Files)
+	})
+}
+
+func TestRemux(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake ffmpeg is the shell script")
+	}
+
+	t.Run("should replace recording with remuxed one", func(t *testing.T) {
+		rec, cleanup := newTestRecording(t)
+		defer cleanup()
+
+		// the fake ffmpeg copies the input to the output given last
+		ffmpeg := filepath.Join(rec.Root, "ffmpeg")
+		script := "#!/bin/sh\nfor out; do :; done\ncp \"$6\" \"$out\"\n"
+		require.NoError(t, ioutil.WriteFile(ffmpeg, []byte(script), 0755))
+
+		require.NoError(t, NewRemux(ffmpeg).Process(&rec))
+
+		require.Equal(t, "dev/1.mp4", rec.Path)
+		data, err := ioutil.ReadFile(filepath.Join(rec.Root, "dev", "1.mp4"))
+		require.NoError(t, err)
+		require.Equal(t, "recording", string(data))
+		_, err = os.Stat(filepath.Join(rec.Root, "dev", "1.flv"))
+		require.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("should keep recording when ffmpeg fails", func(t *testing.T) {
+		rec, cleanup := newTestRecording(t)
+		defer cleanup()
+
+		ffmpeg := filepath.Join(rec.Root, "ffmpeg")
+		require.NoError(t, ioutil.WriteFile(ffmpeg, []byte("#!/bin/sh\necho invalid data >&2\nexit 1\n"), 0755))
+
+		err := NewRemux(ffmpeg).Process(&rec)
+
+		require.EqualError(t, err, "ffmpeg failed: exit status 1: invalid data")
+		require.Equal(t, "dev/1.flv", rec.Path)
+		_, err = os.Stat(filepath.Join(rec.Root, "dev", "1.flv"))
+		require.NoError(t, err)
+	})
+}
+
+func TestMove(t *testing.T) {
+	t.Run("should move recording with sidecar files to sink", func(t *testing.T) {
+		rec, cleanup := newTestRecording(t)
+		defer cleanup()
+		require.NoError(t, NewChecksum().Process(&rec))
+
+		dst, err := ioutil.TempDir("", "pipeline")
+		require.NoError(t, err)
+		defer os.RemoveAll(dst)
+
+		require.NoError(t, NewMove(sink.NewLocal(dst)).Process(&rec))
+
+		for _, name := range []string{"1.flv", "1.flv.sha256"} {
+			_, err := os.Stat(filepath.Join(dst, "dev", name))
+			require.NoError(t, err)
+		}
+		_, err = os.Stat(filepath.Join(rec.Root, "dev"))
+		require.True(t, os.IsNotExist(err))
+	})
+}
@@ -0,0 +1,65 @@
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+const DefaultFFmpeg = "ffmpeg"
+
+// remux copies the streams of the recording into the MP4 container with
+// ffmpeg, which replaces the original recording.
+type remux struct {
+	ffmpeg string
+}
+
+func NewRemux(ffmpeg string) Processor {
+	if ffmpeg == "" {
+		ffmpeg = DefaultFFmpeg
+	}
+
+	return remux{ffmpeg: ffmpeg}
+}
+
+func (remux) Name() string {
+	return "mp4"
+}
+
+func (r remux) Process(rec *Recording) error {
+	if strings.EqualFold(path.Ext(rec.Path), ".mp4") {
+		return nil
+	}
+
+	dst := sidecarPath(rec.Path, ".mp4")
+	tmp := rec.File(dst) + ".part"
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(r.ffmpeg, "-nostdin", "-y", "-v", "error",
+		"-i", rec.File(rec.Path),
+		"-c", "copy", "-movflags", "+faststart", "-f", "mp4", tmp)
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		os.Remove(tmp)
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("ffmpeg failed: %s: %s", err, msg)
+		}
+		return fmt.Errorf("ffmpeg failed: %s", err)
+	}
+
+	if err := os.Rename(tmp, rec.File(dst)); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Remove(rec.File(rec.Path)); err != nil {
+		return err
+	}
+	rec.Path = dst
+
+	return nil
+}
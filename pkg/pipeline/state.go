@@ -0,0 +1,150 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// StateFileName is the name of the state file kept in the downloads
+// directory.
+const StateFileName = ".defeway-pipeline.json"
+
+// Entry holds the outcomes of the steps run on the recording, and the files
+// of the recording after the last step.
+type Entry struct {
+	Path     string               `json:"path"`
+	Files    []string             `json:"files,omitempty"`
+	Checksum string               `json:"checksum,omitempty"`
+	Steps    map[string]StepState `json:"steps"`
+}
+
+type StepState struct {
+	Status Status    `json:"status"`
+	Error  string    `json:"error,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// State keeps the entries of the processed recordings keyed by their names,
+// in the JSON file saved after every step.
+type State struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]Entry
+}
+
+// NewState returns the state which is not saved.
+func NewState() *State {
+	return &State{entries: make(map[string]Entry)}
+}
+
+// OpenState reads the state file. The missing file is the empty state.
+func OpenState(fp string) (*State, error) {
+	s := NewState()
+	s.path = fp
+
+	data, err := ioutil.ReadFile(fp)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &s.entries); err != nil {
+		return nil, fmt.Errorf("invalid pipeline state %s: %s", fp, err)
+	}
+
+	return s, nil
+}
+
+func (s *State) get(name string) (Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[name]
+	if !ok {
+		return Entry{}, false
+	}
+
+	// the entry is modified by the caller
+	steps := make(map[string]StepState, len(entry.Steps))
+	for step, state := range entry.Steps {
+		steps[step] = state
+	}
+	entry.Steps = steps
+	entry.Files = append([]string(nil), entry.Files...)
+
+	return entry, true
+}
+
+func (s *State) put(name string, entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the entry is kept apart from the one modified by the caller
+	steps := make(map[string]StepState, len(entry.Steps))
+	for step, state := range entry.Steps {
+		if state.Time.IsZero() {
+			state.Time = time.Now()
+		}
+		steps[step] = state
+	}
+	entry.Steps = steps
+	entry.Files = append([]string(nil), entry.Files...)
+	s.entries[name] = entry
+
+	return s.save()
+}
+
+func (s *State) delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[name]; !ok {
+		return nil
+	}
+	delete(s.entries, name)
+
+	return s.save()
+}
+
+// save writes the state to the temporary file, which replaces the state
+// file, so the interrupted run does not corrupt it.
+func (s *State) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	return os.Rename(f.Name(), s.path)
+}
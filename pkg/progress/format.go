@@ -0,0 +1,118 @@
+package progress
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// IsTerminal reports whether the file is the terminal, which shows the
+// progress bars.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// formatBytes formats the number of bytes with the binary units.
+func formatBytes(bytes int64) string {
+	const unit = 1 << 10
+	if bytes < unit {
+		return fmt.Sprintf("%dB", bytes)
+	}
+
+	value := float64(bytes)
+	for _, suffix := range []string{"KiB", "MiB", "GiB"} {
+		value /= unit
+		if value < unit || suffix == "GiB" {
+			return fmt.Sprintf("%.1f%s", value, suffix)
+		}
+	}
+
+	return ""
+}
+
+// formatDuration formats the duration rounded to the second, and to the
+// minute over an hour.
+func formatDuration(d time.Duration) string {
+	if d >= time.Hour {
+		return strings.TrimSuffix(d.Round(time.Minute).String(), "0s")
+	}
+
+	return d.Round(time.Second).String()
+}
+
+// bar returns the bar of the width filled in the ratio of the bytes to the
+// total.
+func bar(bytes, total int64, width int) string {
+	filled := 0
+	if total > 0 {
+		filled = int(float64(bytes) / float64(total) * float64(width))
+	}
+	if filled > width {
+		filled = width
+	}
+
+	return strings.Repeat("=", filled) + strings.Repeat(" ", width-filled)
+}
+
+// shorten keeps the end of the name, like the file name of the path, within
+// the width.
+func shorten(name string, width int) string {
+	runes := []rune(name)
+	if len(runes) <= width {
+		return name
+	}
+
+	return "..." + string(runes[len(runes)-width+3:])
+}
+
+// transferLine describes the transfer in flight.
+func transferLine(ts TransferSnapshot) string {
+	size := "?"
+	if ts.Total > 0 {
+		size = formatBytes(ts.Total)
+		if ts.Estimated {
+			size = "~" + size
+		}
+	}
+
+	eta := ""
+	if ts.ETA > 0 {
+		eta = " ETA " + formatDuration(ts.ETA)
+	}
+
+	return fmt.Sprintf("%-40s [%s] %s/%s %s/s%s",
+		shorten(ts.Name, 40), bar(ts.Bytes, ts.Total, 20), formatBytes(ts.Bytes), size, formatBytes(int64(ts.Rate)), eta)
+}
+
+// summaryLine describes the progress of the run.
+func summaryLine(s Snapshot) string {
+	parts := []string{fmt.Sprintf("%s %d", s.Unit, s.Done)}
+	if s.Total > 0 {
+		parts[0] += fmt.Sprintf("/%d", s.Total)
+	}
+
+	if s.Failed > 0 {
+		parts = append(parts, fmt.Sprintf("%d failed", s.Failed))
+	}
+
+	if s.Bytes > 0 {
+		parts = append(parts, formatBytes(s.Bytes), formatBytes(int64(s.Rate))+"/s")
+	}
+
+	parts = append(parts, "elapsed "+formatDuration(s.Elapsed))
+	if s.ETA > 0 {
+		parts = append(parts, "ETA "+formatDuration(s.ETA))
+	}
+
+	if s.Concurrency > 0 {
+		parts = append(parts, fmt.Sprintf("concurrency %d", s.Concurrency))
+	}
+
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,204 @@
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Reporter reports the progress of the tracker until it is stopped.
+type Reporter interface {
+	// Stop reports the final progress and stops the reports.
+	Stop()
+}
+
+// ticker calls the report every interval until it is stopped.
+type ticker struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+func startTicker(interval time.Duration, report func()) *ticker {
+	t := &ticker{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	go func() {
+		defer close(t.done)
+
+		tick := time.NewTicker(interval)
+		defer tick.Stop()
+
+		for {
+			select {
+			case <-tick.C:
+				report()
+			case <-t.stop:
+				return
+			}
+		}
+	}()
+
+	return t
+}
+
+func (t *ticker) Stop() {
+	close(t.stop)
+	<-t.done
+}
+
+// Bars draws the progress bars of the transfers in flight and the progress
+// of the run at the bottom of the terminal. The log written through Bars is
+// printed above the bars.
+type Bars struct {
+	tracker *Tracker
+	ticker  *ticker
+
+	mu    sync.Mutex
+	w     io.Writer
+	lines int
+}
+
+func NewBars(tracker *Tracker, w io.Writer, interval time.Duration) *Bars {
+	b := &Bars{tracker: tracker, w: w}
+	b.ticker = startTicker(interval, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		b.clear()
+		b.draw()
+	})
+
+	return b
+}
+
+func (b *Bars) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.clear()
+	n, err := b.w.Write(p)
+	b.draw()
+
+	return n, err
+}
+
+// Stop leaves the final progress of the run on the terminal.
+func (b *Bars) Stop() {
+	b.ticker.Stop()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.clear()
+	fmt.Fprintln(b.w, summaryLine(b.tracker.Snapshot()))
+}
+
+// clear erases the drawn bars, the cursor is left at their first line.
+func (b *Bars) clear() {
+	if b.lines > 0 {
+		fmt.Fprintf(b.w, "\x1b[%dA\x1b[J", b.lines)
+		b.lines = 0
+	}
+}
+
+func (b *Bars) draw() {
+	s := b.tracker.Snapshot()
+	for _, ts := range s.Transfers {
+		fmt.Fprintln(b.w, transferLine(ts))
+	}
+	fmt.Fprintln(b.w, summaryLine(s))
+
+	b.lines = len(s.Transfers) + 1
+}
+
+// NDJSON writes the progress as the JSON lines every interval, like to the
+// log collector when the output is not the terminal.
+type NDJSON struct {
+	tracker *Tracker
+	ticker  *ticker
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewNDJSON(tracker *Tracker, w io.Writer, interval time.Duration) *NDJSON {
+	nd := &NDJSON{tracker: tracker, w: w}
+	nd.ticker = startTicker(interval, nd.write)
+
+	return nd
+}
+
+func (nd *NDJSON) Stop() {
+	nd.ticker.Stop()
+	nd.write()
+}
+
+// event is the JSON line of the progress, with the durations in seconds.
+type event struct {
+	Event          string
+	Time           time.Time
+	Unit           string
+	Total          int `json:",omitempty"`
+	Done           int
+	Failed         int
+	Bytes          int64
+	Rate           float64
+	ElapsedSeconds float64
+	ETASeconds     float64         `json:",omitempty"`
+	Concurrency    int             `json:",omitempty"`
+	Transfers      []transferEvent `json:",omitempty"`
+}
+
+type transferEvent struct {
+	Name       string
+	Bytes      int64
+	Total      int64 `json:",omitempty"`
+	Estimated  bool  `json:",omitempty"`
+	Rate       float64
+	ETASeconds float64 `json:",omitempty"`
+}
+
+func newEvent(s Snapshot, now time.Time) event {
+	e := event{
+		Event:          "progress",
+		Time:           now,
+		Unit:           s.Unit,
+		Total:          s.Total,
+		Done:           s.Done,
+		Failed:         s.Failed,
+		Bytes:          s.Bytes,
+		Rate:           s.Rate,
+		ElapsedSeconds: s.Elapsed.Seconds(),
+		ETASeconds:     s.ETA.Seconds(),
+		Concurrency:    s.Concurrency,
+	}
+
+	for _, ts := range s.Transfers {
+		e.Transfers = append(e.Transfers, transferEvent{
+			Name:       ts.Name,
+			Bytes:      ts.Bytes,
+			Total:      ts.Total,
+			Estimated:  ts.Estimated,
+			Rate:       ts.Rate,
+			ETASeconds: ts.ETA.Seconds(),
+		})
+	}
+
+	return e
+}
+
+func (nd *NDJSON) write() {
+	data, err := json.Marshal(newEvent(nd.tracker.Snapshot(), nd.tracker.now()))
+	if err != nil {
+		return
+	}
+
+	nd.mu.Lock()
+	defer nd.mu.Unlock()
+
+	nd.w.Write(append(data, '\n'))
+}
@@ -0,0 +1,94 @@
+package progress
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	t.Run("should format bytes with binary units", func(t *testing.T) {
+		require.Equal(t, "512B", formatBytes(512))
+		require.Equal(t, "1.5KiB", formatBytes(1536))
+		require.Equal(t, "2.0MiB", formatBytes(2<<20))
+		require.Equal(t, "2048.0GiB", formatBytes(2<<40))
+	})
+
+	t.Run("should format durations", func(t *testing.T) {
+		require.Equal(t, "42s", formatDuration(41600*time.Millisecond))
+		require.Equal(t, "1h2m", formatDuration(time.Hour+2*time.Minute+10*time.Second))
+	})
+
+	t.Run("should keep the end of the long names", func(t *testing.T) {
+		require.Equal(t, "short.flv", shorten("short.flv", 12))
+		require.Equal(t, ".../1-0-1.flv", shorten("/archive/AA000000000001/2021-10-22/1-0-1.flv", 13))
+	})
+
+	t.Run("should describe the transfer and the run", func(t *testing.T) {
+		line := transferLine(TransferSnapshot{Name: "1-0-1.flv", Bytes: 1 << 20, Total: 4 << 20, Estimated: true, Rate: 1 << 20, ETA: 3 * time.Second})
+		require.Equal(t, "1-0-1.flv                                [=====               ] 1.0MiB/~4.0MiB 1.0MiB/s ETA 3s", line)
+
+		line = summaryLine(Snapshot{Unit: "recordings", Total: 10, Done: 4, Failed: 1, Bytes: 3 << 20, Rate: 1 << 20, Elapsed: 3 * time.Second, ETA: 5 * time.Second, Concurrency: 2})
+		require.Equal(t, "recordings 4/10, 1 failed, 3.0MiB, 1.0MiB/s, elapsed 3s, ETA 5s, concurrency 2", line)
+
+		line = summaryLine(Snapshot{Unit: "addresses", Done: 3, Elapsed: time.Second})
+		require.Equal(t, "addresses 3, elapsed 1s", line)
+	})
+}
+
+func TestBars(t *testing.T) {
+	t.Run("should print the log above the bars and leave the final progress", func(t *testing.T) {
+		now := time.Date(2021, 10, 22, 12, 0, 0, 0, time.UTC)
+		tracker := newTestTracker(&now)
+		tracker.SetTotal(2)
+		tr := tracker.Start("1-0-1.flv", 0)
+		tr.Update(10, 20)
+
+		var out bytes.Buffer
+		bars := NewBars(tracker, &out, time.Hour)
+
+		_, err := bars.Write([]byte("Downloading 1\n"))
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(out.String(), "Downloading 1\n1-0-1.flv "))
+
+		tr.Finish(true)
+		tracker.Done(false)
+		bars.Stop()
+
+		require.Contains(t, out.String(), "\x1b[2A\x1b[J")
+		require.True(t, strings.HasSuffix(out.String(), "\x1b[J"+summaryLine(tracker.Snapshot())+"\n"))
+	})
+}
+
+func TestNDJSON(t *testing.T) {
+	t.Run("should write the final progress as the JSON line", func(t *testing.T) {
+		tracker := NewTracker("recordings")
+		tracker.SetTotal(2)
+		tracker.SetConcurrency(3)
+		tracker.Done(true)
+		tr := tracker.Start("2-0-1.flv", 0)
+		tr.Update(10, 20)
+
+		var out bytes.Buffer
+		nd := NewNDJSON(tracker, &out, time.Hour)
+		nd.Stop()
+
+		lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+		require.Len(t, lines, 1)
+
+		var e map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &e))
+		require.Equal(t, "progress", e["Event"])
+		require.Equal(t, "recordings", e["Unit"])
+		require.Equal(t, float64(2), e["Total"])
+		require.Equal(t, float64(1), e["Done"])
+		require.Equal(t, float64(1), e["Failed"])
+		require.Equal(t, float64(3), e["Concurrency"])
+		require.Len(t, e["Transfers"], 1)
+		require.Equal(t, "2-0-1.flv", e["Transfers"].([]interface{})[0].(map[string]interface{})["Name"])
+	})
+}
@@ -0,0 +1,229 @@
+package progress
+
+import (
+	"sync"
+	"time"
+)
+
+// Tracker tracks the progress of the run over the list of the items, like
+// the recordings or the scanned addresses, and of the transfers in flight.
+// A nil Tracker tracks nothing.
+type Tracker struct {
+	unit string
+	now  func() time.Time
+
+	mu          sync.Mutex
+	started     time.Time
+	total       int
+	done        int
+	failed      int
+	bytes       int64 // of the finished transfers
+	concurrency int
+	transfers   []*Transfer
+	// the bytes and the duration of the media of the completed transfers,
+	// which estimate the size of the transfers not reporting it
+	mediaBytes    int64
+	mediaDuration time.Duration
+}
+
+func NewTracker(unit string) *Tracker {
+	return &Tracker{
+		unit:    unit,
+		now:     time.Now,
+		started: time.Now(),
+	}
+}
+
+// SetTotal sets the number of the items of the run, once it is known.
+func (t *Tracker) SetTotal(total int) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.total = total
+}
+
+// SetConcurrency sets the current number of the concurrent transfers,
+// reported when it is not zero.
+func (t *Tracker) SetConcurrency(concurrency int) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.concurrency = concurrency
+}
+
+// Done counts the finished item.
+func (t *Tracker) Done(failed bool) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.done++
+	if failed {
+		t.failed++
+	}
+}
+
+// Start starts tracking the transfer. The duration of the transferred media,
+// like of the recording, estimates the size of the transfer when it is not
+// known, 0 when it is not known either.
+func (t *Tracker) Start(name string, duration time.Duration) *Transfer {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tr := &Transfer{
+		tracker:  t,
+		name:     name,
+		duration: duration,
+		started:  t.now(),
+	}
+	t.transfers = append(t.transfers, tr)
+
+	return tr
+}
+
+// Transfer is the transfer in flight.
+type Transfer struct {
+	tracker  *Tracker
+	name     string
+	duration time.Duration
+	started  time.Time
+	bytes    int64
+	total    int64
+}
+
+// Update sets the transferred bytes and the size of the transfer, 0 when
+// it is not known.
+func (tr *Transfer) Update(bytes, total int64) {
+	if tr == nil {
+		return
+	}
+
+	tr.tracker.mu.Lock()
+	defer tr.tracker.mu.Unlock()
+
+	tr.bytes = bytes
+	tr.total = total
+}
+
+// Finish stops tracking the transfer. The completed transfer is used to
+// estimate the size of the other transfers.
+func (tr *Transfer) Finish(completed bool) {
+	if tr == nil {
+		return
+	}
+
+	t := tr.tracker
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i, other := range t.transfers {
+		if other == tr {
+			t.transfers = append(t.transfers[:i], t.transfers[i+1:]...)
+			break
+		}
+	}
+
+	t.bytes += tr.bytes
+	if completed && tr.duration > 0 && tr.bytes > 0 {
+		t.mediaBytes += tr.bytes
+		t.mediaDuration += tr.duration
+	}
+}
+
+// Snapshot describes the progress of the run.
+type Snapshot struct {
+	Unit        string
+	Total       int
+	Done        int
+	Failed      int
+	Bytes       int64
+	Rate        float64
+	Elapsed     time.Duration
+	ETA         time.Duration
+	Concurrency int
+	Transfers   []TransferSnapshot
+}
+
+// TransferSnapshot describes the progress of the transfer in flight. The
+// size is Estimated from the duration of its media when the transfer does
+// not report it.
+type TransferSnapshot struct {
+	Name      string
+	Bytes     int64
+	Total     int64
+	Estimated bool
+	Rate      float64
+	ETA       time.Duration
+}
+
+// Snapshot returns the current progress. The rates are in bytes per second
+// and the ETAs are 0 when they are not known.
+func (t *Tracker) Snapshot() Snapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	s := Snapshot{
+		Unit:        t.unit,
+		Total:       t.total,
+		Done:        t.done,
+		Failed:      t.failed,
+		Bytes:       t.bytes,
+		Elapsed:     now.Sub(t.started),
+		Concurrency: t.concurrency,
+	}
+
+	for _, tr := range t.transfers {
+		ts := TransferSnapshot{
+			Name:  tr.name,
+			Bytes: tr.bytes,
+			Total: tr.total,
+			Rate:  rate(tr.bytes, now.Sub(tr.started)),
+		}
+
+		// the estimate exceeded by the transfer is dropped
+		if ts.Total <= 0 && tr.duration > 0 && t.mediaDuration > 0 {
+			if estimate := int64(float64(t.mediaBytes) * float64(tr.duration) / float64(t.mediaDuration)); estimate > ts.Bytes {
+				ts.Total = estimate
+				ts.Estimated = true
+			}
+		}
+
+		if ts.Total > ts.Bytes && ts.Rate > 0 {
+			ts.ETA = time.Duration(float64(ts.Total-ts.Bytes) / ts.Rate * float64(time.Second))
+		}
+
+		s.Bytes += tr.bytes
+		s.Transfers = append(s.Transfers, ts)
+	}
+
+	s.Rate = rate(s.Bytes, s.Elapsed)
+	if s.Done > 0 && s.Total > s.Done {
+		s.ETA = time.Duration(int64(s.Elapsed) / int64(s.Done) * int64(s.Total-s.Done))
+	}
+
+	return s
+}
+
+func rate(bytes int64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return float64(bytes) / elapsed.Seconds()
+}
@@ -0,0 +1,107 @@
+package progress
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTracker(now *time.Time) *Tracker {
+	t := NewTracker("recordings")
+	t.now = func() time.Time { return *now }
+	t.started = *now
+
+	return t
+}
+
+func TestTracker(t *testing.T) {
+	t.Run("should count the items and estimate the end of the run", func(t *testing.T) {
+		now := time.Date(2021, 10, 22, 12, 0, 0, 0, time.UTC)
+		tracker := newTestTracker(&now)
+		tracker.SetTotal(4)
+
+		now = now.Add(10 * time.Second)
+		tracker.Done(false)
+
+		s := tracker.Snapshot()
+		require.Equal(t, "recordings", s.Unit)
+		require.Equal(t, 4, s.Total)
+		require.Equal(t, 1, s.Done)
+		require.Equal(t, 0, s.Failed)
+		require.Equal(t, 10*time.Second, s.Elapsed)
+		require.Equal(t, 30*time.Second, s.ETA)
+
+		tracker.Done(true)
+		s = tracker.Snapshot()
+		require.Equal(t, 2, s.Done)
+		require.Equal(t, 1, s.Failed)
+		require.Equal(t, 10*time.Second, s.ETA)
+	})
+
+	t.Run("should report the transfers in flight", func(t *testing.T) {
+		now := time.Date(2021, 10, 22, 12, 0, 0, 0, time.UTC)
+		tracker := newTestTracker(&now)
+
+		tr := tracker.Start("1-0-1.flv", 0)
+		now = now.Add(2 * time.Second)
+		tr.Update(1000, 4000)
+
+		s := tracker.Snapshot()
+		require.Len(t, s.Transfers, 1)
+		require.Equal(t, TransferSnapshot{
+			Name:  "1-0-1.flv",
+			Bytes: 1000,
+			Total: 4000,
+			Rate:  500,
+			ETA:   6 * time.Second,
+		}, s.Transfers[0])
+		require.Equal(t, int64(1000), s.Bytes)
+
+		tr.Finish(true)
+		s = tracker.Snapshot()
+		require.Empty(t, s.Transfers)
+		require.Equal(t, int64(1000), s.Bytes)
+	})
+
+	t.Run("should estimate the size from the duration of the completed transfers", func(t *testing.T) {
+		now := time.Date(2021, 10, 22, 12, 0, 0, 0, time.UTC)
+		tracker := newTestTracker(&now)
+
+		failed := tracker.Start("1-0-1.flv", time.Minute)
+		failed.Update(100, 0)
+		failed.Finish(false)
+
+		completed := tracker.Start("2-0-1.flv", time.Minute)
+		completed.Update(6000, 0)
+		completed.Finish(true)
+
+		tr := tracker.Start("3-0-1.flv", 2*time.Minute)
+		now = now.Add(time.Second)
+		tr.Update(2000, 0)
+
+		s := tracker.Snapshot()
+		require.Equal(t, int64(12000), s.Transfers[0].Total)
+		require.True(t, s.Transfers[0].Estimated)
+		require.Equal(t, 5*time.Second, s.Transfers[0].ETA)
+		require.Equal(t, int64(8100), s.Bytes)
+
+		tr.Update(13000, 0)
+		s = tracker.Snapshot()
+		require.Equal(t, int64(0), s.Transfers[0].Total)
+		require.False(t, s.Transfers[0].Estimated)
+	})
+
+	t.Run("should track nothing when nil", func(t *testing.T) {
+		var tracker *Tracker
+		tracker.SetTotal(1)
+		tracker.SetConcurrency(1)
+		tracker.Done(false)
+
+		tr := tracker.Start("1-0-1.flv", time.Minute)
+		tr.Update(1, 1)
+		tr.Finish(true)
+
+		require.Nil(t, tr)
+	})
+}
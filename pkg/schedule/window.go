@@ -0,0 +1,306 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const clockLayout = "15:04"
+
+// searchDays limits the search of the next window, the windows repeat every
+// week.
+const searchDays = 8
+
+var dayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// Days is the set of the week days, the bit 1<<time.Weekday is set for each
+// day. The empty set means every day.
+type Days uint8
+
+const (
+	Weekdays Days = 1<<time.Monday | 1<<time.Tuesday | 1<<time.Wednesday | 1<<time.Thursday | 1<<time.Friday
+	Weekends Days = 1<<time.Saturday | 1<<time.Sunday
+)
+
+// ParseDays parses the days like mon, mon-fri, sat+sun, weekdays or
+// weekends. The ranges and the days are joined with +.
+func ParseDays(value string) (Days, error) {
+	var days Days
+	for _, part := range strings.Split(strings.ToLower(value), "+") {
+		switch part {
+		case "weekdays":
+			days |= Weekdays
+			continue
+		case "weekends":
+			days |= Weekends
+			continue
+		}
+
+		bounds := strings.Split(part, "-")
+		if len(bounds) > 2 {
+			return 0, fmt.Errorf("invalid days %s", value)
+		}
+
+		first, err := parseDay(bounds[0])
+		if err != nil {
+			return 0, err
+		}
+
+		last := first
+		if len(bounds) == 2 {
+			if last, err = parseDay(bounds[1]); err != nil {
+				return 0, err
+			}
+		}
+
+		// the range may wrap the week, like fri-mon
+		for d := first; ; d = (d + 1) % 7 {
+			days |= 1 << uint(d)
+			if d == last {
+				break
+			}
+		}
+	}
+
+	return days, nil
+}
+
+func parseDay(value string) (time.Weekday, error) {
+	for i, name := range dayNames {
+		if value == name {
+			return time.Weekday(i), nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid day %s, specify one of %s", value, strings.Join(dayNames, ", "))
+}
+
+// Has reports whether the day is in the set.
+func (d Days) Has(day time.Weekday) bool {
+	return d == 0 || d&(1<<uint(day)) != 0
+}
+
+func (d Days) String() string {
+	var parts []string
+	for day := 0; day < 7; day++ {
+		if d&(1<<uint(day)) == 0 {
+			continue
+		}
+
+		last := day
+		for last < 6 && d&(1<<uint(last+1)) != 0 {
+			last++
+		}
+
+		if last > day {
+			parts = append(parts, dayNames[day]+"-"+dayNames[last])
+		} else {
+			parts = append(parts, dayNames[day])
+		}
+		day = last
+	}
+
+	return strings.Join(parts, "+")
+}
+
+// Window is the daily time range, like 06:00-20:00, optionally on the given
+// days only, like mon-fri 01:00-05:30. The window which ends before it
+// starts spans midnight, like 22:00-06:00, and belongs to the day it starts.
+type Window struct {
+	Days  Days
+	Start time.Duration // since midnight
+	End   time.Duration // since midnight
+}
+
+// ParseWindow parses the window in [<days> ]HH:MM-HH:MM format.
+func ParseWindow(value string) (Window, error) {
+	var days Days
+	value = strings.TrimSpace(value)
+	if fields := strings.Fields(value); len(fields) > 1 && strings.IndexAny(fields[0][:1], "0123456789") < 0 {
+		var err error
+		if days, err = ParseDays(fields[0]); err != nil {
+			return Window{}, err
+		}
+		value = strings.TrimSpace(strings.TrimPrefix(value, fields[0]))
+	}
+
+	parts := strings.Split(value, "-")
+	if len(parts) != 2 {
+		return Window{}, fmt.Errorf("the window %s is not in HH:MM-HH:MM format", value)
+	}
+
+	start, err := parseClock(parts[0])
+	if err != nil {
+		return Window{}, err
+	}
+
+	end, err := parseClock(parts[1])
+	if err != nil {
+		return Window{}, err
+	}
+
+	if start == end {
+		return Window{}, fmt.Errorf("the window %s is empty", value)
+	}
+
+	return Window{Days: days, Start: start, End: end}, nil
+}
+
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse(clockLayout, strings.TrimSpace(value))
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Contains reports whether the clock time of t is in the window. The start
+// is inclusive, the end is exclusive.
+func (w Window) Contains(t time.Time) bool {
+	clock := time.Duration(t.Hour())*time.Hour +
+		time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second
+
+	if w.Start < w.End {
+		return clock >= w.Start && clock < w.End && w.Days.Has(t.Weekday())
+	}
+
+	if clock >= w.Start {
+		return w.Days.Has(t.Weekday())
+	}
+
+	// the window spanning midnight belongs to the previous day
+	return clock < w.End && w.Days.Has((t.Weekday()+6)%7)
+}
+
+// on returns the start and end of the window opened on the day of the date,
+// it reports false when the window is not open on that day.
+func (w Window) on(date time.Time) (time.Time, time.Time, bool) {
+	if !w.Days.Has(date.Weekday()) {
+		return time.Time{}, time.Time{}, false
+	}
+
+	at := func(days int, clock time.Duration) time.Time {
+		return time.Date(date.Year(), date.Month(), date.Day()+days,
+			int(clock/time.Hour), int(clock%time.Hour/time.Minute), 0, 0, date.Location())
+	}
+
+	if w.Start < w.End {
+		return at(0, w.Start), at(0, w.End), true
+	}
+
+	return at(0, w.Start), at(1, w.End), true
+}
+
+func (w Window) String() string {
+	midnight := time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := midnight.Add(w.Start).Format(clockLayout) + "-" + midnight.Add(w.End).Format(clockLayout)
+
+	if w.Days == 0 {
+		return clock
+	}
+
+	return w.Days.String() + " " + clock
+}
+
+// Windows is the list of the windows. The empty list contains any time.
+type Windows []Window
+
+// ParseWindows parses the comma separated list of the windows.
+func ParseWindows(value string) (Windows, error) {
+	var windows Windows
+	for _, part := range strings.Split(value, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+
+		w, err := ParseWindow(part)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, w)
+	}
+
+	return windows, nil
+}
+
+func (ws Windows) Contains(t time.Time) bool {
+	if len(ws) == 0 {
+		return true
+	}
+
+	for _, w := range ws {
+		if w.Contains(t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Next returns the time, not before t, when any of the windows is open. It
+// returns t when there are no windows.
+func (ws Windows) Next(t time.Time) time.Time {
+	if ws.Contains(t) {
+		return t
+	}
+
+	var next time.Time
+	for days := 0; days < searchDays; days++ {
+		date := time.Date(t.Year(), t.Month(), t.Day()+days, 0, 0, 0, 0, t.Location())
+		for _, w := range ws {
+			start, _, ok := w.on(date)
+			if ok && start.After(t) && (next.IsZero() || start.Before(next)) {
+				next = start
+			}
+		}
+	}
+
+	return next
+}
+
+// End returns the time when the windows open at t close, the adjacent
+// windows are joined. It returns the zero time when there are no windows,
+// or when they are not open at t.
+func (ws Windows) End(t time.Time) time.Time {
+	if len(ws) == 0 {
+		return time.Time{}
+	}
+
+	end := t
+	for extended := true; extended; {
+		extended = false
+		for days := -1; days < searchDays; days++ {
+			date := time.Date(t.Year(), t.Month(), t.Day()+days, 0, 0, 0, 0, t.Location())
+			for _, w := range ws {
+				start, stop, ok := w.on(date)
+				if ok && !start.After(end) && stop.After(end) {
+					end = stop
+					extended = true
+				}
+			}
+		}
+
+		// the windows open all week long never close
+		if end.Sub(t) > searchDays*24*time.Hour {
+			break
+		}
+	}
+
+	if end.Equal(t) {
+		return time.Time{}
+	}
+
+	return end
+}
+
+func (ws Windows) String() string {
+	parts := make([]string, 0, len(ws))
+	for _, w := range ws {
+		parts = append(parts, w.String())
+	}
+
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,166 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWindow(t *testing.T) {
+	t.Run("should parse window", func(t *testing.T) {
+		w, err := ParseWindow("06:30-20:00")
+
+		require.NoError(t, err)
+		require.Equal(t, 6*time.Hour+30*time.Minute, w.Start)
+		require.Equal(t, 20*time.Hour, w.End)
+		require.Equal(t, "06:30-20:00", w.String())
+	})
+
+	t.Run("should parse window on days", func(t *testing.T) {
+		w, err := ParseWindow("Mon-Fri 01:00-05:30")
+
+		require.NoError(t, err)
+		require.Equal(t, Weekdays, w.Days)
+		require.Equal(t, 5*time.Hour+30*time.Minute, w.End)
+		require.Equal(t, "mon-fri 01:00-05:30", w.String())
+	})
+
+	t.Run("should return error for invalid window", func(t *testing.T) {
+		for _, value := range []string{"06:00", "6-20", "06:00-25:00", "08:00-08:00", "someday 01:00-05:00"} {
+			_, err := ParseWindow(value)
+
+			require.Error(t, err, value)
+		}
+	})
+}
+
+func TestParseDays(t *testing.T) {
+	t.Run("should parse days", func(t *testing.T) {
+		for value, expected := range map[string]string{
+			"mon":          "mon",
+			"weekdays":     "mon-fri",
+			"weekends":     "sun+sat",
+			"mon-wed+fri":  "mon-wed+fri",
+			"fri-mon":      "sun-mon+fri-sat",
+			"Sat+Sun+tue":  "sun+tue+sat",
+			"thu-thu+weds": "",
+		} {
+			days, err := ParseDays(value)
+			if expected == "" {
+				require.Error(t, err, value)
+				continue
+			}
+
+			require.NoError(t, err, value)
+			require.Equal(t, expected, days.String(), value)
+		}
+	})
+}
+
+func TestWindow_Contains(t *testing.T) {
+	day := func(hour, min int) time.Time {
+		return time.Date(2020, 6, 1, hour, min, 0, 0, time.Local)
+	}
+
+	t.Run("should contain time within the day window", func(t *testing.T) {
+		w := Window{Start: 6 * time.Hour, End: 20 * time.Hour}
+
+		require.True(t, w.Contains(day(6, 0)))
+		require.True(t, w.Contains(day(19, 59)))
+		require.False(t, w.Contains(day(20, 0)))
+		require.False(t, w.Contains(day(5, 59)))
+	})
+
+	t.Run("should contain time within the window spanning midnight", func(t *testing.T) {
+		w := Window{Start: 22 * time.Hour, End: 6 * time.Hour}
+
+		require.True(t, w.Contains(day(23, 0)))
+		require.True(t, w.Contains(day(1, 0)))
+		require.False(t, w.Contains(day(12, 0)))
+	})
+
+	t.Run("should contain time within the window on days", func(t *testing.T) {
+		// 2020-06-05 is Friday
+		friday := func(hour int) time.Time {
+			return time.Date(2020, 6, 5, hour, 0, 0, 0, time.Local)
+		}
+		w, err := ParseWindow("mon-fri 22:00-06:00")
+		require.NoError(t, err)
+
+		require.True(t, w.Contains(friday(23)))
+		require.True(t, w.Contains(friday(23).Add(6*time.Hour)))
+		require.False(t, w.Contains(friday(23).Add(24*time.Hour)))
+		// Monday morning belongs to the Sunday window
+		require.False(t, w.Contains(friday(1).Add(-96*time.Hour)))
+		require.True(t, w.Contains(friday(1).Add(-72*time.Hour)))
+	})
+}
+
+func TestWindows_Contains(t *testing.T) {
+	t.Run("should contain any time when there are no windows", func(t *testing.T) {
+		require.True(t, Windows(nil).Contains(time.Now()))
+	})
+
+	t.Run("should contain time within any of the windows", func(t *testing.T) {
+		ws, err := ParseWindows("06:00-10:00, 14:00-18:00")
+		require.NoError(t, err)
+
+		require.True(t, ws.Contains(time.Date(2020, 6, 1, 15, 0, 0, 0, time.Local)))
+		require.False(t, ws.Contains(time.Date(2020, 6, 1, 12, 0, 0, 0, time.Local)))
+		require.Equal(t, "06:00-10:00,14:00-18:00", ws.String())
+	})
+}
+
+func TestWindows_Next(t *testing.T) {
+	// 2020-06-05 is Friday
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2020, 6, day, hour, min, 0, 0, time.Local)
+	}
+
+	t.Run("should return the time within the window", func(t *testing.T) {
+		ws, err := ParseWindows("01:00-05:30")
+		require.NoError(t, err)
+
+		require.Equal(t, at(5, 2, 0), ws.Next(at(5, 2, 0)))
+		require.Equal(t, at(5, 2, 0), Windows(nil).Next(at(5, 2, 0)))
+	})
+
+	t.Run("should return the start of the next window", func(t *testing.T) {
+		ws, err := ParseWindows("weekdays 01:00-05:30, sat 12:00-14:00")
+		require.NoError(t, err)
+
+		require.Equal(t, at(6, 12, 0), ws.Next(at(5, 6, 0)))
+		require.Equal(t, at(8, 1, 0), ws.Next(at(6, 14, 0)))
+		require.Equal(t, at(5, 1, 0), ws.Next(at(4, 23, 0)))
+	})
+}
+
+func TestWindows_End(t *testing.T) {
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2020, 6, day, hour, min, 0, 0, time.Local)
+	}
+
+	t.Run("should return the end of the open window", func(t *testing.T) {
+		ws, err := ParseWindows("22:00-06:00")
+		require.NoError(t, err)
+
+		require.Equal(t, at(6, 6, 0), ws.End(at(5, 23, 0)))
+		require.Equal(t, at(6, 6, 0), ws.End(at(6, 1, 0)))
+	})
+
+	t.Run("should join the adjacent windows", func(t *testing.T) {
+		ws, err := ParseWindows("22:00-00:00, 00:00-02:00, 02:00-03:00")
+		require.NoError(t, err)
+
+		require.Equal(t, at(6, 3, 0), ws.End(at(5, 23, 0)))
+	})
+
+	t.Run("should return zero time outside of the windows", func(t *testing.T) {
+		ws, err := ParseWindows("22:00-06:00")
+		require.NoError(t, err)
+
+		require.True(t, ws.End(at(5, 12, 0)).IsZero())
+		require.True(t, Windows(nil).End(at(5, 12, 0)).IsZero())
+	})
+}
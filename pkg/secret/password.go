@@ -0,0 +1,60 @@
+package secret
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// ReadPassword reads the password from the first line of the reader, the
+// line ending is not a part of the password.
+func ReadPassword(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", fmt.Errorf("empty password")
+	}
+
+	return password, nil
+}
+
+// ReadPasswordFile reads the password from the file, like the Docker or
+// Kubernetes secret.
+func ReadPasswordFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	return ReadPassword(f)
+}
+
+// PromptPassword asks for the password on the terminal. The echo is disabled
+// with stty, which is not available on Windows, where the password is
+// visible while typing.
+func PromptPassword(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	defer fmt.Fprintln(os.Stderr)
+
+	if runtime.GOOS != "windows" && stty("-echo") == nil {
+		defer stty("echo")
+	}
+
+	return ReadPassword(os.Stdin)
+}
+
+func stty(arg string) error {
+	cmd := exec.Command("stty", arg)
+	cmd.Stdin = os.Stdin
+
+	return cmd.Run()
+}
@@ -0,0 +1,103 @@
+package secret
+
+import (
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Mask replaces the secrets in the logs, dumps and reports.
+const Mask = "******"
+
+var (
+	// credentialsPattern matches the credentials in the query strings, like
+	// u=admin&p=secret of the recordings download, and in the dumps of the
+	// command parameters, like Password=secret.
+	credentialsPattern = regexp.MustCompile(`(?i)(\b(?:u|p|usr|pwd|password|passwd)=)[^&\s"]+`)
+	// attributesPattern matches the non empty credentials in the XML
+	// requests, escapedAttributesPattern in their URL escaped form sent in
+	// the gw.cgi query string.
+	attributesPattern        = regexp.MustCompile(`(?i)(\b(?:usr|pwd|ddnsusr|ddnspwd)=")[^"]+(")`)
+	escapedAttributesPattern = regexp.MustCompile(`(?i)(\b(?:usr|pwd|ddnsusr|ddnspwd)%3D%22)(?:[^%]|%[^2]|%2[^2])+(%22)`)
+)
+
+var defaultRedactor = &Redactor{}
+
+// Redactor masks the credentials and the registered secret values in the
+// text.
+type Redactor struct {
+	mu     sync.RWMutex
+	values []string
+}
+
+// Register adds the secret values, like the password read from the file, so
+// they are masked wherever they appear.
+func (r *Redactor) Register(values ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+
+		r.values = append(r.values, value)
+		if escaped := url.QueryEscape(value); escaped != value {
+			r.values = append(r.values, escaped)
+		}
+	}
+
+	// the longer values first, so the value containing the other one is
+	// masked as a whole
+	sort.Slice(r.values, func(i, j int) bool {
+		return len(r.values[i]) > len(r.values[j])
+	})
+}
+
+func (r *Redactor) Redact(s string) string {
+	s = attributesPattern.ReplaceAllString(s, "${1}"+Mask+"${2}")
+	s = escapedAttributesPattern.ReplaceAllString(s, "${1}"+Mask+"${2}")
+	s = credentialsPattern.ReplaceAllString(s, "${1}"+Mask)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, value := range r.values {
+		s = strings.Replace(s, value, Mask, -1)
+	}
+
+	return s
+}
+
+// Register adds the secret values to the default redactor.
+func Register(values ...string) {
+	defaultRedactor.Register(values...)
+}
+
+// Redact masks the secrets known to the default redactor.
+func Redact(s string) string {
+	return defaultRedactor.Redact(s)
+}
+
+type writer struct {
+	w        io.Writer
+	redactor *Redactor
+}
+
+// NewWriter returns the writer masking the secrets of the default redactor,
+// like the output of the logger. Each write is redacted separately, so the
+// secret split between the writes is not masked.
+func NewWriter(w io.Writer) io.Writer {
+	return &writer{w: w, redactor: defaultRedactor}
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, w.redactor.Redact(string(p))); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
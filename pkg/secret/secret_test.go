@@ -0,0 +1,97 @@
+package secret
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactor_Redact(t *testing.T) {
+	t.Run("should mask credentials in the query string", func(t *testing.T) {
+		r := &Redactor{}
+
+		redacted := r.Redact(`Get "http://192.168.1.1/cgi-bin/flv.cgi?u=admin&p=s3cret&mode=time&chn=1": EOF`)
+
+		require.Equal(t, `Get "http://192.168.1.1/cgi-bin/flv.cgi?u=******&p=******&mode=time&chn=1": EOF`, redacted)
+	})
+
+	t.Run("should mask credentials in the escaped XML request", func(t *testing.T) {
+		r := &Redactor{}
+
+		redacted := r.Redact(`gw.cgi?xml=%3Cenvload+usr%3D%22admin%22+pwd%3D%22s3%25cret%22+type%3D%220%22+ddnspwd%3D%22%22`)
+
+		require.Equal(t, `gw.cgi?xml=%3Cenvload+usr%3D%22******%22+pwd%3D%22******%22+type%3D%220%22+ddnspwd%3D%22%22`, redacted)
+	})
+
+	t.Run("should mask credentials in the XML attributes", func(t *testing.T) {
+		r := &Redactor{}
+
+		redacted := r.Redact(`<network ddnsusr="user" ddnspwd="s3cret" ip="192.168.1.1">`)
+
+		require.Equal(t, `<network ddnsusr="******" ddnspwd="******" ip="192.168.1.1">`, redacted)
+	})
+
+	t.Run("should mask password in the parameters dump", func(t *testing.T) {
+		r := &Redactor{}
+
+		redacted := r.Redact("Address=192.168.1.1 Password=s3cret Timeout=5s PasswordFile= Username=admin")
+
+		require.Equal(t, "Address=192.168.1.1 Password=****** Timeout=5s PasswordFile= Username=admin", redacted)
+	})
+
+	t.Run("should mask registered values", func(t *testing.T) {
+		r := &Redactor{}
+		r.Register("p&ss word", "")
+
+		redacted := r.Redact("login failed for p&ss word, sent p%26ss+word")
+
+		require.Equal(t, "login failed for ******, sent ******", redacted)
+	})
+}
+
+func TestNewWriter(t *testing.T) {
+	t.Run("should mask secrets written to the underlying writer", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := NewWriter(&buf)
+
+		n, err := w.Write([]byte("flv.cgi?u=admin&p=s3cret\n"))
+
+		require.NoError(t, err)
+		require.Equal(t, 25, n)
+		require.Equal(t, "flv.cgi?u=******&p=******\n", buf.String())
+	})
+}
+
+func TestReadPassword(t *testing.T) {
+	t.Run("should read the first line without line ending", func(t *testing.T) {
+		password, err := ReadPassword(strings.NewReader("s3cret\r\nignored\n"))
+
+		require.NoError(t, err)
+		require.Equal(t, "s3cret", password)
+	})
+
+	t.Run("should return error for empty password", func(t *testing.T) {
+		_, err := ReadPassword(strings.NewReader("\n"))
+
+		require.Error(t, err)
+	})
+
+	t.Run("should read password from the file", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "secret")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		fp := path.Join(dir, "password")
+		require.NoError(t, ioutil.WriteFile(fp, []byte("s3cret"), 0600))
+
+		password, err := ReadPasswordFile(fp)
+
+		require.NoError(t, err)
+		require.Equal(t, "s3cret", password)
+	})
+}
@@ -0,0 +1,123 @@
+package sink
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Local stores the recordings in the directory.
+type Local struct {
+	Dir string
+}
+
+func NewLocal(dir string) *Local {
+	return &Local{Dir: dir}
+}
+
+func (l *Local) path(name string) string {
+	return filepath.Join(l.Dir, filepath.FromSlash(name))
+}
+
+func (l *Local) Exists(name string) (bool, error) {
+	return fileExists(l.path(name))
+}
+
+// Create writes the recording to the temporary file next to its path, which
+// is renamed when the writer is closed.
+func (l *Local) Create(name string) (Writer, error) {
+	fp := l.path(name)
+	if err := os.MkdirAll(filepath.Dir(fp), 0755); err != nil {
+		return nil, err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(fp), filepath.Base(fp)+".part")
+	if err != nil {
+		return nil, err
+	}
+
+	return &localWriter{File: f, path: fp}, nil
+}
+
+func (l *Local) Move(src, dst string) (bool, error) {
+	srcPath, dstPath := l.path(src), l.path(dst)
+
+	exists, err := fileExists(srcPath)
+	if err != nil || !exists {
+		return false, err
+	}
+
+	if exists, err = fileExists(dstPath); err != nil || exists {
+		return false, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+		return false, err
+	}
+
+	if err := os.Rename(srcPath, dstPath); err != nil {
+		return false, err
+	}
+
+	RemoveEmptyDirs(filepath.Dir(srcPath), l.Dir)
+
+	return true, nil
+}
+
+// RemoveEmptyDirs removes the directory and its parents below the root, as
+// long as they are empty after the recordings were moved out of them.
+func RemoveEmptyDirs(dir, root string) {
+	root = filepath.Clean(root)
+	for dir != root && dir != "." && dir != filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+func (l *Local) Close() error {
+	return nil
+}
+
+func (l *Local) String() string {
+	return l.Dir
+}
+
+type localWriter struct {
+	*os.File
+	path string
+}
+
+func (w *localWriter) Close() error {
+	if err := w.File.Close(); err != nil {
+		os.Remove(w.File.Name())
+		return err
+	}
+
+	// the temporary file is readable only by its owner
+	if err := os.Chmod(w.File.Name(), 0644); err != nil {
+		os.Remove(w.File.Name())
+		return err
+	}
+
+	return os.Rename(w.File.Name(), w.path)
+}
+
+func (w *localWriter) Abort() error {
+	w.File.Close()
+
+	return os.Remove(w.File.Name())
+}
+
+func fileExists(fp string) (bool, error) {
+	_, err := os.Stat(fp)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,98 @@
+package sink
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocal(t *testing.T) {
+	t.Run("should store recording when writer is closed", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "sink")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		l := NewLocal(dir)
+		w, err := l.Create("dev/2020-01-02/1.flv")
+		require.NoError(t, err)
+		_, err = w.Write([]byte("data"))
+		require.NoError(t, err)
+
+		exists, err := l.Exists("dev/2020-01-02/1.flv")
+		require.NoError(t, err)
+		require.False(t, exists)
+
+		require.NoError(t, w.Close())
+
+		exists, err = l.Exists("dev/2020-01-02/1.flv")
+		require.NoError(t, err)
+		require.True(t, exists)
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, "dev", "2020-01-02", "1.flv"))
+		require.NoError(t, err)
+		require.Equal(t, "data", string(data))
+	})
+
+	t.Run("should discard recording when writer is aborted", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "sink")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		l := NewLocal(dir)
+		w, err := l.Create("dev/1.flv")
+		require.NoError(t, err)
+		_, err = w.Write([]byte("data"))
+		require.NoError(t, err)
+		require.NoError(t, w.Abort())
+
+		files, err := ioutil.ReadDir(filepath.Join(dir, "dev"))
+		require.NoError(t, err)
+		require.Empty(t, files)
+	})
+
+	t.Run("should move recording and remove empty directories", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "sink")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		l := NewLocal(dir)
+		w, err := l.Create("old/dev/1.flv")
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		moved, err := l.Move("old/dev/1.flv", "new/1.flv")
+		require.NoError(t, err)
+		require.True(t, moved)
+
+		exists, err := l.Exists("new/1.flv")
+		require.NoError(t, err)
+		require.True(t, exists)
+
+		_, err = os.Stat(filepath.Join(dir, "old"))
+		require.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("should not move recording over existing one", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "sink")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		l := NewLocal(dir)
+		for _, name := range []string{"a.flv", "b.flv"} {
+			w, err := l.Create(name)
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+		}
+
+		moved, err := l.Move("a.flv", "b.flv")
+		require.NoError(t, err)
+		require.False(t, moved)
+
+		moved, err = l.Move("missing.flv", "c.flv")
+		require.NoError(t, err)
+		require.False(t, moved)
+	})
+}
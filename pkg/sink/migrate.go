@@ -0,0 +1,30 @@
+package sink
+
+import (
+	dc "github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/crabtree/defeway-toolbox/pkg/layout"
+)
+
+// Migrate moves the recording stored with one of the previous layouts to
+// the path dst of the current one. It returns the paths the recording was
+// moved from.
+func Migrate(m Mover, from []*layout.Template, device layout.Device, recMeta dc.RecordingMeta, dst string) ([]string, error) {
+	var moved []string
+	for _, tmpl := range from {
+		src, err := tmpl.Render(device, recMeta)
+		if err != nil || src == dst {
+			continue
+		}
+
+		ok, err := m.Move(src, dst)
+		if err != nil {
+			return moved, err
+		}
+
+		if ok {
+			moved = append(moved, src)
+		}
+	}
+
+	return moved, nil
+}
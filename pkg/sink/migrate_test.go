@@ -0,0 +1,86 @@
+package sink
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	dc "github.com/crabtree/defeway-toolbox/pkg/defewayclient"
+	"github.com/crabtree/defeway-toolbox/pkg/layout"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	device := layout.Device{
+		Address: "192.168.1.10-60001",
+		Date:    time.Date(2021, 10, 22, 0, 0, 0, 0, time.UTC),
+		Device:  "AA000000000001",
+		Serial:  "AA000000000001",
+	}
+	rec := dc.RecordingMeta{RecordingID: 7, ChannelID: 1, TypeID: 2}
+
+	legacy := func(t *testing.T, templates ...string) []*layout.Template {
+		var from []*layout.Template
+		for _, s := range templates {
+			tmpl, err := layout.Parse(s)
+			require.NoError(t, err)
+			from = append(from, tmpl)
+		}
+
+		return from
+	}
+
+	t.Run("should move the recording from the <ip>-<port> directory to the device directory", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "sink")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		archived := filepath.Join(dir, "192.168.1.10-60001", "2021-10-22", "7-1-2.flv")
+		require.NoError(t, os.MkdirAll(filepath.Dir(archived), 0755))
+		require.NoError(t, ioutil.WriteFile(archived, []byte("data"), 0644))
+
+		moved, err := Migrate(NewLocal(dir), legacy(t, layout.Legacy, layout.LegacyAddress), device, rec, "AA000000000001/2021-10-22/7-1-2.flv")
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"192.168.1.10-60001/2021-10-22/7-1-2.flv"}, moved)
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, "AA000000000001", "2021-10-22", "7-1-2.flv"))
+		require.NoError(t, err)
+		require.Equal(t, "data", string(data))
+
+		_, err = os.Stat(filepath.Join(dir, "192.168.1.10-60001"))
+		require.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("should move the recording from any legacy layout", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "sink")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		archived := filepath.Join(dir, "192.168.1.10-60001", "2021-10-22", "7.flv")
+		require.NoError(t, os.MkdirAll(filepath.Dir(archived), 0755))
+		require.NoError(t, ioutil.WriteFile(archived, []byte("data"), 0644))
+
+		moved, err := Migrate(NewLocal(dir), legacy(t, layout.Legacies...), device, rec, "AA000000000001/2021-10-22/7-1-2.flv")
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"192.168.1.10-60001/2021-10-22/7.flv"}, moved)
+
+		exists, err := NewLocal(dir).Exists("AA000000000001/2021-10-22/7-1-2.flv")
+		require.NoError(t, err)
+		require.True(t, exists)
+	})
+
+	t.Run("should not move the recording already in place", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "sink")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		moved, err := Migrate(NewLocal(dir), legacy(t, layout.Legacy), device, rec, "AA000000000001/2021-10-22/7-1-2.flv")
+
+		require.NoError(t, err)
+		require.Empty(t, moved)
+	})
+}
@@ -0,0 +1,305 @@
+package sink
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultS3Region   = "us-east-1"
+	DefaultS3PartSize = 8 << 20
+	// MinS3PartSize is the smallest part of the multipart upload accepted
+	// by S3, but the last one.
+	MinS3PartSize = 5 << 20
+)
+
+// S3Config holds the configuration of the S3 compatible object storage. The
+// objects are addressed path style, as <endpoint>/<bucket>/<key>, which is
+// supported by S3 and by the self-hosted storages, like MinIO.
+type S3Config struct {
+	Endpoint   string
+	Region     string
+	Bucket     string
+	Prefix     string
+	AccessKey  string
+	SecretKey  string
+	PartSize   int
+	HTTPClient *http.Client
+}
+
+// S3 stores the recordings in the S3 compatible object storage. The
+// recordings are uploaded in parts, so they are never buffered in whole.
+type S3 struct {
+	endpoint *url.URL
+	bucket   string
+	prefix   string
+	partSize int
+	client   *http.Client
+	signer   *signer
+	now      func() time.Time
+}
+
+func NewS3(config S3Config) (*S3, error) {
+	if config.Endpoint == "" {
+		return nil, fmt.Errorf("specify S3 endpoint")
+	}
+	if config.Bucket == "" {
+		return nil, fmt.Errorf("specify S3 bucket")
+	}
+	if config.AccessKey == "" || config.SecretKey == "" {
+		return nil, fmt.Errorf("specify S3 access key and secret key")
+	}
+
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint: %w", err)
+	}
+	if endpoint.Scheme != "http" && endpoint.Scheme != "https" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q: use http or https scheme", config.Endpoint)
+	}
+
+	region := config.Region
+	if region == "" {
+		region = DefaultS3Region
+	}
+
+	partSize := config.PartSize
+	if partSize == 0 {
+		partSize = DefaultS3PartSize
+	}
+	if partSize < MinS3PartSize {
+		return nil, fmt.Errorf("S3 part size must be at least %d bytes", MinS3PartSize)
+	}
+
+	client := config.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &S3{
+		endpoint: endpoint,
+		bucket:   config.Bucket,
+		prefix:   strings.Trim(config.Prefix, "/"),
+		partSize: partSize,
+		client:   client,
+		signer: &signer{
+			accessKey: config.AccessKey,
+			secretKey: config.SecretKey,
+			region:    region,
+			service:   "s3",
+		},
+		now: time.Now,
+	}, nil
+}
+
+func (s *S3) String() string {
+	return "s3://" + path.Join(s.bucket, s.prefix)
+}
+
+func (s *S3) Exists(name string) (bool, error) {
+	resp, err := s.do(http.MethodHead, name, nil, nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+
+	return false, s.responseError(http.MethodHead, name, resp)
+}
+
+// Create returns the writer which uploads the recording in parts as they
+// fill. The recording smaller than a part is uploaded with a single request.
+func (s *S3) Create(name string) (Writer, error) {
+	return &s3Writer{s3: s, name: name}, nil
+}
+
+func (s *S3) Close() error {
+	return nil
+}
+
+func (s *S3) key(name string) string {
+	return path.Join(s.prefix, name)
+}
+
+func (s *S3) url(name string, query url.Values) *url.URL {
+	u := *s.endpoint
+	u.Path = "/" + path.Join(strings.Trim(u.Path, "/"), s.bucket, s.key(name))
+	u.RawPath = awsEscape(u.Path, true)
+	u.RawQuery = strings.Replace(query.Encode(), "+", "%20", -1)
+
+	return &u
+}
+
+func (s *S3) do(method, name string, query url.Values, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, s.url(name, query).String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		req.Body = nil
+	}
+
+	s.signer.sign(req, hashHex(body), s.now())
+
+	return s.client.Do(req)
+}
+
+// call sends the request and returns the body of the successful response.
+func (s *S3) call(method, name string, query url.Values, body []byte) (http.Header, []byte, error) {
+	resp, err := s.do(method, name, query, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return nil, nil, s.responseError(method, name, resp)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resp.Header, data, nil
+}
+
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func (s *S3) responseError(method, name string, resp *http.Response) error {
+	data, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+	var e s3Error
+	if err := xml.Unmarshal(data, &e); err == nil && e.Code != "" {
+		return fmt.Errorf("%s %s: %s: %s", method, s.key(name), e.Code, e.Message)
+	}
+
+	return fmt.Errorf("%s %s: unexpected status %s", method, s.key(name), resp.Status)
+}
+
+type s3Part struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type s3InitiateResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+type s3CompleteRequest struct {
+	XMLName xml.Name `xml:"CompleteMultipartUpload"`
+	Parts   []s3Part `xml:"Part"`
+}
+
+type s3Writer struct {
+	s3       *S3
+	name     string
+	buf      []byte
+	uploadID string
+	parts    []s3Part
+}
+
+func (w *s3Writer) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+
+	for len(w.buf) >= w.s3.partSize {
+		if err := w.uploadPart(w.buf[:w.s3.partSize]); err != nil {
+			return 0, err
+		}
+		w.buf = append(w.buf[:0], w.buf[w.s3.partSize:]...)
+	}
+
+	return len(p), nil
+}
+
+func (w *s3Writer) uploadPart(data []byte) error {
+	if w.uploadID == "" {
+		_, body, err := w.s3.call(http.MethodPost, w.name, url.Values{"uploads": {""}}, nil)
+		if err != nil {
+			return err
+		}
+
+		var result s3InitiateResult
+		if err := xml.Unmarshal(body, &result); err != nil || result.UploadID == "" {
+			return fmt.Errorf("invalid response of multipart upload of %s", w.s3.key(w.name))
+		}
+		w.uploadID = result.UploadID
+	}
+
+	number := len(w.parts) + 1
+	header, _, err := w.s3.call(http.MethodPut, w.name, url.Values{
+		"partNumber": {strconv.Itoa(number)},
+		"uploadId":   {w.uploadID},
+	}, data)
+	if err != nil {
+		return err
+	}
+
+	w.parts = append(w.parts, s3Part{PartNumber: number, ETag: header.Get("ETag")})
+
+	return nil
+}
+
+// Close uploads the rest of the recording and completes the upload.
+func (w *s3Writer) Close() error {
+	if w.uploadID == "" {
+		_, _, err := w.s3.call(http.MethodPut, w.name, nil, w.buf)
+		return err
+	}
+
+	if len(w.buf) > 0 {
+		if err := w.uploadPart(w.buf); err != nil {
+			return err
+		}
+		w.buf = nil
+	}
+
+	body, err := xml.Marshal(s3CompleteRequest{Parts: w.parts})
+	if err != nil {
+		return err
+	}
+
+	_, data, err := w.s3.call(http.MethodPost, w.name, url.Values{"uploadId": {w.uploadID}}, body)
+	if err != nil {
+		return err
+	}
+
+	// the completion may fail after the response has started, in which
+	// case the error is sent in the body of the successful response
+	var e s3Error
+	if err := xml.Unmarshal(data, &e); err == nil && e.Code != "" {
+		return fmt.Errorf("%s %s: %s: %s", http.MethodPost, w.s3.key(w.name), e.Code, e.Message)
+	}
+
+	return nil
+}
+
+// Abort discards the uploaded parts of the recording.
+func (w *s3Writer) Abort() error {
+	w.buf = nil
+	if w.uploadID == "" {
+		return nil
+	}
+
+	_, _, err := w.s3.call(http.MethodDelete, w.name, url.Values{"uploadId": {w.uploadID}}, nil)
+
+	return err
+}
@@ -0,0 +1,213 @@
+package sink
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 implements the subset of the S3 API used by the sink.
+type fakeS3 struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	uploads  map[string]map[int][]byte
+	aborted  int
+	requests []string
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{
+		objects: make(map[string][]byte),
+		uploads: make(map[string]map[int][]byte),
+	}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	body, _ := ioutil.ReadAll(r.Body)
+	if r.Header.Get("X-Amz-Content-Sha256") != hashHex(body) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	uploadID := query.Get("uploadId")
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+
+	switch {
+	case r.Method == http.MethodHead:
+		if _, ok := f.objects[r.URL.Path]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case r.Method == http.MethodPost && query["uploads"] != nil:
+		id := strconv.Itoa(len(f.uploads) + 1)
+		f.uploads[id] = make(map[int][]byte)
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>", id)
+	case r.Method == http.MethodPut && uploadID != "":
+		number, _ := strconv.Atoi(query.Get("partNumber"))
+		f.uploads[uploadID][number] = body
+		w.Header().Set("ETag", fmt.Sprintf(`"%d"`, number))
+	case r.Method == http.MethodPut:
+		f.objects[r.URL.Path] = body
+	case r.Method == http.MethodPost && uploadID != "":
+		var req s3CompleteRequest
+		if err := xml.Unmarshal(body, &req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var data []byte
+		for _, part := range req.Parts {
+			data = append(data, f.uploads[uploadID][part.PartNumber]...)
+		}
+		f.objects[r.URL.Path] = data
+		delete(f.uploads, uploadID)
+	case r.Method == http.MethodDelete && uploadID != "":
+		delete(f.uploads, uploadID)
+		f.aborted++
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func newTestS3(t *testing.T, url string) *S3 {
+	s, err := NewS3(S3Config{
+		Endpoint:  url,
+		Bucket:    "bucket",
+		Prefix:    "/archive/",
+		AccessKey: "access",
+		SecretKey: "secret",
+	})
+	require.NoError(t, err)
+	s.partSize = 4
+
+	return s
+}
+
+func TestS3(t *testing.T) {
+	t.Run("should upload small recording with single request", func(t *testing.T) {
+		fake := newFakeS3()
+		server := httptest.NewServer(fake)
+		defer server.Close()
+		s := newTestS3(t, server.URL)
+
+		exists, err := s.Exists("dev/1 a.flv")
+		require.NoError(t, err)
+		require.False(t, exists)
+
+		w, err := s.Create("dev/1 a.flv")
+		require.NoError(t, err)
+		_, err = w.Write([]byte("abc"))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		exists, err = s.Exists("dev/1 a.flv")
+		require.NoError(t, err)
+		require.True(t, exists)
+
+		require.Equal(t, []byte("abc"), fake.objects["/bucket/archive/dev/1 a.flv"])
+		require.Equal(t, []string{
+			"HEAD /bucket/archive/dev/1 a.flv",
+			"PUT /bucket/archive/dev/1 a.flv",
+			"HEAD /bucket/archive/dev/1 a.flv",
+		}, fake.requests)
+	})
+
+	t.Run("should upload large recording in parts", func(t *testing.T) {
+		fake := newFakeS3()
+		server := httptest.NewServer(fake)
+		defer server.Close()
+		s := newTestS3(t, server.URL)
+
+		w, err := s.Create("dev/1.flv")
+		require.NoError(t, err)
+		_, err = w.Write([]byte("abcdef"))
+		require.NoError(t, err)
+		_, err = w.Write([]byte("ghijk"))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		require.Equal(t, []byte("abcdefghijk"), fake.objects["/bucket/archive/dev/1.flv"])
+		require.Equal(t, []string{
+			"POST /bucket/archive/dev/1.flv",
+			"PUT /bucket/archive/dev/1.flv",
+			"PUT /bucket/archive/dev/1.flv",
+			"PUT /bucket/archive/dev/1.flv",
+			"POST /bucket/archive/dev/1.flv",
+		}, fake.requests)
+		require.Empty(t, fake.uploads)
+	})
+
+	t.Run("should abort multipart upload", func(t *testing.T) {
+		fake := newFakeS3()
+		server := httptest.NewServer(fake)
+		defer server.Close()
+		s := newTestS3(t, server.URL)
+
+		w, err := s.Create("dev/1.flv")
+		require.NoError(t, err)
+		_, err = w.Write(bytes.Repeat([]byte("a"), 10))
+		require.NoError(t, err)
+		require.NoError(t, w.Abort())
+
+		require.Empty(t, fake.objects)
+		require.Empty(t, fake.uploads)
+		require.Equal(t, 1, fake.aborted)
+	})
+
+	t.Run("should return error of rejected request", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, "<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>")
+		}))
+		defer server.Close()
+		s := newTestS3(t, server.URL)
+
+		w, err := s.Create("dev/1.flv")
+		require.NoError(t, err)
+		err = w.Close()
+		require.EqualError(t, err, "PUT archive/dev/1.flv: AccessDenied: Access Denied")
+	})
+
+	t.Run("should reject part size below the minimum", func(t *testing.T) {
+		_, err := NewS3(S3Config{
+			Endpoint:  "http://localhost:9000",
+			Bucket:    "bucket",
+			AccessKey: "access",
+			SecretKey: "secret",
+			PartSize:  1024,
+		})
+		require.Error(t, err)
+	})
+}
+
+func TestOpen(t *testing.T) {
+	t.Run("should open sink of output", func(t *testing.T) {
+		s, err := Open("s3://bucket/some/prefix/", S3Config{
+			Endpoint:  "http://localhost:9000",
+			AccessKey: "access",
+			SecretKey: "secret",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "s3://bucket/some/prefix", s.String())
+
+		s, err = Open("out", S3Config{})
+		require.NoError(t, err)
+		require.Equal(t, NewLocal("out"), s)
+	})
+}
@@ -0,0 +1,63 @@
+package sink
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Sink stores the downloaded recordings under the slash separated paths
+// relative to its root.
+type Sink interface {
+	// Exists reports whether the recording is already stored.
+	Exists(name string) (bool, error)
+	// Create returns the writer of the recording. The recording is stored
+	// when the writer is closed and discarded when it is aborted, so the
+	// interrupted download does not leave the partial recording behind.
+	Create(name string) (Writer, error)
+	// Close completes the sink, like the end of the tar archive.
+	Close() error
+	String() string
+}
+
+type Writer interface {
+	io.Writer
+	Close() error
+	Abort() error
+}
+
+// Mover is implemented by the sinks which can move the stored recordings,
+// which is used to migrate the layout of the archive.
+type Mover interface {
+	// Move moves the recording, unless the source does not exist or the
+	// destination exists. It reports whether the recording was moved.
+	Move(src, dst string) (bool, error)
+}
+
+// Open returns the sink of the output, which is the local directory, the tar
+// archive given as tar:<path> or tar:- for the standard output, or the S3
+// bucket given as s3://<bucket>/<prefix>.
+func Open(output string, s3 S3Config) (Sink, error) {
+	switch {
+	case output == "":
+		return nil, fmt.Errorf("specify output")
+	case output == "tar:-":
+		return NewTar(os.Stdout), nil
+	case strings.HasPrefix(output, "tar:"):
+		return CreateTar(strings.TrimPrefix(output, "tar:"))
+	case strings.HasPrefix(output, "s3://"):
+		bucket := strings.TrimPrefix(output, "s3://")
+		prefix := ""
+		if i := strings.IndexByte(bucket, '/'); i >= 0 {
+			bucket, prefix = bucket[:i], strings.Trim(bucket[i+1:], "/")
+		}
+
+		s3.Bucket = bucket
+		s3.Prefix = prefix
+
+		return NewS3(s3)
+	}
+
+	return NewLocal(output), nil
+}
@@ -0,0 +1,126 @@
+package sink
+
+import (
+	"archive/tar"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// Tar streams the recordings into the tar archive, like the standard output
+// piped to the other host. The tar header needs the size of the recording,
+// so each recording is buffered in the temporary file until it is complete.
+type Tar struct {
+	mu      sync.Mutex
+	tw      *tar.Writer
+	closer  io.Closer
+	name    string
+	written map[string]bool
+}
+
+func NewTar(w io.Writer) *Tar {
+	return &Tar{
+		tw:      tar.NewWriter(w),
+		name:    "tar:-",
+		written: make(map[string]bool),
+	}
+}
+
+// CreateTar creates the tar archive file.
+func CreateTar(fp string) (*Tar, error) {
+	f, err := os.Create(fp)
+	if err != nil {
+		return nil, err
+	}
+
+	t := NewTar(f)
+	t.closer = f
+	t.name = "tar:" + fp
+
+	return t, nil
+}
+
+// Exists reports whether the recording was written to the archive, the
+// archive is always created from scratch.
+func (t *Tar) Exists(name string) (bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.written[name], nil
+}
+
+func (t *Tar) Create(name string) (Writer, error) {
+	f, err := ioutil.TempFile("", "defeway-tar")
+	if err != nil {
+		return nil, err
+	}
+
+	return &tarWriter{File: f, tar: t, name: name}, nil
+}
+
+func (t *Tar) add(name string, f *os.File) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    info.Size(),
+		ModTime: time.Now(),
+	}
+	if err := t.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(t.tw, f); err != nil {
+		return err
+	}
+	t.written[name] = true
+
+	return nil
+}
+
+func (t *Tar) Close() error {
+	err := t.tw.Close()
+	if t.closer != nil {
+		if closeErr := t.closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	return err
+}
+
+func (t *Tar) String() string {
+	return t.name
+}
+
+type tarWriter struct {
+	*os.File
+	tar  *Tar
+	name string
+}
+
+func (w *tarWriter) Close() error {
+	defer os.Remove(w.File.Name())
+	defer w.File.Close()
+
+	return w.tar.add(w.name, w.File)
+}
+
+func (w *tarWriter) Abort() error {
+	w.File.Close()
+
+	return os.Remove(w.File.Name())
+}
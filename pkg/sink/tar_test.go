@@ -0,0 +1,51 @@
+package sink
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTar(t *testing.T) {
+	t.Run("should write closed recordings to archive", func(t *testing.T) {
+		var buf bytes.Buffer
+		s := NewTar(&buf)
+
+		w, err := s.Create("dev/1.flv")
+		require.NoError(t, err)
+		_, err = w.Write([]byte("first"))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		w, err = s.Create("dev/2.flv")
+		require.NoError(t, err)
+		_, err = w.Write([]byte("partial"))
+		require.NoError(t, err)
+		require.NoError(t, w.Abort())
+
+		exists, err := s.Exists("dev/1.flv")
+		require.NoError(t, err)
+		require.True(t, exists)
+		exists, err = s.Exists("dev/2.flv")
+		require.NoError(t, err)
+		require.False(t, exists)
+
+		require.NoError(t, s.Close())
+
+		tr := tar.NewReader(&buf)
+		hdr, err := tr.Next()
+		require.NoError(t, err)
+		require.Equal(t, "dev/1.flv", hdr.Name)
+		require.Equal(t, int64(5), hdr.Size)
+		data, err := ioutil.ReadAll(tr)
+		require.NoError(t, err)
+		require.Equal(t, "first", string(data))
+
+		_, err = tr.Next()
+		require.Equal(t, io.EOF, err)
+	})
+}
@@ -0,0 +1,170 @@
+package snapshot
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"math"
+)
+
+type Verdict string
+
+const (
+	VerdictOK          Verdict = "ok"
+	VerdictBlack       Verdict = "black"
+	VerdictBlue        Verdict = "blue"
+	VerdictOverexposed Verdict = "overexposed"
+	VerdictUniform     Verdict = "uniform"
+)
+
+const (
+	samplesPerAxis = 64
+	thumbnailSize  = 16
+	hashSize       = 8
+
+	uniformMaxStdDev   = 6.0
+	blackMaxMean       = 25.0
+	blueMinDominance   = 40.0
+	overexposedMinMean = 235.0
+	// the DVR encodes the frozen frame again into the same JPEG, which does
+	// not differ at all, while the captures of the live static scene differ
+	// by the sensor noise, about 0.15 even for the faint noise
+	frozenMaxDiff = 0.05
+)
+
+// Analysis holds the luminance statistics of the snapshot. The luminance is
+// in 0-255 range.
+type Analysis struct {
+	Width     int
+	Height    int
+	Mean      float64
+	StdDev    float64
+	MeanR     float64
+	MeanG     float64
+	MeanB     float64
+	Sharpness float64 // mean squared gradient between neighbouring pixels
+	Hash      uint64
+	Verdict   Verdict
+	Thumbnail []float64 `json:"-"`
+}
+
+// Analyze decodes the JPEG snapshot and checks whether it is a near uniform
+// frame, like the black or blue "no video" screen of the DVR.
+func Analyze(data []byte) (*Analysis, error) {
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	return AnalyzeImage(img), nil
+}
+
+func AnalyzeImage(img image.Image) *Analysis {
+	bounds := img.Bounds()
+	a := &Analysis{
+		Width:  bounds.Dx(),
+		Height: bounds.Dy(),
+	}
+
+	if a.Width == 0 || a.Height == 0 {
+		a.Verdict = VerdictUniform
+		return a
+	}
+
+	stepX := maxInt(a.Width/samplesPerAxis, 1)
+	stepY := maxInt(a.Height/samplesPerAxis, 1)
+	g := &grid{}
+	var sum, sumSq, gradient float64
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += stepY {
+		g.rows++
+		for x := bounds.Min.X; x < bounds.Max.X; x += stepX {
+			lum, rf, gf, bf := luminance(img, x, y)
+
+			sum += lum
+			sumSq += lum * lum
+			a.MeanR += rf
+			a.MeanG += gf
+			a.MeanB += bf
+			g.values = append(g.values, lum)
+
+			if x+1 < bounds.Max.X && y+1 < bounds.Max.Y {
+				right, _, _, _ := luminance(img, x+1, y)
+				below, _, _, _ := luminance(img, x, y+1)
+				gradient += (right-lum)*(right-lum) + (below-lum)*(below-lum)
+			}
+		}
+	}
+	g.cols = len(g.values) / g.rows
+
+	n := float64(len(g.values))
+	a.Mean = sum / n
+	a.StdDev = math.Sqrt(math.Max(sumSq/n-a.Mean*a.Mean, 0))
+	a.MeanR /= n
+	a.MeanG /= n
+	a.MeanB /= n
+
+	a.Thumbnail = g.resample(thumbnailSize, thumbnailSize)
+	a.Hash = differenceHash(g.resample(hashSize+1, hashSize))
+	a.Sharpness = gradient / n
+	a.Verdict = a.verdict()
+
+	return a
+}
+
+// luminance returns the luminance and the color components of the pixel.
+func luminance(img image.Image, x, y int) (float64, float64, float64, float64) {
+	r, g, b, _ := img.At(x, y).RGBA()
+	rf, gf, bf := float64(r>>8), float64(g>>8), float64(b>>8)
+
+	return 0.299*rf + 0.587*gf + 0.114*bf, rf, gf, bf
+}
+
+func (a *Analysis) verdict() Verdict {
+	if a.Mean >= overexposedMinMean {
+		return VerdictOverexposed
+	}
+
+	if a.StdDev > uniformMaxStdDev {
+		return VerdictOK
+	}
+
+	if a.MeanB-a.MeanR >= blueMinDominance && a.MeanB-a.MeanG >= blueMinDominance {
+		return VerdictBlue
+	}
+
+	if a.Mean <= blackMaxMean {
+		return VerdictBlack
+	}
+
+	return VerdictUniform
+}
+
+// Difference returns the mean absolute difference of the thumbnails of two
+// snapshots. It returns +Inf when the snapshots cannot be compared.
+func Difference(a, b *Analysis) float64 {
+	if a == nil || b == nil || len(a.Thumbnail) != len(b.Thumbnail) || len(a.Thumbnail) == 0 {
+		return math.Inf(1)
+	}
+
+	var diff float64
+	for i := range a.Thumbnail {
+		diff += math.Abs(a.Thumbnail[i] - b.Thumbnail[i])
+	}
+
+	return diff / float64(len(a.Thumbnail))
+}
+
+// Frozen reports whether two consecutive captures show the same image. Live
+// cameras always differ slightly because of the sensor noise.
+func Frozen(a, b *Analysis) bool {
+	return Difference(a, b) <= frozenMaxDiff
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,143 @@
+package snapshot
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyze(t *testing.T) {
+	t.Run("should recognize black frame", func(t *testing.T) {
+		a, err := Analyze(encodeTestJPEG(t, uniformImage(color.RGBA{5, 5, 5, 255})))
+
+		require.NoError(t, err)
+		require.Equal(t, VerdictBlack, a.Verdict)
+		require.Equal(t, 352, a.Width)
+		require.Equal(t, 288, a.Height)
+	})
+
+	t.Run("should recognize blue no video frame", func(t *testing.T) {
+		a, err := Analyze(encodeTestJPEG(t, uniformImage(color.RGBA{0, 0, 200, 255})))
+
+		require.NoError(t, err)
+		require.Equal(t, VerdictBlue, a.Verdict)
+	})
+
+	t.Run("should recognize overexposed frame", func(t *testing.T) {
+		a, err := Analyze(encodeTestJPEG(t, uniformImage(color.RGBA{250, 250, 250, 255})))
+
+		require.NoError(t, err)
+		require.Equal(t, VerdictOverexposed, a.Verdict)
+	})
+
+	t.Run("should recognize uniform gray frame", func(t *testing.T) {
+		a, err := Analyze(encodeTestJPEG(t, uniformImage(color.RGBA{120, 120, 120, 255})))
+
+		require.NoError(t, err)
+		require.Equal(t, VerdictUniform, a.Verdict)
+	})
+
+	t.Run("should accept frame with details", func(t *testing.T) {
+		a, err := Analyze(encodeTestJPEG(t, patternImage(0)))
+
+		require.NoError(t, err)
+		require.Equal(t, VerdictOK, a.Verdict)
+	})
+
+	t.Run("should return error for invalid JPEG", func(t *testing.T) {
+		_, err := Analyze([]byte("not a JPEG"))
+
+		require.Error(t, err)
+	})
+}
+
+func TestFrozen(t *testing.T) {
+	t.Run("should report the same captures as frozen", func(t *testing.T) {
+		a := AnalyzeImage(patternImage(0))
+		b := AnalyzeImage(patternImage(0))
+
+		require.True(t, Frozen(a, b))
+	})
+
+	t.Run("should not report different captures as frozen", func(t *testing.T) {
+		a := AnalyzeImage(patternImage(0))
+		b := AnalyzeImage(patternImage(60))
+
+		require.False(t, Frozen(a, b))
+	})
+
+	t.Run("should report the same JPEG captures as frozen", func(t *testing.T) {
+		data := encodeTestJPEG(t, staticScene(rand.New(rand.NewSource(1)), 2))
+		a, err := Analyze(data)
+		require.NoError(t, err)
+		b, err := Analyze(data)
+		require.NoError(t, err)
+
+		require.True(t, Frozen(a, b))
+	})
+
+	t.Run("should not report static scene with faint sensor noise as frozen", func(t *testing.T) {
+		r := rand.New(rand.NewSource(1))
+		for _, sigma := range []float64{0.5, 1, 2} {
+			a, err := Analyze(encodeTestJPEG(t, staticScene(r, sigma)))
+			require.NoError(t, err)
+			b, err := Analyze(encodeTestJPEG(t, staticScene(r, sigma)))
+			require.NoError(t, err)
+
+			require.False(t, Frozen(a, b), "sensor noise %g", sigma)
+		}
+	})
+
+	t.Run("should not report missing captures as frozen", func(t *testing.T) {
+		require.False(t, Frozen(AnalyzeImage(patternImage(0)), nil))
+	})
+}
+
+func uniformImage(c color.Color) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 352, 288))
+	for y := 0; y < 288; y++ {
+		for x := 0; x < 352; x++ {
+			img.Set(x, y, c)
+		}
+	}
+
+	return img
+}
+
+func patternImage(shift int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 352, 288))
+	for y := 0; y < 288; y++ {
+		for x := 0; x < 352; x++ {
+			img.Set(x, y, color.RGBA{uint8(x + shift), uint8(y + shift), uint8(x * y % 255), 255})
+		}
+	}
+
+	return img
+}
+
+// staticScene returns the D1 frame of the static scene, the same for all
+// calls, with the gaussian sensor noise of the given standard deviation.
+func staticScene(r *rand.Rand, sigma float64) image.Image {
+	img := image.NewGray(image.Rect(0, 0, 704, 576))
+	for y := 0; y < 576; y++ {
+		for x := 0; x < 704; x++ {
+			v := 40 + float64((x/40+y/30)%5)*30 + r.NormFloat64()*sigma
+			img.SetGray(x, y, color.Gray{uint8(math.Max(0, math.Min(255, v)))})
+		}
+	}
+
+	return img
+}
+
+func encodeTestJPEG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, nil))
+
+	return buf.Bytes()
+}
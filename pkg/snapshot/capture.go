@@ -0,0 +1,114 @@
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+)
+
+type Fetcher interface {
+	Fetch(chn int, dst io.Writer) error
+}
+
+type ChannelResult struct {
+	Channel int
+	Verdict Verdict `json:",omitempty"`
+	Mean    float64
+	StdDev  float64
+	Frozen  bool
+	Error   string `json:",omitempty"`
+	// FrozenError is the error of the second capture, the frozen image is
+	// not checked then.
+	FrozenError string `json:",omitempty"`
+}
+
+func (r *ChannelResult) IsOK() bool {
+	return r.Problem() == ""
+}
+
+// Problem describes why the channel is not OK, it returns empty string for
+// the OK channel.
+func (r *ChannelResult) Problem() string {
+	switch {
+	case r.Error != "":
+		return "snapshot error: " + r.Error
+	case r.Verdict != VerdictOK:
+		return fmt.Sprintf("%s image (mean %.0f, stddev %.1f)", r.Verdict, r.Mean, r.StdDev)
+	case r.Frozen:
+		return "frozen image"
+	case r.FrozenError != "":
+		return "frozen check error: " + r.FrozenError
+	default:
+		return ""
+	}
+}
+
+// CheckChannel captures the snapshot of the channel and analyses it. When the
+// frozenInterval is not zero, it captures the second snapshot after the
+// interval and compares both of them. It returns the first capture as well.
+func CheckChannel(client Fetcher, ch int, frozenInterval time.Duration) (ChannelResult, []byte) {
+	results, data := CheckChannels(client, []int{ch}, frozenInterval)
+
+	return results[0], data[0]
+}
+
+// CheckChannels checks the channels like CheckChannel, but it captures the
+// second snapshots of all channels after the single interval, so the check
+// of the DVR does not take the interval per channel.
+func CheckChannels(client Fetcher, channels []int, frozenInterval time.Duration) ([]ChannelResult, [][]byte) {
+	results := make([]ChannelResult, len(channels))
+	data := make([][]byte, len(channels))
+	firsts := make([]*Analysis, len(channels))
+
+	for i, ch := range channels {
+		results[i].Channel = ch
+
+		var err error
+		data[i], firsts[i], err = capture(client, ch)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+
+		results[i].Verdict = firsts[i].Verdict
+		results[i].Mean = firsts[i].Mean
+		results[i].StdDev = firsts[i].StdDev
+	}
+
+	if frozenInterval == 0 {
+		return results, data
+	}
+
+	time.Sleep(frozenInterval)
+
+	for i, ch := range channels {
+		if firsts[i] == nil {
+			continue
+		}
+
+		_, second, err := capture(client, ch)
+		if err != nil {
+			results[i].FrozenError = err.Error()
+			continue
+		}
+
+		results[i].Frozen = Frozen(firsts[i], second)
+	}
+
+	return results, data
+}
+
+func capture(client Fetcher, ch int) ([]byte, *Analysis, error) {
+	var buf bytes.Buffer
+	if err := client.Fetch(ch, &buf); err != nil {
+		return nil, nil, err
+	}
+
+	analysis, err := Analyze(buf.Bytes())
+	if err != nil {
+		return buf.Bytes(), nil, err
+	}
+
+	return buf.Bytes(), analysis, nil
+}
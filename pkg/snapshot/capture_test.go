@@ -0,0 +1,130 @@
+package snapshot
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fetcherMock struct {
+	frames   [][]byte
+	calls    int
+	channels []int
+	err      error
+	callErrs map[int]error
+}
+
+func (f *fetcherMock) Fetch(chn int, dst io.Writer) error {
+	if f.err != nil {
+		return f.err
+	}
+
+	frame := f.frames[f.calls%len(f.frames)]
+	err := f.callErrs[f.calls]
+	f.calls++
+	f.channels = append(f.channels, chn)
+	if err != nil {
+		return err
+	}
+
+	_, err = dst.Write(frame)
+
+	return err
+}
+
+func TestCheckChannel(t *testing.T) {
+	t.Run("should analyse single capture when frozen check is disabled", func(t *testing.T) {
+		fetcher := &fetcherMock{frames: [][]byte{encodeTestJPEG(t, uniformImage(color.RGBA{0, 0, 0, 255}))}}
+
+		result, data := CheckChannel(fetcher, 3, 0)
+
+		require.Equal(t, 1, fetcher.calls)
+		require.Equal(t, 3, result.Channel)
+		require.Equal(t, VerdictBlack, result.Verdict)
+		require.False(t, result.IsOK())
+		require.Equal(t, fetcher.frames[0], data)
+	})
+
+	t.Run("should report frozen channel", func(t *testing.T) {
+		fetcher := &fetcherMock{frames: [][]byte{encodeTestJPEG(t, patternImage(0))}}
+
+		result, _ := CheckChannel(fetcher, 0, time.Millisecond)
+
+		require.Equal(t, 2, fetcher.calls)
+		require.True(t, result.Frozen)
+		require.False(t, result.IsOK())
+		require.Equal(t, "frozen image", result.Problem())
+	})
+
+	t.Run("should accept live channel", func(t *testing.T) {
+		fetcher := &fetcherMock{frames: [][]byte{
+			encodeTestJPEG(t, patternImage(0)),
+			encodeTestJPEG(t, patternImage(60)),
+		}}
+
+		result, _ := CheckChannel(fetcher, 0, time.Millisecond)
+
+		require.True(t, result.IsOK())
+	})
+
+	t.Run("should report fetch error", func(t *testing.T) {
+		fetcher := &fetcherMock{err: fmt.Errorf("connection refused")}
+
+		result, _ := CheckChannel(fetcher, 0, 0)
+
+		require.Equal(t, "connection refused", result.Error)
+		require.Equal(t, "snapshot error: connection refused", result.Problem())
+	})
+}
+
+func TestCheckChannels(t *testing.T) {
+	t.Run("should capture second snapshots of all channels after single interval", func(t *testing.T) {
+		fetcher := &fetcherMock{frames: [][]byte{encodeTestJPEG(t, patternImage(0))}}
+
+		start := time.Now()
+		results, data := CheckChannels(fetcher, []int{0, 1, 2}, 50*time.Millisecond)
+
+		require.Less(t, int64(time.Since(start)), int64(100*time.Millisecond))
+		require.Equal(t, []int{0, 1, 2, 0, 1, 2}, fetcher.channels)
+		require.Len(t, results, 3)
+		require.Len(t, data, 3)
+		for i, result := range results {
+			require.Equal(t, i, result.Channel)
+			require.True(t, result.Frozen)
+		}
+	})
+
+	t.Run("should report failed second capture separately", func(t *testing.T) {
+		fetcher := &fetcherMock{
+			frames:   [][]byte{encodeTestJPEG(t, patternImage(0)), encodeTestJPEG(t, patternImage(60)), encodeTestJPEG(t, patternImage(0))},
+			callErrs: map[int]error{2: fmt.Errorf("connection reset")},
+		}
+
+		results, _ := CheckChannels(fetcher, []int{0, 1}, time.Millisecond)
+
+		require.Empty(t, results[0].Error)
+		require.Equal(t, "connection reset", results[0].FrozenError)
+		require.False(t, results[0].Frozen)
+		require.Equal(t, VerdictOK, results[0].Verdict)
+		require.Equal(t, "frozen check error: connection reset", results[0].Problem())
+		require.True(t, results[1].IsOK())
+	})
+
+	t.Run("should not capture second snapshot of failed channel", func(t *testing.T) {
+		fetcher := &fetcherMock{
+			frames:   [][]byte{encodeTestJPEG(t, patternImage(0))},
+			callErrs: map[int]error{0: fmt.Errorf("connection refused")},
+		}
+
+		results, data := CheckChannels(fetcher, []int{0, 1}, time.Millisecond)
+
+		require.Equal(t, []int{0, 1, 1}, fetcher.channels)
+		require.Equal(t, "connection refused", results[0].Error)
+		require.Nil(t, data[0])
+		require.True(t, results[1].Frozen)
+	})
+}
@@ -0,0 +1,95 @@
+package snapshot
+
+import (
+	"math"
+	"math/bits"
+)
+
+type TamperVerdict string
+
+const (
+	TamperOK        TamperVerdict = "ok"
+	TamperMoved     TamperVerdict = "moved"
+	TamperBlocked   TamperVerdict = "blocked"
+	TamperDefocused TamperVerdict = "defocused"
+)
+
+const (
+	DefaultMinSimilarity = 0.8
+
+	defocusedMaxSharpness = 0.5
+)
+
+// Comparison holds the result of comparing the snapshot with the baseline
+// snapshot of the channel. The Similarity is in 0-1 range, where 1 means the
+// same view.
+type Comparison struct {
+	Similarity     float64
+	HashDistance   int
+	Correlation    float64
+	SharpnessRatio float64
+	Verdict        TamperVerdict
+}
+
+// Compare checks whether the current snapshot shows the same view as the
+// baseline. The similarity combines the perceptual hash distance with the
+// correlation of the thumbnails, so it tolerates the sensor noise and the
+// changes of the brightness during the day. Below minSimilarity the camera is
+// considered moved.
+func Compare(baseline, current *Analysis, minSimilarity float64) Comparison {
+	result := Comparison{
+		HashDistance: bits.OnesCount64(baseline.Hash ^ current.Hash),
+		Correlation:  correlation(baseline.Thumbnail, current.Thumbnail),
+	}
+
+	hashSimilarity := 1 - float64(result.HashDistance)/float64(hashSize*hashSize)
+	result.Similarity = (hashSimilarity + math.Max(result.Correlation, 0)) / 2
+
+	if baseline.Sharpness > 0 {
+		result.SharpnessRatio = current.Sharpness / baseline.Sharpness
+	}
+
+	switch {
+	case current.Verdict != VerdictOK:
+		result.Verdict = TamperBlocked
+	case result.Similarity < minSimilarity:
+		result.Verdict = TamperMoved
+	case baseline.Sharpness > 0 && result.SharpnessRatio < defocusedMaxSharpness:
+		result.Verdict = TamperDefocused
+	default:
+		result.Verdict = TamperOK
+	}
+
+	return result
+}
+
+// correlation returns the Pearson correlation coefficient of the thumbnails.
+// It returns 0 when the thumbnails cannot be compared or one of them is
+// uniform.
+func correlation(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	n := float64(len(a))
+	var sumA, sumB float64
+	for i := range a {
+		sumA += a[i]
+		sumB += b[i]
+	}
+	meanA, meanB := sumA/n, sumB/n
+
+	var cov, varA, varB float64
+	for i := range a {
+		da, db := a[i]-meanA, b[i]-meanB
+		cov += da * db
+		varA += da * da
+		varB += db * db
+	}
+
+	if varA == 0 || varB == 0 {
+		return 0
+	}
+
+	return cov / math.Sqrt(varA*varB)
+}
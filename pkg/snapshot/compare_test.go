@@ -0,0 +1,99 @@
+package snapshot
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompare(t *testing.T) {
+	baseline := AnalyzeImage(sceneImage(0, 0))
+
+	t.Run("should accept the same view", func(t *testing.T) {
+		result := Compare(baseline, AnalyzeImage(sceneImage(0, 0)), DefaultMinSimilarity)
+
+		require.Equal(t, TamperOK, result.Verdict)
+		require.Equal(t, 0, result.HashDistance)
+		require.InDelta(t, 1, result.Similarity, 0.001)
+	})
+
+	t.Run("should accept the same view with different brightness", func(t *testing.T) {
+		result := Compare(baseline, AnalyzeImage(sceneImage(0, 40)), DefaultMinSimilarity)
+
+		require.Equal(t, TamperOK, result.Verdict)
+	})
+
+	t.Run("should recognize moved camera", func(t *testing.T) {
+		result := Compare(baseline, AnalyzeImage(sceneImage(150, 0)), DefaultMinSimilarity)
+
+		require.Equal(t, TamperMoved, result.Verdict)
+		require.Less(t, result.Similarity, DefaultMinSimilarity)
+	})
+
+	t.Run("should recognize blocked camera", func(t *testing.T) {
+		result := Compare(baseline, AnalyzeImage(uniformImage(color.RGBA{5, 5, 5, 255})), DefaultMinSimilarity)
+
+		require.Equal(t, TamperBlocked, result.Verdict)
+	})
+
+	t.Run("should recognize defocused camera", func(t *testing.T) {
+		result := Compare(baseline, AnalyzeImage(blurImage(sceneImage(0, 0), 12)), DefaultMinSimilarity)
+
+		require.Equal(t, TamperDefocused, result.Verdict)
+		require.Less(t, result.SharpnessRatio, 0.5)
+	})
+}
+
+// sceneImage draws the blocks shifted horizontally by offset pixels, the
+// brightness is added to all pixels. The periods of the blocks are not
+// multiples of the sampling step, so their edges are sampled.
+func sceneImage(offset int, brightness uint8) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 352, 288))
+	for y := 0; y < 288; y++ {
+		for x := 0; x < 352; x++ {
+			v := uint8(40)
+			sx := x + offset
+			switch {
+			case sx%117 < 53 && y%97 < 41:
+				v = 200
+			case sx%71 < 19:
+				v = 120
+			}
+
+			img.Set(x, y, color.RGBA{v + brightness/2, v + brightness/2, v + brightness/2, 255})
+		}
+	}
+
+	return img
+}
+
+// blurImage applies the box blur of the gray image in both directions.
+func blurImage(src image.Image, radius int) image.Image {
+	return boxBlur(boxBlur(src, radius, 1, 0), radius, 0, 1)
+}
+
+func boxBlur(src image.Image, radius, stepX, stepY int) image.Image {
+	bounds := src.Bounds()
+	img := image.NewRGBA(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			var sum, n uint32
+			for d := -radius; d <= radius; d++ {
+				p := image.Pt(x+d*stepX, y+d*stepY)
+				if !p.In(bounds) {
+					continue
+				}
+				r, _, _, _ := src.At(p.X, p.Y).RGBA()
+				sum += r >> 8
+				n++
+			}
+
+			v := uint8(sum / n)
+			img.Set(x, y, color.RGBA{v, v, v, 255})
+		}
+	}
+
+	return img
+}
@@ -0,0 +1,101 @@
+package snapshot
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+)
+
+const (
+	glyphWidth   = 5
+	glyphHeight  = 7
+	glyphSpacing = 1
+)
+
+// glyphs is the 5x7 bitmap font. Every byte is one column of the glyph, the
+// least significant bit is the top row.
+var glyphs = map[rune][glyphWidth]byte{
+	' ': {0x00, 0x00, 0x00, 0x00, 0x00},
+	'(': {0x00, 0x1c, 0x22, 0x41, 0x00},
+	')': {0x00, 0x41, 0x22, 0x1c, 0x00},
+	'-': {0x08, 0x08, 0x08, 0x08, 0x08},
+	'.': {0x00, 0x60, 0x60, 0x00, 0x00},
+	'/': {0x20, 0x10, 0x08, 0x04, 0x02},
+	'0': {0x3e, 0x51, 0x49, 0x45, 0x3e},
+	'1': {0x00, 0x42, 0x7f, 0x40, 0x00},
+	'2': {0x42, 0x61, 0x51, 0x49, 0x46},
+	'3': {0x21, 0x41, 0x45, 0x4b, 0x31},
+	'4': {0x18, 0x14, 0x12, 0x7f, 0x10},
+	'5': {0x27, 0x45, 0x45, 0x45, 0x39},
+	'6': {0x3c, 0x4a, 0x49, 0x49, 0x30},
+	'7': {0x01, 0x71, 0x09, 0x05, 0x03},
+	'8': {0x36, 0x49, 0x49, 0x49, 0x36},
+	'9': {0x06, 0x49, 0x49, 0x29, 0x1e},
+	':': {0x00, 0x36, 0x36, 0x00, 0x00},
+	'?': {0x02, 0x01, 0x51, 0x09, 0x06},
+	'A': {0x7e, 0x11, 0x11, 0x11, 0x7e},
+	'B': {0x7f, 0x49, 0x49, 0x49, 0x36},
+	'C': {0x3e, 0x41, 0x41, 0x41, 0x22},
+	'D': {0x7f, 0x41, 0x41, 0x22, 0x1c},
+	'E': {0x7f, 0x49, 0x49, 0x49, 0x41},
+	'F': {0x7f, 0x09, 0x09, 0x01, 0x01},
+	'G': {0x3e, 0x41, 0x49, 0x49, 0x7a},
+	'H': {0x7f, 0x08, 0x08, 0x08, 0x7f},
+	'I': {0x00, 0x41, 0x7f, 0x41, 0x00},
+	'J': {0x20, 0x40, 0x41, 0x3f, 0x01},
+	'K': {0x7f, 0x08, 0x14, 0x22, 0x41},
+	'L': {0x7f, 0x40, 0x40, 0x40, 0x40},
+	'M': {0x7f, 0x02, 0x04, 0x02, 0x7f},
+	'N': {0x7f, 0x04, 0x08, 0x10, 0x7f},
+	'O': {0x3e, 0x41, 0x41, 0x41, 0x3e},
+	'P': {0x7f, 0x09, 0x09, 0x09, 0x06},
+	'Q': {0x3e, 0x41, 0x51, 0x21, 0x5e},
+	'R': {0x7f, 0x09, 0x19, 0x29, 0x46},
+	'S': {0x46, 0x49, 0x49, 0x49, 0x31},
+	'T': {0x01, 0x01, 0x7f, 0x01, 0x01},
+	'U': {0x3f, 0x40, 0x40, 0x40, 0x3f},
+	'V': {0x1f, 0x20, 0x40, 0x20, 0x1f},
+	'W': {0x7f, 0x20, 0x18, 0x20, 0x7f},
+	'X': {0x63, 0x14, 0x08, 0x14, 0x63},
+	'Y': {0x03, 0x04, 0x78, 0x04, 0x03},
+	'Z': {0x61, 0x51, 0x49, 0x45, 0x43},
+	'_': {0x40, 0x40, 0x40, 0x40, 0x40},
+}
+
+// textWidth returns the width of the text drawn with the scale.
+func textWidth(text string, scale int) int {
+	n := len([]rune(text))
+	if n == 0 {
+		return 0
+	}
+
+	return (n*(glyphWidth+glyphSpacing) - glyphSpacing) * scale
+}
+
+// drawText draws the text with the top left corner at the point. The lower
+// case letters are drawn as upper case, the unknown characters as '?'.
+func drawText(dst draw.Image, pt image.Point, text string, scale int, c color.Color) {
+	src := image.NewUniform(c)
+	x := pt.X
+
+	for _, r := range strings.ToUpper(text) {
+		glyph, ok := glyphs[r]
+		if !ok {
+			glyph = glyphs['?']
+		}
+
+		for col := 0; col < glyphWidth; col++ {
+			for row := 0; row < glyphHeight; row++ {
+				if glyph[col]&(1<<uint(row)) == 0 {
+					continue
+				}
+
+				px := image.Rect(0, 0, scale, scale).Add(image.Pt(x+col*scale, pt.Y+row*scale))
+				draw.Draw(dst, px, src, image.Point{}, draw.Src)
+			}
+		}
+
+		x += (glyphWidth + glyphSpacing) * scale
+	}
+}
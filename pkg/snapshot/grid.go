@@ -0,0 +1,49 @@
+package snapshot
+
+// grid holds the luminance of the pixels sampled from the snapshot.
+type grid struct {
+	cols   int
+	rows   int
+	values []float64
+}
+
+// resample returns the box averaged grid of the given size.
+func (g *grid) resample(cols, rows int) []float64 {
+	result := make([]float64, cols*rows)
+	counts := make([]int, cols*rows)
+
+	for y := 0; y < g.rows; y++ {
+		for x := 0; x < g.cols; x++ {
+			cell := y*rows/g.rows*cols + x*cols/g.cols
+			result[cell] += g.values[y*g.cols+x]
+			counts[cell]++
+		}
+	}
+
+	for i := range result {
+		if counts[i] > 0 {
+			result[i] /= float64(counts[i])
+		}
+	}
+
+	return result
+}
+
+// differenceHash computes the perceptual dHash of the grid with hashSize+1
+// columns and hashSize rows. Every bit tells whether the luminance grows
+// between the neighbouring cells of the row.
+func differenceHash(values []float64) uint64 {
+	var hash uint64
+	cols := hashSize + 1
+
+	for y := 0; y < hashSize; y++ {
+		for x := 0; x < hashSize; x++ {
+			hash <<= 1
+			if values[y*cols+x] < values[y*cols+x+1] {
+				hash |= 1
+			}
+		}
+	}
+
+	return hash
+}
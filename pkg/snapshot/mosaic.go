@@ -0,0 +1,110 @@
+package snapshot
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+const (
+	defaultTileWidth = 352
+	labelPadding     = 4
+	offlineText      = "OFFLINE"
+)
+
+var (
+	labelBackground   = color.RGBA{0, 0, 0, 160}
+	labelColor        = color.RGBA{255, 255, 255, 255}
+	offlineBackground = color.RGBA{48, 48, 48, 255}
+	offlineColor      = color.RGBA{220, 60, 60, 255}
+)
+
+// Tile is the channel snapshot placed in the mosaic. The nil Image is drawn
+// as the offline placeholder.
+type Tile struct {
+	Label string
+	Image image.Image
+}
+
+type MosaicConfig struct {
+	Columns   int // 0 - the smallest square grid
+	TileWidth int // 0 - the width of the D1 snapshot
+}
+
+// Mosaic composes the tiles into the grid image. The tiles are scaled to the
+// same size, the height is given by the aspect ratio of the first snapshot.
+func Mosaic(tiles []Tile, cfg MosaicConfig) *image.RGBA {
+	columns := cfg.Columns
+	if columns <= 0 {
+		columns = int(math.Ceil(math.Sqrt(float64(len(tiles)))))
+	}
+	columns = maxInt(columns, 1)
+	rows := maxInt((len(tiles)+columns-1)/columns, 1)
+
+	tileWidth := cfg.TileWidth
+	if tileWidth <= 0 {
+		tileWidth = defaultTileWidth
+	}
+	tileHeight := tileWidth * 3 / 4
+	for _, tile := range tiles {
+		if tile.Image != nil && tile.Image.Bounds().Dx() > 0 {
+			b := tile.Image.Bounds()
+			tileHeight = tileWidth * b.Dy() / b.Dx()
+			break
+		}
+	}
+
+	scale := maxInt(tileWidth/200, 1)
+	mosaic := image.NewRGBA(image.Rect(0, 0, columns*tileWidth, rows*tileHeight))
+
+	for i, tile := range tiles {
+		rect := image.Rect(0, 0, tileWidth, tileHeight).
+			Add(image.Pt(i%columns*tileWidth, i/columns*tileHeight))
+
+		if tile.Image != nil {
+			drawScaled(mosaic, rect, tile.Image)
+		} else {
+			drawOffline(mosaic, rect, scale)
+		}
+
+		drawLabel(mosaic, rect, tile.Label, scale)
+	}
+
+	return mosaic
+}
+
+// drawScaled draws the image scaled to the rectangle with the nearest
+// neighbour sampling.
+func drawScaled(dst *image.RGBA, rect image.Rectangle, src image.Image) {
+	sb := src.Bounds()
+	for y := 0; y < rect.Dy(); y++ {
+		sy := sb.Min.Y + y*sb.Dy()/rect.Dy()
+		for x := 0; x < rect.Dx(); x++ {
+			sx := sb.Min.X + x*sb.Dx()/rect.Dx()
+			dst.Set(rect.Min.X+x, rect.Min.Y+y, src.At(sx, sy))
+		}
+	}
+}
+
+func drawOffline(dst *image.RGBA, rect image.Rectangle, scale int) {
+	draw.Draw(dst, rect, image.NewUniform(offlineBackground), image.Point{}, draw.Src)
+
+	textScale := scale * 2
+	pt := image.Pt(
+		rect.Min.X+(rect.Dx()-textWidth(offlineText, textScale))/2,
+		rect.Min.Y+(rect.Dy()-glyphHeight*textScale)/2)
+	drawText(dst.SubImage(rect).(*image.RGBA), pt, offlineText, textScale, offlineColor)
+}
+
+func drawLabel(dst *image.RGBA, rect image.Rectangle, label string, scale int) {
+	if label == "" {
+		return
+	}
+
+	bar := image.Rect(0, 0, textWidth(label, scale)+2*labelPadding, glyphHeight*scale+2*labelPadding).
+		Add(rect.Min).Intersect(rect)
+	draw.Draw(dst, bar, image.NewUniform(labelBackground), image.Point{}, draw.Over)
+	// the long label is clipped to the tile
+	drawText(dst.SubImage(rect).(*image.RGBA), rect.Min.Add(image.Pt(labelPadding, labelPadding)), label, scale, labelColor)
+}
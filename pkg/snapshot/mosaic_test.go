@@ -0,0 +1,70 @@
+package snapshot
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMosaic(t *testing.T) {
+	t.Run("should compose tiles into square grid", func(t *testing.T) {
+		tiles := make([]Tile, 5)
+		for i := range tiles {
+			tiles[i].Image = uniformImage(color.RGBA{0, 0, 200, 255})
+		}
+
+		img := Mosaic(tiles, MosaicConfig{TileWidth: 176})
+
+		require.Equal(t, image.Rect(0, 0, 3*176, 2*144), img.Bounds())
+		require.Equal(t, color.RGBA{0, 0, 200, 255}, img.RGBAAt(100, 100))
+		// the empty cell of the grid stays transparent
+		require.Equal(t, color.RGBA{}, img.RGBAAt(2*176+10, 144+10))
+	})
+
+	t.Run("should use the number of columns", func(t *testing.T) {
+		img := Mosaic(make([]Tile, 4), MosaicConfig{Columns: 4, TileWidth: 100})
+
+		require.Equal(t, image.Rect(0, 0, 400, 75), img.Bounds())
+	})
+
+	t.Run("should draw offline placeholder", func(t *testing.T) {
+		img := Mosaic([]Tile{{Label: "CH1"}}, MosaicConfig{TileWidth: 352})
+
+		require.Equal(t, offlineBackground, img.RGBAAt(5, 260))
+		require.True(t, containsColor(img, img.Bounds(), offlineColor))
+	})
+
+	t.Run("should draw label in the tile corner", func(t *testing.T) {
+		tiles := []Tile{
+			{Label: "CH1 2020-06-01 12:00:00", Image: uniformImage(color.RGBA{0, 0, 0, 255})},
+			{Image: uniformImage(color.RGBA{0, 0, 0, 255})},
+		}
+
+		img := Mosaic(tiles, MosaicConfig{Columns: 2, TileWidth: 352})
+
+		require.True(t, containsColor(img, image.Rect(0, 0, 352, 30), labelColor))
+		require.False(t, containsColor(img, image.Rect(352, 0, 704, 30), labelColor))
+	})
+}
+
+func TestTextWidth(t *testing.T) {
+	t.Run("should measure text without trailing spacing", func(t *testing.T) {
+		require.Equal(t, 0, textWidth("", 2))
+		require.Equal(t, 10, textWidth("A", 2))
+		require.Equal(t, 22, textWidth("AB", 2))
+	})
+}
+
+func containsColor(img *image.RGBA, rect image.Rectangle, c color.RGBA) bool {
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			if img.RGBAAt(x, y) == c {
+				return true
+			}
+		}
+	}
+
+	return false
+}
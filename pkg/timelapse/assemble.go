@@ -0,0 +1,60 @@
+package timelapse
+
+import (
+	"bytes"
+	"fmt"
+	"image/jpeg"
+	"io"
+	"io/ioutil"
+
+	"github.com/crabtree/defeway-toolbox/pkg/mjpeg"
+)
+
+// Assemble writes the frames into the Motion-JPEG AVI. The frame size is
+// taken from the first frame, the frames which cannot be decoded or have a
+// different size are skipped. It returns the number of written frames.
+func Assemble(frames []Frame, dst io.WriteSeeker, fps int) (int, error) {
+	var w *mjpeg.Writer
+	var width, height int
+
+	for _, frame := range frames {
+		data, err := ioutil.ReadFile(frame.Path)
+		if err != nil {
+			return framesOf(w), err
+		}
+
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			continue
+		}
+
+		if w == nil {
+			width, height = cfg.Width, cfg.Height
+			if w, err = mjpeg.NewWriter(dst, width, height, fps); err != nil {
+				return 0, err
+			}
+		}
+
+		if cfg.Width != width || cfg.Height != height {
+			continue
+		}
+
+		if err := w.WriteFrame(data); err != nil {
+			return w.Frames(), err
+		}
+	}
+
+	if w == nil {
+		return 0, fmt.Errorf("no valid frames to assemble")
+	}
+
+	return w.Frames(), w.Close()
+}
+
+func framesOf(w *mjpeg.Writer) int {
+	if w == nil {
+		return 0
+	}
+
+	return w.Frames()
+}
@@ -0,0 +1,62 @@
+package timelapse
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssemble(t *testing.T) {
+	dir, err := ioutil.TempDir("", "timelapse")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store := &Store{Dir: dir}
+	base := time.Date(2020, 6, 1, 12, 0, 0, 0, time.Local)
+
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 64, 48)), nil))
+	for i := 0; i < 3; i++ {
+		_, err := store.Save(1, base.Add(time.Duration(i)*time.Minute), buf.Bytes())
+		require.NoError(t, err)
+	}
+	_, err = store.Save(1, base.Add(time.Hour), []byte("broken"))
+	require.NoError(t, err)
+
+	t.Run("should assemble valid frames into AVI", func(t *testing.T) {
+		frames, err := store.Frames(1, time.Time{}, time.Time{})
+		require.NoError(t, err)
+
+		f, err := os.Create(path.Join(dir, "ch-1.avi"))
+		require.NoError(t, err)
+		defer f.Close()
+
+		written, err := Assemble(frames, f, 10)
+
+		require.NoError(t, err)
+		require.Equal(t, 3, written)
+
+		data, err := ioutil.ReadFile(f.Name())
+		require.NoError(t, err)
+		require.Equal(t, "RIFF", string(data[0:4]))
+		require.Equal(t, uint32(64), binary.LittleEndian.Uint32(data[64:68]))
+	})
+
+	t.Run("should return error when there are no frames", func(t *testing.T) {
+		f, err := os.Create(path.Join(dir, "empty.avi"))
+		require.NoError(t, err)
+		defer f.Close()
+
+		_, err = Assemble(nil, f, 10)
+
+		require.Error(t, err)
+	})
+}
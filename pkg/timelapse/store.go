@@ -0,0 +1,104 @@
+package timelapse
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const frameTimeLayout = "20060102-150405"
+
+// Frame is the snapshot stored in the time-lapse directory.
+type Frame struct {
+	Time time.Time
+	Path string
+}
+
+// Store keeps the timestamped snapshots in the directory per channel, like
+// <dir>/ch-1/20200601-120000.jpg. The frames are named with the time in the
+// Location, the local time zone when nil.
+type Store struct {
+	Dir      string
+	Location *time.Location
+}
+
+func (s *Store) location() *time.Location {
+	if s.Location == nil {
+		return time.Local
+	}
+
+	return s.Location
+}
+
+func (s *Store) channelDir(ch int) string {
+	return path.Join(s.Dir, fmt.Sprintf("ch-%d", ch))
+}
+
+func (s *Store) Save(ch int, t time.Time, data []byte) (string, error) {
+	dir := s.channelDir(ch)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", err
+	}
+
+	fp := path.Join(dir, t.In(s.location()).Format(frameTimeLayout)+".jpg")
+
+	return fp, ioutil.WriteFile(fp, data, 0644)
+}
+
+// Frames returns the frames of the channel captured in the [from, to) range
+// ordered by the capture time. The zero from or to does not limit the range.
+func (s *Store) Frames(ch int, from, to time.Time) ([]Frame, error) {
+	files, err := ioutil.ReadDir(s.channelDir(ch))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var frames []Frame
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || !strings.HasSuffix(name, ".jpg") {
+			continue
+		}
+
+		t, err := time.ParseInLocation(frameTimeLayout, strings.TrimSuffix(name, ".jpg"), s.location())
+		if err != nil {
+			continue
+		}
+
+		if (!from.IsZero() && t.Before(from)) || (!to.IsZero() && !t.Before(to)) {
+			continue
+		}
+
+		frames = append(frames, Frame{Time: t, Path: path.Join(s.channelDir(ch), name)})
+	}
+
+	sort.Slice(frames, func(i, j int) bool {
+		return frames[i].Time.Before(frames[j].Time)
+	})
+
+	return frames, nil
+}
+
+// Prune removes the frames of the channel captured before the given time
+// and returns the number of removed frames.
+func (s *Store) Prune(ch int, before time.Time) (int, error) {
+	frames, err := s.Frames(ch, time.Time{}, before)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, frame := range frames {
+		if err := os.Remove(frame.Path); err != nil {
+			return i, err
+		}
+	}
+
+	return len(frames), nil
+}
@@ -0,0 +1,72 @@
+package timelapse
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "timelapse")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store := &Store{Dir: dir}
+	base := time.Date(2020, 6, 1, 12, 0, 0, 0, time.Local)
+
+	for _, offset := range []time.Duration{2 * time.Hour, 0, time.Hour} {
+		_, err := store.Save(1, base.Add(offset), []byte("jpeg"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, "ch-1", "notes.txt"), nil, 0644))
+
+	t.Run("should list frames ordered by capture time", func(t *testing.T) {
+		frames, err := store.Frames(1, time.Time{}, time.Time{})
+
+		require.NoError(t, err)
+		require.Len(t, frames, 3)
+		require.True(t, frames[0].Time.Equal(base))
+		require.Equal(t, path.Join(dir, "ch-1", "20200601-120000.jpg"), frames[0].Path)
+		require.True(t, frames[2].Time.Equal(base.Add(2*time.Hour)))
+	})
+
+	t.Run("should list frames within the range", func(t *testing.T) {
+		frames, err := store.Frames(1, base.Add(time.Hour), base.Add(2*time.Hour))
+
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+	})
+
+	t.Run("should return no frames of unknown channel", func(t *testing.T) {
+		frames, err := store.Frames(2, time.Time{}, time.Time{})
+
+		require.NoError(t, err)
+		require.Empty(t, frames)
+	})
+
+	t.Run("should prune old frames", func(t *testing.T) {
+		removed, err := store.Prune(1, base.Add(90*time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, 2, removed)
+
+		frames, err := store.Frames(1, time.Time{}, time.Time{})
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+	})
+
+	t.Run("should name frames with time in the store location", func(t *testing.T) {
+		store := &Store{Dir: dir, Location: time.FixedZone("UTC+2", 2*60*60)}
+
+		fp, err := store.Save(3, time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC), []byte("jpeg"))
+		require.NoError(t, err)
+		require.Equal(t, path.Join(dir, "ch-3", "20200601-120000.jpg"), fp)
+
+		frames, err := store.Frames(3, time.Time{}, time.Time{})
+		require.NoError(t, err)
+		require.True(t, frames[0].Time.Equal(time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)))
+	})
+}